@@ -0,0 +1,175 @@
+package cafesdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCircuitOpen is returned without an RPC while the circuit breaker is
+// open. See SetCircuitBreaker.
+var ErrCircuitOpen = errors.New("cafesdk: circuit breaker open, platform calls suspended")
+
+const (
+	defaultBreakerFailures = 5
+	defaultBreakerWindow   = time.Minute
+	defaultBreakerCooldown = 30 * time.Second
+)
+
+// CircuitBreakerOptions tunes the circuit breaker. Zero values select the
+// defaults.
+type CircuitBreakerOptions struct {
+	// Failures is the number of consecutive transient failures that opens
+	// the breaker; it defaults to 5.
+	Failures int
+	// Window bounds how far apart those failures may be; a failure after
+	// a longer gap starts a new count. It defaults to one minute.
+	Window time.Duration
+	// Cooldown is how long the breaker stays open before letting a trial
+	// call through; it defaults to 30 seconds.
+	Cooldown time.Duration
+}
+
+var breakerOptions atomic.Pointer[CircuitBreakerOptions]
+
+// SetCircuitBreaker makes every Client stop calling a platform that keeps
+// failing. After opts.Failures consecutive transient failures (see
+// IsTransient) the Client's breaker opens and its calls fail at once with
+// ErrCircuitOpen. Once opts.Cooldown has passed, one trial call is let
+// through: if it succeeds the breaker closes, and if it fails transiently
+// the breaker opens for another cooldown. Passing nil, the default, turns the breaker off.
+func SetCircuitBreaker(opts *CircuitBreakerOptions) {
+	if opts == nil {
+		breakerOptions.Store(nil)
+		return
+	}
+	o := *opts
+	if o.Failures <= 0 {
+		o.Failures = defaultBreakerFailures
+	}
+	if o.Window <= 0 {
+		o.Window = defaultBreakerWindow
+	}
+	if o.Cooldown <= 0 {
+		o.Cooldown = defaultBreakerCooldown
+	}
+	breakerOptions.Store(&o)
+}
+
+// CircuitState is the state of a Client's circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets calls through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails calls with ErrCircuitOpen.
+	CircuitOpen
+	// CircuitHalfOpen has let a trial call through and fails others until
+	// it completes.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// BreakerState returns the state of the default Client's circuit breaker.
+func BreakerState() CircuitState {
+	return defaultClient.BreakerState()
+}
+
+// BreakerState returns the state of the Client's circuit breaker, which is
+// CircuitClosed while SetCircuitBreaker is off.
+func (c *Client) BreakerState() CircuitState {
+	if breakerOptions.Load() == nil {
+		return CircuitClosed
+	}
+	c.breaker.mu.Lock()
+	defer c.breaker.mu.Unlock()
+	return c.breaker.state
+}
+
+type circuitBreaker struct {
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	first    time.Time
+	openedAt time.Time
+}
+
+// allow reports whether a call may proceed, moving an open breaker whose
+// cooldown has passed to half-open for the trial call.
+func (b *circuitBreaker) allow() bool {
+	opts := breakerOptions.Load()
+	if opts == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if clockSince(b.openedAt) < opts.Cooldown {
+			return false
+		}
+		b.state = CircuitHalfOpen
+		return true
+	case CircuitHalfOpen:
+		return false
+	}
+	return true
+}
+
+// record updates the breaker with the outcome of a call allow let through,
+// made with the caller's ctx. Only a success closes it. A call that the
+// caller or the SDK ended, by cancelling ctx, letting its deadline pass,
+// shutting down or closing, says nothing about the platform: it changes no
+// count, and a half-open trial ended that way reopens the breaker with its
+// cooldown already over, so the next call is the trial. Any other answer
+// from the platform breaks a run of transient failures but does not prove
+// it recovered, so it does not close a half-open breaker either.
+func (b *circuitBreaker) record(ctx context.Context, err error) {
+	opts := breakerOptions.Load()
+	if opts == nil {
+		return
+	}
+	inconclusive := err != nil && (ctx.Err() != nil ||
+		errors.Is(err, context.Canceled) || errors.Is(err, ErrShutdown) || errors.Is(err, ErrClosed))
+	failed := !inconclusive && IsTransient(err)
+	now := clockNow()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case err == nil:
+		b.state, b.failures = CircuitClosed, 0
+	case b.state == CircuitHalfOpen && failed:
+		b.state, b.openedAt = CircuitOpen, now
+	case b.state == CircuitHalfOpen:
+		b.state = CircuitOpen
+	case inconclusive:
+	case !failed:
+		b.failures = 0
+	default:
+		if b.failures == 0 || now.Sub(b.first) > opts.Window {
+			b.failures, b.first = 0, now
+		}
+		b.failures++
+		if b.failures >= opts.Failures {
+			b.state, b.openedAt, b.failures = CircuitOpen, now, 0
+		}
+	}
+}
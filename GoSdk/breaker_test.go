@@ -0,0 +1,159 @@
+package cafesdk_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	cafesdk "test/GoSdk"
+	"test/GoSdk/cafesdktest"
+)
+
+func useCircuitBreaker(t *testing.T, opts cafesdk.CircuitBreakerOptions) {
+	t.Helper()
+	cafesdk.SetCircuitBreaker(&opts)
+	t.Cleanup(func() { cafesdk.SetCircuitBreaker(nil) })
+}
+
+// tripBreaker fails PushData until the breaker opens.
+func tripBreaker(t *testing.T, client *cafesdk.Client, srv *cafesdktest.Server, failures int) {
+	t.Helper()
+	srv.Fail(cafesdk.MethodPushData, status.Error(codes.Unavailable, "down"))
+	for i := 0; i < failures; i++ {
+		if _, err := client.Result.PushData(context.Background(), `{}`); !errors.Is(err, cafesdk.ErrUnavailable) {
+			t.Fatalf("push %d: err = %v, want ErrUnavailable", i+1, err)
+		}
+	}
+	if got := client.BreakerState(); got != cafesdk.CircuitOpen {
+		t.Fatalf("after %d failures state = %v, want open", failures, got)
+	}
+}
+
+func TestBreakerTripsAndRecovers(t *testing.T) {
+	clock := useFakeClock(t)
+	useCircuitBreaker(t, cafesdk.CircuitBreakerOptions{Failures: 3, Cooldown: 10 * time.Second})
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+
+	tripBreaker(t, client, srv, 3)
+	if _, err := client.Result.PushData(ctx, `{}`); !errors.Is(err, cafesdk.ErrCircuitOpen) {
+		t.Fatalf("open breaker: err = %v, want ErrCircuitOpen", err)
+	}
+	if n := len(srv.CallsTo(cafesdk.MethodPushData)); n != 3 {
+		t.Errorf("server saw %d pushes, want 3: the open breaker must not call it", n)
+	}
+
+	srv.Fail(cafesdk.MethodPushData, nil)
+	clock.Advance(10 * time.Second)
+	if _, err := client.Result.PushData(ctx, `{"trial":true}`); err != nil {
+		t.Fatalf("trial call: %v", err)
+	}
+	if got := client.BreakerState(); got != cafesdk.CircuitClosed {
+		t.Errorf("after a successful trial state = %v, want closed", got)
+	}
+	if got := srv.Data(); len(got) != 1 || got[0] != `{"trial":true}` {
+		t.Errorf("Data() = %q, want the trial record", got)
+	}
+}
+
+func TestBreakerReopensOnFailedTrial(t *testing.T) {
+	clock := useFakeClock(t)
+	useCircuitBreaker(t, cafesdk.CircuitBreakerOptions{Failures: 2, Cooldown: 10 * time.Second})
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+
+	tripBreaker(t, client, srv, 2)
+	clock.Advance(10 * time.Second)
+	if _, err := client.Result.PushData(ctx, `{}`); !errors.Is(err, cafesdk.ErrUnavailable) {
+		t.Fatalf("trial call: err = %v, want ErrUnavailable", err)
+	}
+	if got := client.BreakerState(); got != cafesdk.CircuitOpen {
+		t.Fatalf("after a failed trial state = %v, want open", got)
+	}
+
+	clock.Advance(5 * time.Second)
+	if _, err := client.Result.PushData(ctx, `{}`); !errors.Is(err, cafesdk.ErrCircuitOpen) {
+		t.Errorf("within the new cooldown: err = %v, want ErrCircuitOpen", err)
+	}
+}
+
+func TestBreakerWindowResetsCount(t *testing.T) {
+	clock := useFakeClock(t)
+	useCircuitBreaker(t, cafesdk.CircuitBreakerOptions{Failures: 2, Window: time.Minute})
+	client, srv := newTestClient(t)
+	srv.Fail(cafesdk.MethodPushData, status.Error(codes.Unavailable, "down"))
+	ctx := context.Background()
+
+	client.Result.PushData(ctx, `{}`)
+	clock.Advance(2 * time.Minute)
+	client.Result.PushData(ctx, `{}`)
+	if got := client.BreakerState(); got != cafesdk.CircuitClosed {
+		t.Errorf("failures a window apart: state = %v, want closed", got)
+	}
+}
+
+func TestBreakerCancelledTrialKeepsItOpen(t *testing.T) {
+	clock := useFakeClock(t)
+	useCircuitBreaker(t, cafesdk.CircuitBreakerOptions{Failures: 2, Cooldown: 10 * time.Second})
+	client, srv := newTestClient(t)
+
+	tripBreaker(t, client, srv, 2)
+	srv.Fail(cafesdk.MethodPushData, nil)
+	clock.Advance(10 * time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.Result.PushData(ctx, `{}`); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled trial: err = %v, want context.Canceled", err)
+	}
+	if got := client.BreakerState(); got != cafesdk.CircuitOpen {
+		t.Fatalf("after a cancelled trial state = %v, want open", got)
+	}
+
+	// The cancelled trial proved nothing, so the next call is the trial
+	// without waiting another cooldown.
+	if _, err := client.Result.PushData(context.Background(), `{"trial":true}`); err != nil {
+		t.Fatalf("next trial: %v", err)
+	}
+	if got := client.BreakerState(); got != cafesdk.CircuitClosed {
+		t.Errorf("after a successful trial state = %v, want closed", got)
+	}
+}
+
+func TestBreakerCallerDeadlineIsNotAFailure(t *testing.T) {
+	useCircuitBreaker(t, cafesdk.CircuitBreakerOptions{Failures: 2})
+	client, srv := newTestClient(t)
+	srv.Delay(cafesdk.MethodPushData, time.Second)
+
+	for range 3 {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		_, err := client.Result.PushData(ctx, `{}`)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("err = %v, want DeadlineExceeded", err)
+		}
+	}
+	if got := client.BreakerState(); got != cafesdk.CircuitClosed {
+		t.Errorf("after caller deadlines state = %v, want closed", got)
+	}
+}
+
+func TestBreakerRejectedTrialDoesNotClose(t *testing.T) {
+	clock := useFakeClock(t)
+	useCircuitBreaker(t, cafesdk.CircuitBreakerOptions{Failures: 2, Cooldown: 10 * time.Second})
+	client, srv := newTestClient(t)
+
+	tripBreaker(t, client, srv, 2)
+	srv.Fail(cafesdk.MethodPushData, status.Error(codes.InvalidArgument, "bad record"))
+	clock.Advance(10 * time.Second)
+	if _, err := client.Result.PushData(context.Background(), `{}`); !errors.Is(err, cafesdk.ErrInvalidInput) {
+		t.Fatalf("trial: err = %v, want ErrInvalidInput", err)
+	}
+	if got := client.BreakerState(); got == cafesdk.CircuitClosed {
+		t.Errorf("a rejected trial closed the breaker")
+	}
+}
@@ -0,0 +1,174 @@
+package cafesdktest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	cafesdk "test/GoSdk"
+)
+
+// FakeClock is a cafesdk.Clock whose time only moves when Advance is
+// called, so retries, flush intervals and heartbeats can be driven without
+// waiting. Install it with cafesdk.SetClock. It is safe for concurrent use.
+//
+//	clock := cafesdktest.NewFakeClock(time.Now())
+//	cafesdk.SetClock(clock)
+//	t.Cleanup(func() { cafesdk.SetClock(nil) })
+type FakeClock struct {
+	mu      sync.Mutex
+	changed *sync.Cond
+	now     time.Time
+	timers  []*fakeTimer
+	tickers []*fakeTicker
+}
+
+type fakeTimer struct {
+	clock *FakeClock
+	at    time.Time
+	c     chan time.Time
+}
+
+type fakeTicker struct {
+	clock  *FakeClock
+	period time.Duration
+	next   time.Time
+	c      chan time.Time
+}
+
+var _ cafesdk.Clock = (*FakeClock)(nil)
+
+// NewFakeClock returns a FakeClock set to start.
+func NewFakeClock(start time.Time) *FakeClock {
+	c := &FakeClock{now: start}
+	c.changed = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now returns the fake time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTimer returns a Timer that fires once Advance has moved the fake time
+// d past now. A stopped timer no longer counts for BlockUntil.
+func (c *FakeClock) NewTimer(d time.Duration) cafesdk.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTimer{clock: c, at: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.c <- c.now
+		return t
+	}
+	c.timers = append(c.timers, t)
+	c.changed.Broadcast()
+	return t
+}
+
+// After returns a channel that receives the fake time once Advance has
+// moved it d past now. The wait counts for BlockUntil until it fires; use
+// NewTimer for a wait that can be abandoned.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C()
+}
+
+// Sleep blocks until Advance has moved the fake time d past now.
+func (c *FakeClock) Sleep(d time.Duration) {
+	<-c.After(d)
+}
+
+// NewTicker returns a Ticker that ticks each time Advance crosses a
+// multiple of d. Like a time.Ticker it drops ticks the reader is not ready
+// for.
+func (c *FakeClock) NewTicker(d time.Duration) cafesdk.Ticker {
+	if d <= 0 {
+		panic("cafesdktest: non-positive interval for NewTicker")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTicker{clock: c, period: d, next: c.now.Add(d), c: make(chan time.Time, 1)}
+	c.tickers = append(c.tickers, t)
+	c.changed.Broadcast()
+	return t
+}
+
+// Advance moves the fake time forward by d, firing the timers and tickers
+// that come due, in time order.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	sort.Slice(c.timers, func(i, j int) bool { return c.timers[i].at.Before(c.timers[j].at) })
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			pending = append(pending, t)
+			continue
+		}
+		t.c <- t.at
+	}
+	c.timers = pending
+
+	for _, t := range c.tickers {
+		for !t.next.After(c.now) {
+			select {
+			case t.c <- t.next:
+			default:
+			}
+			t.next = t.next.Add(t.period)
+		}
+	}
+}
+
+// BlockUntil waits until at least n timers and tickers are waiting on the
+// clock, so a test can Advance only once the code under test has started
+// waiting, such as a Writer's background flusher after NewWriter.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for len(c.timers)+len(c.tickers) < n {
+		c.changed.Wait()
+	}
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, other := range c.timers {
+		if other == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTicker) Stop() {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, other := range c.tickers {
+		if other == t {
+			c.tickers = append(c.tickers[:i], c.tickers[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package cafesdktest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cafesdk "test/GoSdk"
+	"test/GoSdk/cafesdktest"
+)
+
+func useFakeClock(t *testing.T) *cafesdktest.FakeClock {
+	t.Helper()
+	clock := cafesdktest.NewFakeClock(time.Unix(1_000_000, 0))
+	cafesdk.SetClock(clock)
+	t.Cleanup(func() { cafesdk.SetClock(nil) })
+	return clock
+}
+
+func TestWriterFlushIntervalOnFakeClock(t *testing.T) {
+	clock := useFakeClock(t)
+	srv := cafesdktest.Start(t)
+	client := cafesdk.New(cafesdk.WithAddress(srv.Addr))
+	t.Cleanup(func() { client.Close() })
+
+	w := client.Result.NewWriter(context.Background(), cafesdk.WriterOptions{BatchSize: 100, FlushInterval: time.Hour})
+	defer w.Close()
+	if err := w.Write(`{"n":1}`); err != nil {
+		t.Fatal(err)
+	}
+	clock.BlockUntil(1)
+
+	// The record may reach the flusher after a tick, so keep ticking until
+	// it is flushed; each tick is an hour of fake time and no real wait.
+	deadline := time.Now().Add(5 * time.Second)
+	for len(srv.Data()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("record not flushed by the fake-clock ticker")
+		}
+		clock.Advance(time.Hour)
+		time.Sleep(time.Millisecond)
+	}
+	if got := srv.Data(); len(got) != 1 || got[0] != `{"n":1}` {
+		t.Errorf("Data() = %q", got)
+	}
+}
+
+func TestCancelledSleepReleasesTimer(t *testing.T) {
+	clock := useFakeClock(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	slept := make(chan error)
+	go func() { slept <- cafesdk.Sleep(ctx, time.Hour) }()
+	clock.BlockUntil(1)
+	cancel()
+	if err := <-slept; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Sleep = %v, want context.Canceled", err)
+	}
+
+	waiting := make(chan struct{})
+	go func() {
+		clock.BlockUntil(1)
+		close(waiting)
+	}()
+	select {
+	case <-waiting:
+		t.Fatal("BlockUntil(1) counted the cancelled Sleep")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	timer := clock.NewTimer(time.Minute)
+	defer timer.Stop()
+	<-waiting
+}
+
+func TestTimerFiresOnAdvance(t *testing.T) {
+	clock := cafesdktest.NewFakeClock(time.Unix(0, 0))
+	timer := clock.NewTimer(time.Minute)
+
+	clock.Advance(59 * time.Second)
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired early")
+	default:
+	}
+	clock.Advance(time.Second)
+	select {
+	case at := <-timer.C():
+		if !at.Equal(time.Unix(60, 0)) {
+			t.Errorf("fired at %v, want 60s", at)
+		}
+	default:
+		t.Fatal("timer did not fire")
+	}
+	if timer.Stop() {
+		t.Error("Stop after firing reported true")
+	}
+}
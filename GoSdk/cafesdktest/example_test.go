@@ -0,0 +1,67 @@
+package cafesdktest_test
+
+import (
+	"context"
+	"testing"
+
+	cafesdk "test/GoSdk"
+	"test/GoSdk/cafesdktest"
+)
+
+func TestPushDataReadBack(t *testing.T) {
+	srv := cafesdktest.Start(t)
+	client := cafesdk.New(cafesdk.WithAddress(srv.Addr))
+	t.Cleanup(func() { client.Close() })
+	ctx := context.Background()
+
+	for _, record := range []string{`{"n":1}`, `{"n":2}`} {
+		if _, err := client.Result.PushData(ctx, record); err != nil {
+			t.Fatalf("PushData(%s): %v", record, err)
+		}
+	}
+
+	got := srv.Data()
+	if len(got) != 2 || got[0] != `{"n":1}` || got[1] != `{"n":2}` {
+		t.Errorf("Data() = %q, want both records in order", got)
+	}
+}
+
+func TestInputAndLogsReadBack(t *testing.T) {
+	srv := cafesdktest.Start(t)
+	srv.SetInput(`{"url":"https://example.com"}`)
+	client := cafesdk.New(cafesdk.WithAddress(srv.Addr))
+	t.Cleanup(func() { client.Close() })
+	ctx := context.Background()
+
+	url, err := client.Parameter.GetString(ctx, "url")
+	if err != nil || url != "https://example.com" {
+		t.Fatalf("GetString(url) = %q, %v", url, err)
+	}
+	if _, err := client.Log.Warn(ctx, "careful"); err != nil {
+		t.Fatalf("Warn: %v", err)
+	}
+
+	logs := srv.Logs()
+	if len(logs) != 1 || logs[0].Level != cafesdk.LevelWarn || logs[0].Text != "careful" {
+		t.Errorf("Logs() = %+v, want one Warn line", logs)
+	}
+}
+
+func TestCallsRecordMetadata(t *testing.T) {
+	srv := cafesdktest.Start(t)
+	client := cafesdk.New(cafesdk.WithAddress(srv.Addr))
+	t.Cleanup(func() { client.Close() })
+
+	ctx := cafesdk.WithMetadata(context.Background(), map[string]string{"x-test": "yes"})
+	if _, err := client.Result.PushData(ctx, `{}`); err != nil {
+		t.Fatalf("PushData: %v", err)
+	}
+
+	calls := srv.CallsTo(cafesdk.MethodPushData)
+	if len(calls) != 1 {
+		t.Fatalf("CallsTo(PushData) = %d calls, want 1", len(calls))
+	}
+	if got := calls[0].Metadata.Get("x-test"); len(got) != 1 || got[0] != "yes" {
+		t.Errorf("x-test metadata = %q, want [yes]", got)
+	}
+}
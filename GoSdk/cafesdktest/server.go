@@ -0,0 +1,322 @@
+// Package cafesdktest runs an in-process platform server for testing
+// actors that use cafesdk without a live platform.
+//
+//	srv := cafesdktest.Start(t)
+//	srv.SetInput(`{"url":"https://example.com"}`)
+//	client := cafesdk.New(cafesdk.WithAddress(srv.Addr))
+//	// run the actor against client, then inspect srv.Data(), srv.Header(), srv.Logs() and srv.Calls()
+package cafesdktest
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cafesdk "test/GoSdk"
+
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// LogLine is one log message received by the server.
+type LogLine struct {
+	Level cafesdk.LogLevel
+	Text  string
+}
+
+// Call is one RPC received by the server.
+type Call struct {
+	// Method is one of the cafesdk.Method constants.
+	Method string
+	// Metadata is the incoming gRPC metadata of the call.
+	Metadata metadata.MD
+}
+
+// Server implements the Parameter, Result and Log services and records
+// every call it receives. It is safe for concurrent use.
+type Server struct {
+	cafesdk.UnimplementedParameterServer
+	cafesdk.UnimplementedResultServer
+	cafesdk.UnimplementedLogServer
+
+	// Addr is the address to pass to cafesdk.WithAddress or
+	// cafesdk.SetAddress.
+	Addr string
+
+	opts []grpc.ServerOption
+
+	mu         sync.Mutex
+	grpcServer *grpc.Server
+	input      string
+	data       []string
+	headers    [][]*cafesdk.TableHeaderItem
+	logs       []LogLine
+	calls      []Call
+	failures   map[string]error
+	failFuncs  map[string]func(call int) error
+	handled    map[string]int
+	delays     map[string]time.Duration
+}
+
+// Start serves on a free localhost port until the test ends. opts configure
+// the gRPC server, for example grpc.Creds for TLS.
+func Start(t testing.TB, opts ...grpc.ServerOption) *Server {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cafesdktest: listen: %v", err)
+	}
+
+	s := &Server{Addr: lis.Addr().String(), opts: opts, failures: map[string]error{}, failFuncs: map[string]func(int) error{}, handled: map[string]int{}, delays: map[string]time.Duration{}}
+	s.serve(lis)
+	t.Cleanup(s.Stop)
+	return s
+}
+
+func (s *Server) serve(lis net.Listener) {
+	g := grpc.NewServer(append([]grpc.ServerOption{grpc.ChainUnaryInterceptor(s.record)}, s.opts...)...)
+	cafesdk.RegisterParameterServer(g, s)
+	cafesdk.RegisterResultServer(g, s)
+	cafesdk.RegisterLogServer(g, s)
+
+	s.mu.Lock()
+	s.grpcServer = g
+	s.mu.Unlock()
+	go g.Serve(lis)
+}
+
+// Stop stops serving, closing open connections, as if the platform went
+// away. Recorded calls are kept.
+func (s *Server) Stop() {
+	s.mu.Lock()
+	g := s.grpcServer
+	s.mu.Unlock()
+	g.Stop()
+}
+
+// Restart serves again on Addr after Stop, keeping the recorded calls, the
+// input and the configured failures.
+func (s *Server) Restart() error {
+	lis, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	s.serve(lis)
+	return nil
+}
+
+// SetInput sets the JSON returned by GetInputJSONString.
+func (s *Server) SetInput(inputJSON string) {
+	s.mu.Lock()
+	s.input = inputJSON
+	s.mu.Unlock()
+}
+
+// Fail makes every call to method (one of the cafesdk.Method constants)
+// return err, until Fail is called again with a nil error.
+func (s *Server) Fail(method string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err == nil {
+		delete(s.failures, method)
+		return
+	}
+	s.failures[method] = err
+}
+
+// FailFunc makes a call to method fail with the error fn returns, given the
+// call's number for that method starting at 1, and succeed when it returns
+// nil; "fail twice, then succeed" is fn returning an error for call <= 2.
+// It takes precedence over Fail. A nil fn removes it.
+func (s *Server) FailFunc(method string, fn func(call int) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if fn == nil {
+		delete(s.failFuncs, method)
+		return
+	}
+	s.failFuncs[method] = fn
+}
+
+// failureLocked counts a call to method and returns the error it should
+// fail with, if any. s.mu must be held.
+func (s *Server) failureLocked(method string) error {
+	s.handled[method]++
+	if fn := s.failFuncs[method]; fn != nil {
+		return fn(s.handled[method])
+	}
+	return s.failures[method]
+}
+
+// Delay makes every call to method wait d, or until the caller gives up,
+// before it is handled, to simulate a slow platform. A zero d removes the
+// delay.
+func (s *Server) Delay(method string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if d <= 0 {
+		delete(s.delays, method)
+		return
+	}
+	s.delays[method] = d
+}
+
+// Data returns the pushed records in the order received.
+func (s *Server) Data() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.data...)
+}
+
+// Header returns the most recently set table header, or nil.
+func (s *Server) Header() []*cafesdk.TableHeaderItem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.headers) == 0 {
+		return nil
+	}
+	return cloneHeader(s.headers[len(s.headers)-1])
+}
+
+// HeaderCalls returns how many times SetTableHeader was called.
+func (s *Server) HeaderCalls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.headers)
+}
+
+// Logs returns the log messages in the order received.
+func (s *Server) Logs() []LogLine {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]LogLine(nil), s.logs...)
+}
+
+// Calls returns every call received, failed ones included, in order, with
+// its metadata.
+func (s *Server) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]Call, len(s.calls))
+	for i, call := range s.calls {
+		out[i] = Call{Method: call.Method, Metadata: call.Metadata.Copy()}
+	}
+	return out
+}
+
+// CallsTo returns the calls received for method, in order.
+func (s *Server) CallsTo(method string) []Call {
+	var out []Call
+	for _, call := range s.Calls() {
+		if call.Method == method {
+			out = append(out, call)
+		}
+	}
+	return out
+}
+
+// record notes each call and applies its delay before the handler runs.
+func (s *Server) record(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	method := methodName(info.FullMethod)
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.mu.Lock()
+	s.calls = append(s.calls, Call{Method: method, Metadata: md.Copy()})
+	delay := s.delays[method]
+	s.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return handler(ctx, req)
+}
+
+// methodName turns "/cafesdk.Result/PushData" into "Result.PushData".
+func methodName(fullMethod string) string {
+	return strings.Replace(strings.TrimPrefix(fullMethod, "/cafesdk."), "/", ".", 1)
+}
+
+func (s *Server) GetInputJSONString(context.Context, *emptypb.Empty) (*cafesdk.InputJSONStringResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.failureLocked(cafesdk.MethodGetInputJSONString); err != nil {
+		return nil, err
+	}
+	return &cafesdk.InputJSONStringResponse{JsonString: s.input}, nil
+}
+
+func (s *Server) SetTableHeader(_ context.Context, in *cafesdk.TableHeader) (*cafesdk.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.failureLocked(cafesdk.MethodSetTableHeader); err != nil {
+		return nil, err
+	}
+	s.headers = append(s.headers, cloneHeader(in.GetHeaders()))
+	return ok(), nil
+}
+
+func (s *Server) PushData(_ context.Context, in *cafesdk.Data) (*cafesdk.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.failureLocked(cafesdk.MethodPushData); err != nil {
+		return nil, err
+	}
+	s.data = append(s.data, in.GetJsonString())
+	return ok(), nil
+}
+
+func (s *Server) Debug(_ context.Context, in *cafesdk.LogBody) (*cafesdk.Response, error) {
+	return s.log(cafesdk.MethodLogDebug, cafesdk.LevelDebug, in)
+}
+
+func (s *Server) Info(_ context.Context, in *cafesdk.LogBody) (*cafesdk.Response, error) {
+	return s.log(cafesdk.MethodLogInfo, cafesdk.LevelInfo, in)
+}
+
+func (s *Server) Warn(_ context.Context, in *cafesdk.LogBody) (*cafesdk.Response, error) {
+	return s.log(cafesdk.MethodLogWarn, cafesdk.LevelWarn, in)
+}
+
+func (s *Server) Error(_ context.Context, in *cafesdk.LogBody) (*cafesdk.Response, error) {
+	return s.log(cafesdk.MethodLogError, cafesdk.LevelError, in)
+}
+
+func (s *Server) log(method string, level cafesdk.LogLevel, in *cafesdk.LogBody) (*cafesdk.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.failureLocked(method); err != nil {
+		return nil, err
+	}
+	s.logs = append(s.logs, LogLine{Level: level, Text: in.GetLog()})
+	return ok(), nil
+}
+
+func ok() *cafesdk.Response {
+	return &cafesdk.Response{Code: 200, Message: "ok"}
+}
+
+func cloneHeader(items []*cafesdk.TableHeaderItem) []*cafesdk.TableHeaderItem {
+	out := make([]*cafesdk.TableHeaderItem, len(items))
+	for i, item := range items {
+		out[i] = proto.Clone(item).(*cafesdk.TableHeaderItem)
+	}
+	return out
+}
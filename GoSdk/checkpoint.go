@@ -0,0 +1,120 @@
+package cafesdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	checkpointDirEnv     = "CAFE_CHECKPOINT_DIR"
+	defaultCheckpointDir = ".cafesdk/checkpoints"
+)
+
+// CheckpointStore persists checkpoint state by key.
+type CheckpointStore interface {
+	Save(ctx context.Context, key string, state []byte) error
+	// Load reports false when no checkpoint exists for key.
+	Load(ctx context.Context, key string) ([]byte, bool, error)
+}
+
+// FileCheckpointStore keeps each checkpoint in its own file under Dir.
+type FileCheckpointStore struct {
+	Dir string
+}
+
+func (s FileCheckpointStore) Save(_ context.Context, key string, state []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return fmt.Errorf("cafesdk: create checkpoint dir: %w", err)
+	}
+
+	// Write to a temporary file first so a crash never leaves a torn
+	// checkpoint behind.
+	tmp, err := os.CreateTemp(s.Dir, ".checkpoint-*")
+	if err != nil {
+		return fmt.Errorf("cafesdk: save checkpoint %q: %w", key, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(state); err != nil {
+		tmp.Close()
+		return fmt.Errorf("cafesdk: save checkpoint %q: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("cafesdk: save checkpoint %q: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("cafesdk: save checkpoint %q: %w", key, err)
+	}
+	return nil
+}
+
+func (s FileCheckpointStore) Load(_ context.Context, key string) ([]byte, bool, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, false, err
+	}
+	state, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("cafesdk: load checkpoint %q: %w", key, err)
+	}
+	return state, true, nil
+}
+
+func (s FileCheckpointStore) path(key string) (string, error) {
+	name := url.PathEscape(key)
+	if name == "" || name == "." || name == ".." {
+		return "", fmt.Errorf("cafesdk: invalid checkpoint key %q", key)
+	}
+	return filepath.Join(s.Dir, name), nil
+}
+
+var (
+	checkpointMu    sync.RWMutex
+	checkpointStore CheckpointStore
+)
+
+// SetCheckpointStore replaces the store used by SaveCheckpoint and
+// LoadCheckpoint. By default checkpoints are files under CAFE_CHECKPOINT_DIR,
+// or .cafesdk/checkpoints in the working directory.
+func SetCheckpointStore(store CheckpointStore) {
+	checkpointMu.Lock()
+	checkpointStore = store
+	checkpointMu.Unlock()
+}
+
+func currentCheckpointStore() CheckpointStore {
+	checkpointMu.RLock()
+	defer checkpointMu.RUnlock()
+
+	if checkpointStore != nil {
+		return checkpointStore
+	}
+	dir := os.Getenv(checkpointDirEnv)
+	if dir == "" {
+		dir = defaultCheckpointDir
+	}
+	return FileCheckpointStore{Dir: dir}
+}
+
+// SaveCheckpoint stores state under key, replacing any previous state.
+func SaveCheckpoint(ctx context.Context, key string, state []byte) error {
+	return currentCheckpointStore().Save(ctx, key, state)
+}
+
+// LoadCheckpoint returns the state saved under key, reporting false when
+// there is none.
+func LoadCheckpoint(ctx context.Context, key string) ([]byte, bool, error) {
+	return currentCheckpointStore().Load(ctx, key)
+}
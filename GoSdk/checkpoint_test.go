@@ -0,0 +1,59 @@
+package cafesdk_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	cafesdk "test/GoSdk"
+)
+
+func TestCheckpointRoundTrip(t *testing.T) {
+	t.Setenv("CAFE_CHECKPOINT_DIR", t.TempDir())
+	ctx := context.Background()
+
+	for _, state := range [][]byte{[]byte(`{"page":9000}`), {0, 0xff, '\n', 0x80}, {}} {
+		if err := cafesdk.SaveCheckpoint(ctx, "crawl/state", state); err != nil {
+			t.Fatalf("SaveCheckpoint: %v", err)
+		}
+		got, ok, err := cafesdk.LoadCheckpoint(ctx, "crawl/state")
+		if err != nil || !ok || !bytes.Equal(got, state) {
+			t.Errorf("LoadCheckpoint = %q, %v, %v; want %q, true, nil", got, ok, err, state)
+		}
+	}
+}
+
+func TestCheckpointMissing(t *testing.T) {
+	t.Setenv("CAFE_CHECKPOINT_DIR", t.TempDir())
+
+	got, ok, err := cafesdk.LoadCheckpoint(context.Background(), "never-saved")
+	if err != nil || ok || got != nil {
+		t.Errorf("LoadCheckpoint = %q, %v, %v; want nil, false, nil", got, ok, err)
+	}
+}
+
+func TestCheckpointLeavesNoTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	store := cafesdk.FileCheckpointStore{Dir: dir}
+	if err := store.Save(context.Background(), "k", []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "k" {
+		t.Errorf("checkpoint dir holds %v, want only k", entries)
+	}
+}
+
+func TestCheckpointInvalidKey(t *testing.T) {
+	store := cafesdk.FileCheckpointStore{Dir: t.TempDir()}
+	for _, key := range []string{"", ".", ".."} {
+		if err := store.Save(context.Background(), key, nil); err == nil {
+			t.Errorf("Save(%q) succeeded, want an invalid key error", key)
+		}
+	}
+}
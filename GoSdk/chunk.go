@@ -0,0 +1,95 @@
+package cafesdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+const defaultChunkSize = 100
+
+// ChunkOptions tunes PushChunks. Zero values select the defaults.
+type ChunkOptions struct {
+	// Size is the number of records per chunk; it defaults to 100.
+	Size int
+	// Retry controls how a failed chunk is retried on transient errors. A
+	// zero RetryPolicy selects DefaultRetryPolicy.
+	Retry RetryPolicy
+}
+
+// ChunkResult reports what PushChunks delivered. Chunk indices count from
+// 0 in the order the chunks were cut from the slice.
+type ChunkResult struct {
+	Succeeded []int
+	Failed    []int
+	// Accepted is the number of records delivered, including those from
+	// the delivered part of a failed chunk.
+	Accepted int
+	// Last is the platform's response to the last record of the last chunk
+	// delivered in full.
+	Last *Response
+}
+
+// PushChunks pushes items in chunks of opts.Size and reports which chunks
+// were delivered. A chunk that fails is retried on its own: records it
+// already delivered are not resent, and neither are earlier chunks. Later
+// chunks are still attempted after one fails, until ctx is done; chunks
+// not started by then are reported as failed. The returned error joins the
+// error of every failed chunk.
+//
+// Chunks are sent one after another, each waiting for the previous one, so
+// a slow platform holds back the caller rather than piling up calls.
+func (r _Result) PushChunks(ctx context.Context, items []string, opts ChunkOptions) (*ChunkResult, error) {
+	if opts.Size <= 0 {
+		opts.Size = defaultChunkSize
+	}
+	if opts.Retry.isZero() {
+		opts.Retry = DefaultRetryPolicy
+	}
+
+	res := &ChunkResult{}
+	var errs []error
+	for i, start := 0, 0; start < len(items); i, start = i+1, start+opts.Size {
+		chunk := items[start:min(start+opts.Size, len(items))]
+
+		err := ctx.Err()
+		if err == nil {
+			err = r.pushChunk(ctx, chunk, opts.Retry, res)
+		}
+		if err != nil {
+			res.Failed = append(res.Failed, i)
+			errs = append(errs, fmt.Errorf("chunk %d: %w", i, err))
+			continue
+		}
+		res.Succeeded = append(res.Succeeded, i)
+	}
+
+	if len(errs) > 0 {
+		return res, fmt.Errorf("cafesdk: %d of %d chunks failed: %w", len(res.Failed), len(res.Failed)+len(res.Succeeded), errors.Join(errs...))
+	}
+	return res, nil
+}
+
+// pushChunk sends chunk record by record, resending only its undelivered
+// tail on each retry under policy, and adds what it delivered to res. The
+// records are sent without the SetRetryPolicy retries, which would multiply
+// the attempts.
+func (r _Result) pushChunk(ctx context.Context, chunk []string, policy RetryPolicy, res *ChunkResult) error {
+	sent := 0
+	_, err := withRetry(ctx, r.c, policy, func(ctx context.Context) (*Response, error) {
+		last, err := r.pushBatch(ctx, chunk[sent:], false)
+		if err != nil {
+			var batchErr *BatchError
+			if errors.As(err, &batchErr) {
+				sent += batchErr.Accepted
+				err = batchErr.Err
+			}
+			return nil, err
+		}
+		sent = len(chunk)
+		res.Last = last
+		return last, nil
+	})
+	res.Accepted += sent
+	return err
+}
@@ -0,0 +1,70 @@
+package cafesdk_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	cafesdk "test/GoSdk"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestPushChunksResendsOnlyFailedChunk(t *testing.T) {
+	client, srv := newTestClient(t)
+	// The fifth push, the second record of chunk 1, fails once.
+	srv.FailFunc(cafesdk.MethodPushData, func(call int) error {
+		if call == 5 {
+			return status.Error(codes.Unavailable, "blip")
+		}
+		return nil
+	})
+	items := records(9)
+
+	res, err := client.Result.PushChunks(context.Background(), items, cafesdk.ChunkOptions{
+		Size:  3,
+		Retry: cafesdk.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("PushChunks: %v", err)
+	}
+	if !slices.Equal(res.Succeeded, []int{0, 1, 2}) || len(res.Failed) != 0 || res.Accepted != 9 || res.Last == nil {
+		t.Errorf("result = %+v, want every chunk delivered", res)
+	}
+	if got := srv.Data(); !equalStrings(got, items) {
+		t.Errorf("Data() = %q, want each record once, in order", got)
+	}
+	if n := len(srv.CallsTo(cafesdk.MethodPushData)); n != 10 {
+		t.Errorf("%d pushes, want 9 plus the one resend", n)
+	}
+}
+
+func TestPushChunksDoesNotNestRetries(t *testing.T) {
+	useRetryPolicy(t, cafesdk.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+	client, srv := newTestClient(t)
+	// Everything after the fourth push fails.
+	srv.FailFunc(cafesdk.MethodPushData, func(call int) error {
+		if call > 4 {
+			return status.Error(codes.Unavailable, "down")
+		}
+		return nil
+	})
+
+	res, err := client.Result.PushChunks(context.Background(), records(9), cafesdk.ChunkOptions{
+		Size:  3,
+		Retry: cafesdk.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond},
+	})
+	if err == nil {
+		t.Fatal("PushChunks succeeded against a failing server")
+	}
+	if !slices.Equal(res.Succeeded, []int{0}) || !slices.Equal(res.Failed, []int{1, 2}) || res.Accepted != 4 {
+		t.Errorf("result = %+v, want chunk 0 and one record of chunk 1 delivered", res)
+	}
+	// Chunks 1 and 2 get the chunk policy's 2 attempts each, not 2 times
+	// the SetRetryPolicy policy's 3.
+	if n := len(srv.CallsTo(cafesdk.MethodPushData)); n != 8 {
+		t.Errorf("%d pushes, want 4 + 2 + 2", n)
+	}
+}
@@ -0,0 +1,81 @@
+package cafesdk
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Clock is the time source behind the SDK's time-based logic: retry and
+// rate limiter waits, heartbeats, the periodic flushes of Writer and log
+// buffering, Log.Every, the circuit breaker, proxy cooldowns, progress
+// throttling and log mirror timestamps. Tests can replace it with a fake,
+// such as cafesdktest.FakeClock, through SetClock. Context deadlines, the
+// SDK's call timeouts and gRPC itself keep using real time, as does the
+// cookie jar; DoWithRetry does read the clock when it checks whether a
+// backoff would outlast the request's deadline.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+	After(d time.Duration) <-chan time.Time
+	Sleep(d time.Duration)
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is the single-shot timer a Clock returns. Stop reports whether it
+// stopped the timer before it fired.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// Ticker is the ticker a Clock returns.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) NewTimer(d time.Duration) Timer         { return realTimer{time.NewTimer(d)} }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (realClock) NewTicker(d time.Duration) Ticker       { return realTicker{time.NewTicker(d)} }
+
+type realTimer struct{ t *time.Timer }
+
+func (t realTimer) C() <-chan time.Time { return t.t.C }
+func (t realTimer) Stop() bool          { return t.t.Stop() }
+
+type realTicker struct{ t *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time { return t.t.C }
+func (t realTicker) Stop()               { t.t.Stop() }
+
+var activeClock atomic.Pointer[Clock]
+
+// SetClock replaces the SDK's Clock; nil restores the real one. Set it
+// before starting the Writers, heartbeats or log buffering that should use
+// it: those keep the ticker they started with.
+func SetClock(c Clock) {
+	if c == nil {
+		activeClock.Store(nil)
+		return
+	}
+	activeClock.Store(&c)
+}
+
+func currentClock() Clock {
+	if c := activeClock.Load(); c != nil {
+		return *c
+	}
+	return realClock{}
+}
+
+func clockNow() time.Time {
+	return currentClock().Now()
+}
+
+func clockSince(t time.Time) time.Duration {
+	return clockNow().Sub(t)
+}
@@ -0,0 +1,97 @@
+package cafesdk
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+var headerCoalescing atomic.Bool
+
+// SetHeaderCoalescing makes SetTableHeader record the header locally instead
+// of sending it, so repeated calls cost one RPC: the latest header is sent
+// by Result.FlushHeader or Close. Headers are still validated immediately,
+// and PushRow sees them at once. Named datasets are not coalesced.
+func SetHeaderCoalescing(enabled bool) {
+	headerCoalescing.Store(enabled)
+}
+
+// pendingHeader is a Client's locally built header, sent by FlushHeader.
+type pendingHeader struct {
+	mu      sync.Mutex
+	items   []*TableHeaderItem
+	version int // bumped on every change
+	sent    int // version last sent
+	// warnings are logged by the FlushHeader that sends the header they
+	// are about, so building it locally never dials the platform.
+	warnings []string
+}
+
+// AddColumn appends item to the header built locally for the default
+// dataset, replacing a column that has the same key. The header is sent by
+// FlushHeader or Close, so several AddColumn calls cost one RPC; a replaced
+// column is reported by a warning logged with it.
+func (r _Result) AddColumn(item *TableHeaderItem) {
+	p := &r.c.pending
+	p.mu.Lock()
+	replaced := false
+	for i, existing := range p.items {
+		if existing.GetKey() == item.GetKey() {
+			p.items[i], replaced = item, true
+			break
+		}
+	}
+	if !replaced {
+		p.items = append(p.items, item)
+	}
+	if replaced {
+		p.warnings = append(p.warnings, fmt.Sprintf("cafesdk: table header column %q added twice; the later definition wins", item.GetKey()))
+	}
+	p.version++
+	items := append([]*TableHeaderItem(nil), p.items...)
+	p.mu.Unlock()
+
+	r.c.header.trackHeader(items)
+}
+
+// FlushHeader sends the header built with AddColumn or recorded by a
+// coalesced SetTableHeader, if it changed since it was last sent. With
+// nothing to send it returns an empty Response without an RPC.
+func (r _Result) FlushHeader(ctx context.Context) (*Response, error) {
+	p := &r.c.pending
+	p.mu.Lock()
+	if p.version == p.sent {
+		p.mu.Unlock()
+		return &Response{}, nil
+	}
+	items, version := append([]*TableHeaderItem(nil), p.items...), p.version
+	warnings := p.warnings
+	p.warnings = nil
+	p.mu.Unlock()
+
+	res, err := r.sendTableHeader(ctx, "", items, "", false)
+	if err != nil {
+		p.mu.Lock()
+		p.warnings = append(warnings, p.warnings...)
+		p.mu.Unlock()
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.sent = max(p.sent, version)
+	p.mu.Unlock()
+	for _, text := range warnings {
+		r.c.Log.Warn(ctx, text)
+	}
+	return res, nil
+}
+
+// coalesce records headers as the pending header, replacing any built so
+// far.
+func (p *pendingHeader) coalesce(headers []*TableHeaderItem) {
+	p.mu.Lock()
+	p.items = append([]*TableHeaderItem(nil), headers...)
+	p.version++
+	p.mu.Unlock()
+}
@@ -0,0 +1,109 @@
+package cafesdk_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	cafesdk "test/GoSdk"
+)
+
+func column(key string) *cafesdk.TableHeaderItem {
+	return &cafesdk.TableHeaderItem{Key: key, Label: key, Format: cafesdk.FormatText}
+}
+
+func TestAddColumnSendsOneHeader(t *testing.T) {
+	client, srv := newTestClient(t)
+
+	for _, key := range []string{"a", "b", "c"} {
+		client.Result.AddColumn(column(key))
+	}
+	if srv.HeaderCalls() != 0 {
+		t.Fatalf("AddColumn sent %d headers before FlushHeader", srv.HeaderCalls())
+	}
+	if _, err := client.Result.FlushHeader(context.Background()); err != nil {
+		t.Fatalf("FlushHeader: %v", err)
+	}
+	if _, err := client.Result.FlushHeader(context.Background()); err != nil {
+		t.Fatalf("second FlushHeader: %v", err)
+	}
+
+	if srv.HeaderCalls() != 1 {
+		t.Errorf("header RPCs = %d, want 1", srv.HeaderCalls())
+	}
+	if got := srv.Header(); len(got) != 3 || got[2].GetKey() != "c" {
+		t.Errorf("header = %v, want columns a, b, c", got)
+	}
+}
+
+func TestCloseSendsPendingHeaderOnce(t *testing.T) {
+	client, srv := newTestClient(t)
+	client.Result.AddColumn(column("a"))
+
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = client.Close()
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("Close #%d: %v", i, err)
+		}
+	}
+	if err := client.Close(); err != nil {
+		t.Errorf("Close after Close: %v", err)
+	}
+	if srv.HeaderCalls() != 1 {
+		t.Errorf("header RPCs = %d, want 1", srv.HeaderCalls())
+	}
+}
+
+func TestAddColumnDuplicateWarnsOnFlush(t *testing.T) {
+	client, srv := newTestClient(t)
+	cafesdk.UseDefaultClient(t, client)
+
+	client.Result.AddColumn(column("a"))
+	client.Result.AddColumn(&cafesdk.TableHeaderItem{Key: "a", Label: "A", Format: cafesdk.FormatLink})
+	if n := len(srv.Calls()); n != 0 {
+		t.Fatalf("AddColumn made %d RPCs", n)
+	}
+	if err := cafesdk.SetAddress(srv.Addr); err != nil {
+		t.Fatalf("SetAddress after a duplicate AddColumn: %v", err)
+	}
+
+	if _, err := client.Result.FlushHeader(context.Background()); err != nil {
+		t.Fatalf("FlushHeader: %v", err)
+	}
+	logs := srv.Logs()
+	if len(logs) != 1 || logs[0].Level != cafesdk.LevelWarn || !strings.Contains(logs[0].Text, `column "a" added twice`) {
+		t.Errorf("Logs() = %+v, want one Warn about the duplicate column", logs)
+	}
+	if h := srv.Header(); len(h) != 1 || h[0].Label != "A" {
+		t.Errorf("Header() = %v, want the later definition of a", h)
+	}
+
+	client.Result.FlushHeader(context.Background())
+	if n := len(srv.Logs()); n != 1 {
+		t.Errorf("got %d log lines after a second FlushHeader, want the warning once", n)
+	}
+}
+
+func TestAddColumnDuplicateDoesNotBlock(t *testing.T) {
+	// The duplicate warning must not dial: this Client's address has no
+	// server behind it.
+	client := cafesdk.New(cafesdk.WithAddress("127.0.0.1:1"))
+	t.Cleanup(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		client.Shutdown(ctx)
+	})
+
+	client.Result.AddColumn(column("a"))
+	client.Result.AddColumn(column("a"))
+}
@@ -0,0 +1,38 @@
+package cafesdk
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/status"
+)
+
+// gzipFallback compresses calls until the server rejects the encoding.
+type gzipFallback struct {
+	unsupported atomic.Bool
+}
+
+func (g *gzipFallback) intercept(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if g.unsupported.Load() {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	err := invoker(ctx, method, req, reply, cc, append(opts, grpc.UseCompressor(gzip.Name))...)
+	if !isEncodingRejected(err) {
+		return err
+	}
+	// The server refuses the request before handling it, so resending is safe.
+	g.unsupported.Store(true)
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// isEncodingRejected matches the error a gRPC server returns for a request
+// compressed with an encoding it has no decompressor for.
+func isEncodingRejected(err error) bool {
+	s, ok := status.FromError(err)
+	return ok && s.Code() == codes.Unimplemented && strings.Contains(s.Message(), "grpc-encoding")
+}
@@ -0,0 +1,55 @@
+package cafesdk
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGzipFallbackWhenRejected(t *testing.T) {
+	var compressed []bool
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		gzip := false
+		for _, opt := range opts {
+			if _, ok := opt.(grpc.CompressorCallOption); ok {
+				gzip = true
+			}
+		}
+		compressed = append(compressed, gzip)
+		if gzip {
+			return status.Error(codes.Unimplemented, `grpc: Decompressor is not installed for grpc-encoding "gzip"`)
+		}
+		return nil
+	}
+
+	var g gzipFallback
+	for range 2 {
+		if err := g.intercept(context.Background(), "/cafesdk.Result/PushData", nil, nil, nil, invoker); err != nil {
+			t.Fatalf("intercept: %v", err)
+		}
+	}
+	// The first call is resent uncompressed; the second is not compressed.
+	if want := []bool{true, false, false}; !slices.Equal(compressed, want) {
+		t.Errorf("compressed per attempt = %v, want %v", compressed, want)
+	}
+}
+
+func TestGzipFallbackKeepsOtherErrors(t *testing.T) {
+	calls := 0
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls++
+		return status.Error(codes.Unimplemented, "unknown method")
+	}
+
+	var g gzipFallback
+	if err := g.intercept(context.Background(), "/cafesdk.Result/PushData", nil, nil, nil, invoker); status.Code(err) != codes.Unimplemented {
+		t.Errorf("intercept = %v, want the Unimplemented error", err)
+	}
+	if calls != 1 || g.unsupported.Load() {
+		t.Errorf("calls = %d, unsupported = %v; want one call and compression kept", calls, g.unsupported.Load())
+	}
+}
@@ -0,0 +1,87 @@
+package cafesdk
+
+import (
+	"context"
+	"slices"
+
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// connWatch holds the OnConnStateChange callbacks and the goroutine
+// watching the connection. It is guarded by Client.mu.
+type connWatch struct {
+	fns    []func(connectivity.State)
+	cancel context.CancelFunc
+}
+
+// OnConnStateChange registers fn with the default Client. See
+// Client.OnConnStateChange.
+func OnConnStateChange(fn func(state connectivity.State)) {
+	defaultClient.OnConnStateChange(fn)
+}
+
+// OnConnStateChange calls fn with the new state each time the Client's
+// connection changes state, for example to pause work while the platform
+// is unreachable. A connection that drops goes Idle and, once the SDK tries
+// to reconnect, Connecting and then TransientFailure until it recovers.
+// When the Client replaces the connection (see WithReconnect), the new one
+// is watched instead.
+//
+// Callbacks run one at a time on a goroutine that starts with the
+// connection and stops on Close, so they should return quickly; whether the
+// final Shutdown is reported is not guaranteed. A connection injected with
+// WithConn is only watched if it is a *grpc.ClientConn. Registering after
+// Close does nothing.
+func (c *Client) OnConnStateChange(fn func(state connectivity.State)) {
+	if fn == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return
+	}
+	c.watch.fns = append(c.watch.fns, fn)
+	c.startWatch()
+}
+
+// startWatch starts the watcher once there is both a connection and a
+// callback. c.mu must be held.
+func (c *Client) startWatch() {
+	conn := c.clientConn()
+	if conn == nil || c.watch.cancel != nil || len(c.watch.fns) == 0 {
+		return
+	}
+	ctx, cancel := c.derive(context.Background())
+	c.watch.cancel = cancel
+	go c.watchConn(ctx, conn)
+}
+
+// stopWatch ends the watcher without waiting for it, so a callback may call
+// Close. c.mu must be held.
+func (c *Client) stopWatch() {
+	if c.watch.cancel != nil {
+		c.watch.cancel()
+	}
+}
+
+func (c *Client) watchConn(ctx context.Context, conn *grpc.ClientConn) {
+	state := conn.GetState()
+	for conn.WaitForStateChange(ctx, state) {
+		state = conn.GetState()
+
+		c.mu.Lock()
+		fns := slices.Clone(c.watch.fns)
+		c.mu.Unlock()
+		for _, fn := range fns {
+			fn(state)
+		}
+
+		if state == connectivity.Shutdown {
+			return
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package cafesdk_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cafesdk "test/GoSdk"
+
+	"google.golang.org/grpc/connectivity"
+)
+
+// waitForState pokes the connection with short pushes, so it keeps trying
+// to connect, until states delivers want.
+func waitForState(t *testing.T, client *cafesdk.Client, states <-chan connectivity.State, want connectivity.State) {
+	t.Helper()
+	deadline := time.After(10 * time.Second)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		client.Result.PushData(ctx, `{}`)
+		cancel()
+	drain:
+		for {
+			select {
+			case s := <-states:
+				if s == want {
+					return
+				}
+			case <-deadline:
+				t.Fatalf("callback never saw %s", want)
+			default:
+				break drain
+			}
+		}
+	}
+}
+
+func TestOnConnStateChangeSeesTransientFailure(t *testing.T) {
+	client, srv := newTestClient(t)
+	states := make(chan connectivity.State, 100)
+	client.OnConnStateChange(func(s connectivity.State) {
+		select {
+		case states <- s:
+		default:
+		}
+	})
+	if _, err := client.Result.PushData(context.Background(), `{}`); err != nil {
+		t.Fatalf("PushData: %v", err)
+	}
+
+	srv.Stop()
+	waitForState(t, client, states, connectivity.TransientFailure)
+
+	if err := srv.Restart(); err != nil {
+		t.Fatalf("Restart: %v", err)
+	}
+	waitForState(t, client, states, connectivity.Ready)
+}
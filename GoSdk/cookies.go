@@ -0,0 +1,118 @@
+package cafesdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ErrNoCookieJar is returned by SaveCookies and LoadCookies for a client
+// whose Jar was not created by NewHTTPClient.
+var ErrNoCookieJar = errors.New("cafesdk: HTTP client has no SDK cookie jar")
+
+// storedCookie is a cookie as received, with the URL that set it.
+type storedCookie struct {
+	URL    string       `json:"url"`
+	Cookie *http.Cookie `json:"cookie"`
+}
+
+// cookieJar is a standard cookie jar that also remembers every cookie set,
+// since http.CookieJar cannot list its contents for saving.
+type cookieJar struct {
+	*cookiejar.Jar
+
+	mu  sync.Mutex
+	set map[string]storedCookie // by URL host, cookie domain, path and name
+}
+
+func newCookieJar() *cookieJar {
+	jar, _ := cookiejar.New(nil) // only fails for invalid options
+	return &cookieJar{Jar: jar, set: map[string]storedCookie{}}
+}
+
+func (j *cookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.Jar.SetCookies(u, cookies)
+
+	now := time.Now()
+	origin := (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}).String()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, c := range cookies {
+		c := *c
+		// Max-Age is relative to now; pin it so a later load keeps the
+		// original expiry.
+		if c.MaxAge > 0 {
+			c.Expires, c.MaxAge = now.Add(time.Duration(c.MaxAge)*time.Second), 0
+		}
+		id := u.Host + ";" + c.Domain + ";" + c.Path + ";" + c.Name
+		if c.MaxAge < 0 || (!c.Expires.IsZero() && c.Expires.Before(now)) {
+			delete(j.set, id)
+			continue
+		}
+		j.set[id] = storedCookie{URL: origin, Cookie: &c}
+	}
+}
+
+func (j *cookieJar) snapshot() []storedCookie {
+	now := time.Now()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	cookies := make([]storedCookie, 0, len(j.set))
+	for _, sc := range j.set {
+		if sc.Cookie.Expires.IsZero() || sc.Cookie.Expires.After(now) {
+			cookies = append(cookies, sc)
+		}
+	}
+	return cookies
+}
+
+// SaveCookies stores the cookies of a client made by NewHTTPClient in the
+// checkpoint store under key, so a later run can resume the session with
+// LoadCookies. Expired cookies are left out.
+func SaveCookies(ctx context.Context, client *http.Client, key string) error {
+	jar, ok := client.Jar.(*cookieJar)
+	if !ok {
+		return ErrNoCookieJar
+	}
+	b, err := json.Marshal(jar.snapshot())
+	if err != nil {
+		return fmt.Errorf("cafesdk: marshal cookies: %w", err)
+	}
+	return SaveCheckpoint(ctx, key, b)
+}
+
+// LoadCookies adds the cookies saved under key to the jar of a client made
+// by NewHTTPClient. Having nothing saved under key is not an error.
+func LoadCookies(ctx context.Context, client *http.Client, key string) error {
+	jar, ok := client.Jar.(*cookieJar)
+	if !ok {
+		return ErrNoCookieJar
+	}
+	b, found, err := LoadCheckpoint(ctx, key)
+	if err != nil || !found {
+		return err
+	}
+
+	var cookies []storedCookie
+	if err := json.Unmarshal(b, &cookies); err != nil {
+		return fmt.Errorf("cafesdk: decode cookies saved under %q: %w", key, err)
+	}
+	for _, sc := range cookies {
+		u, err := url.Parse(sc.URL)
+		if err != nil || sc.Cookie == nil {
+			continue
+		}
+		jar.SetCookies(u, []*http.Cookie{sc.Cookie})
+	}
+	return nil
+}
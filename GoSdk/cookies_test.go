@@ -0,0 +1,109 @@
+package cafesdk_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	cafesdk "test/GoSdk"
+)
+
+// sessionSite sets a session cookie on /login and reports on /me whether
+// the request carried it.
+func sessionSite(t *testing.T) *httptest.Server {
+	t.Helper()
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/", MaxAge: 3600})
+			http.SetCookie(w, &http.Cookie{Name: "stale", Value: "x", Path: "/", MaxAge: -1})
+		case "/me":
+			if c, err := r.Cookie("session"); err != nil || c.Value != "abc" {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+			if _, err := r.Cookie("stale"); err == nil {
+				w.WriteHeader(http.StatusBadRequest)
+			}
+		}
+	}))
+	t.Cleanup(site.Close)
+	return site
+}
+
+func newCookieClient(t *testing.T, opts cafesdk.HTTPClientOptions) *http.Client {
+	t.Helper()
+	unsetenv(t, "PROXY_AUTH")
+	client, err := cafesdk.NewHTTPClient(opts)
+	if err != nil {
+		t.Fatalf("NewHTTPClient: %v", err)
+	}
+	return client
+}
+
+func get(t *testing.T, client *http.Client, url string) int {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestHTTPClientKeepsCookies(t *testing.T) {
+	site := sessionSite(t)
+	client := newCookieClient(t, cafesdk.HTTPClientOptions{})
+
+	get(t, client, site.URL+"/login")
+	if code := get(t, client, site.URL+"/me"); code != http.StatusOK {
+		t.Errorf("GET /me after login = %d, want 200 with the session cookie", code)
+	}
+}
+
+func TestHTTPClientDisableCookies(t *testing.T) {
+	site := sessionSite(t)
+	client := newCookieClient(t, cafesdk.HTTPClientOptions{DisableCookies: true})
+
+	get(t, client, site.URL+"/login")
+	if code := get(t, client, site.URL+"/me"); code != http.StatusUnauthorized {
+		t.Errorf("GET /me without a jar = %d, want 401", code)
+	}
+	if err := cafesdk.SaveCookies(context.Background(), client, "cookies"); !errors.Is(err, cafesdk.ErrNoCookieJar) {
+		t.Errorf("SaveCookies = %v, want ErrNoCookieJar", err)
+	}
+}
+
+func TestSaveLoadCookiesRoundTrip(t *testing.T) {
+	t.Setenv("CAFE_CHECKPOINT_DIR", t.TempDir())
+	site := sessionSite(t)
+	ctx := context.Background()
+
+	first := newCookieClient(t, cafesdk.HTTPClientOptions{})
+	get(t, first, site.URL+"/login")
+	if err := cafesdk.SaveCookies(ctx, first, "session"); err != nil {
+		t.Fatalf("SaveCookies: %v", err)
+	}
+
+	// A later run starts with an empty jar.
+	second := newCookieClient(t, cafesdk.HTTPClientOptions{})
+	if code := get(t, second, site.URL+"/me"); code != http.StatusUnauthorized {
+		t.Fatalf("GET /me before LoadCookies = %d, want 401", code)
+	}
+	if err := cafesdk.LoadCookies(ctx, second, "session"); err != nil {
+		t.Fatalf("LoadCookies: %v", err)
+	}
+	if code := get(t, second, site.URL+"/me"); code != http.StatusOK {
+		t.Errorf("GET /me after LoadCookies = %d, want 200", code)
+	}
+}
+
+func TestLoadCookiesNothingSaved(t *testing.T) {
+	t.Setenv("CAFE_CHECKPOINT_DIR", t.TempDir())
+	client := newCookieClient(t, cafesdk.HTTPClientOptions{})
+
+	if err := cafesdk.LoadCookies(context.Background(), client, "never-saved"); err != nil {
+		t.Errorf("LoadCookies = %v, want nil", err)
+	}
+}
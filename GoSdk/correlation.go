@@ -0,0 +1,46 @@
+package cafesdk
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// CorrelationIDHeader is the gRPC metadata key carrying the correlation ID
+// on every SDK RPC.
+const CorrelationIDHeader = "x-correlation-id"
+
+type correlationKey struct{}
+
+// processCorrelationID is sent on calls whose context carries no ID.
+var processCorrelationID = newCorrelationID()
+
+func newCorrelationID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// WithCorrelationID returns a copy of ctx whose SDK calls send id as the
+// correlation ID, so they can be traced through the platform.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// CorrelationID returns the correlation ID SDK calls made with ctx send:
+// the one set with WithCorrelationID, or else an ID generated once per
+// process.
+func CorrelationID(ctx context.Context) string {
+	if id, ok := ctx.Value(correlationKey{}).(string); ok && id != "" {
+		return id
+	}
+	return processCorrelationID
+}
+
+func correlationInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	ctx = metadata.AppendToOutgoingContext(ctx, CorrelationIDHeader, CorrelationID(ctx))
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
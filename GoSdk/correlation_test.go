@@ -0,0 +1,46 @@
+package cafesdk_test
+
+import (
+	"context"
+	"testing"
+
+	cafesdk "test/GoSdk"
+)
+
+func TestCorrelationIDSentOnEveryCall(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.SetInput(`{}`)
+	ctx := cafesdk.WithCorrelationID(context.Background(), "run-42")
+
+	client.Parameter.GetInputJSONString(ctx)
+	client.Result.PushData(ctx, `{}`)
+	client.Log.Info(ctx, "hello")
+
+	calls := srv.Calls()
+	if len(calls) != 3 {
+		t.Fatalf("server saw %d calls, want 3", len(calls))
+	}
+	for _, call := range calls {
+		if got := call.Metadata.Get(cafesdk.CorrelationIDHeader); len(got) != 1 || got[0] != "run-42" {
+			t.Errorf("%s %s = %q, want [run-42]", call.Method, cafesdk.CorrelationIDHeader, got)
+		}
+	}
+}
+
+func TestCorrelationIDDefaultsToProcessID(t *testing.T) {
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+
+	processID := cafesdk.CorrelationID(ctx)
+	if processID == "" {
+		t.Fatal("no process correlation ID")
+	}
+	client.Result.PushData(ctx, `{}`)
+	client.Result.PushData(ctx, `{}`)
+
+	for _, call := range srv.Calls() {
+		if got := call.Metadata.Get(cafesdk.CorrelationIDHeader); len(got) != 1 || got[0] != processID {
+			t.Errorf("%s = %q, want the process ID %q", cafesdk.CorrelationIDHeader, got, processID)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package cafesdk
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// DatasetHeader is the gRPC metadata key naming the dataset a push or header
+// belongs to. Calls for the default dataset do not send it.
+const DatasetHeader = "x-dataset"
+
+// PushTo pushes jsonString to the named dataset, so an actor can emit
+// separate tables such as "products" and "reviews". The empty name is the
+// default dataset that PushData writes to.
+func (r _Result) PushTo(ctx context.Context, dataset, jsonString string) (*Response, error) {
+	return r.push(ctx, dataset, jsonString, true)
+}
+
+// SetTableHeaderFor sets the columns of the named dataset. Only the default
+// dataset's header is used by PushRow and VerifyHeader.
+func (r _Result) SetTableHeaderFor(ctx context.Context, dataset string, headers []*TableHeaderItem) (*Response, error) {
+	return r.setTableHeader(ctx, dataset, headers)
+}
+
+func withDataset(ctx context.Context, dataset string) context.Context {
+	if dataset == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, DatasetHeader, dataset)
+}
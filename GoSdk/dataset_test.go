@@ -0,0 +1,41 @@
+package cafesdk_test
+
+import (
+	"context"
+	"testing"
+
+	cafesdk "test/GoSdk"
+)
+
+func TestPushToTagsDataset(t *testing.T) {
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+
+	header := []*cafesdk.TableHeaderItem{{Key: "stars", Label: "Stars", Format: cafesdk.FormatInteger}}
+	if _, err := client.Result.SetTableHeaderFor(ctx, "reviews", header); err != nil {
+		t.Fatalf("SetTableHeaderFor: %v", err)
+	}
+	for _, push := range []struct{ dataset, record string }{
+		{"products", `{"sku":"a"}`},
+		{"reviews", `{"stars":5}`},
+		{"", `{"plain":true}`},
+	} {
+		if _, err := client.Result.PushTo(ctx, push.dataset, push.record); err != nil {
+			t.Fatalf("PushTo(%q): %v", push.dataset, err)
+		}
+	}
+
+	headers := srv.CallsTo(cafesdk.MethodSetTableHeader)
+	if len(headers) != 1 || !equalStrings(headers[0].Metadata.Get(cafesdk.DatasetHeader), []string{"reviews"}) {
+		t.Errorf("SetTableHeader calls = %+v, want one tagged reviews", headers)
+	}
+	pushes := srv.CallsTo(cafesdk.MethodPushData)
+	if len(pushes) != 3 {
+		t.Fatalf("%d PushData calls, want 3", len(pushes))
+	}
+	for i, want := range [][]string{{"products"}, {"reviews"}, nil} {
+		if got := pushes[i].Metadata.Get(cafesdk.DatasetHeader); !equalStrings(got, want) {
+			t.Errorf("push %d %s = %q, want %q", i, cafesdk.DatasetHeader, got, want)
+		}
+	}
+}
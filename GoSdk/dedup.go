@@ -0,0 +1,236 @@
+package cafesdk
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DedupWriter pushes records unless a record with the same key was already
+// pushed in this run. Keys are kept in memory; Limit bounds how many are
+// remembered, evicting the least recently seen.
+type DedupWriter struct {
+	result  _Result
+	keyFn   func(jsonString string) string
+	skipped atomic.Int64
+
+	mu    sync.Mutex
+	limit int
+	order *list.List // of string keys, most recently seen at the front
+	seen  map[string]*list.Element
+}
+
+// NewDedupWriter returns a DedupWriter keyed by keyFn.
+func (r _Result) NewDedupWriter(keyFn func(jsonString string) string) *DedupWriter {
+	return &DedupWriter{result: r, keyFn: keyFn, order: list.New(), seen: map[string]*list.Element{}}
+}
+
+// Limit caps the number of remembered keys at n (0 means unbounded) and
+// returns d.
+func (d *DedupWriter) Limit(n int) *DedupWriter {
+	d.mu.Lock()
+	d.limit = n
+	d.evict()
+	d.mu.Unlock()
+	return d
+}
+
+// PushData pushes jsonString unless its key was seen before, in which case
+// it returns an empty Response without an RPC. A failed push forgets the
+// key so the record can be retried.
+func (d *DedupWriter) PushData(ctx context.Context, jsonString string) (*Response, error) {
+	key := d.keyFn(jsonString)
+	if !d.mark(key) {
+		d.skipped.Add(1)
+		return &Response{}, nil
+	}
+
+	res, err := d.result.PushData(ctx, jsonString)
+	if err != nil {
+		d.forget(key)
+		return nil, err
+	}
+	return res, nil
+}
+
+// Skipped returns how many duplicates have been dropped.
+func (d *DedupWriter) Skipped() int64 {
+	return d.skipped.Load()
+}
+
+// mark records key as seen, reporting false if it already was.
+func (d *DedupWriter) mark(key string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if e, ok := d.seen[key]; ok {
+		d.order.MoveToFront(e)
+		return false
+	}
+	d.seen[key] = d.order.PushFront(key)
+	d.evict()
+	return true
+}
+
+func (d *DedupWriter) forget(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if e, ok := d.seen[key]; ok {
+		d.order.Remove(e)
+		delete(d.seen, key)
+	}
+}
+
+func (d *DedupWriter) evict() {
+	for d.limit > 0 && d.order.Len() > d.limit {
+		e := d.order.Back()
+		d.order.Remove(e)
+		delete(d.seen, e.Value.(string))
+	}
+}
+
+// DedupCheckpointKey is the checkpoint key a PersistentDedupWriter keeps
+// its seen keys under unless CheckpointKey sets another.
+const DedupCheckpointKey = "cafesdk-dedup"
+
+// PersistentDedupWriter is a DedupWriter whose seen keys outlive the run:
+// they are loaded from a CheckpointStore on first use and written back by
+// Save, so a scheduled actor skips records emitted by earlier runs. Keys
+// expire ttl after the record was pushed, letting it be pushed again.
+type PersistentDedupWriter struct {
+	result  _Result
+	keyFn   func(jsonString string) string
+	store   CheckpointStore
+	ttl     time.Duration
+	skipped atomic.Int64
+
+	mu     sync.Mutex
+	key    string
+	loaded bool
+	seen   map[string]time.Time
+}
+
+// NewPersistentDedupWriter returns a PersistentDedupWriter keyed by keyFn
+// that persists to store, or to the store set with SetCheckpointStore when
+// store is nil. A ttl of zero or less never expires keys.
+func (r _Result) NewPersistentDedupWriter(keyFn func(jsonString string) string, store CheckpointStore, ttl time.Duration) *PersistentDedupWriter {
+	return &PersistentDedupWriter{result: r, keyFn: keyFn, store: store, ttl: ttl, key: DedupCheckpointKey}
+}
+
+// CheckpointKey sets the checkpoint key, so several writers can share a
+// store, and returns d. Set it before the first PushData.
+func (d *PersistentDedupWriter) CheckpointKey(key string) *PersistentDedupWriter {
+	d.mu.Lock()
+	d.key = key
+	d.mu.Unlock()
+	return d
+}
+
+// PushData pushes jsonString unless its key was pushed in this or an
+// earlier run and has not expired, in which case it returns an empty
+// Response without an RPC. The first call loads the saved keys, and fails
+// if they cannot be read. A failed push forgets the key.
+func (d *PersistentDedupWriter) PushData(ctx context.Context, jsonString string) (*Response, error) {
+	key := d.keyFn(jsonString)
+
+	d.mu.Lock()
+	if err := d.loadLocked(ctx); err != nil {
+		d.mu.Unlock()
+		return nil, err
+	}
+	now := clockNow()
+	prev, had := d.seen[key]
+	if had && !d.expired(prev, now) {
+		d.mu.Unlock()
+		d.skipped.Add(1)
+		return &Response{}, nil
+	}
+	d.seen[key] = now
+	d.mu.Unlock()
+
+	res, err := d.result.PushData(ctx, jsonString)
+	if err != nil {
+		d.mu.Lock()
+		if had {
+			d.seen[key] = prev
+		} else {
+			delete(d.seen, key)
+		}
+		d.mu.Unlock()
+		return nil, err
+	}
+	return res, nil
+}
+
+// Skipped returns how many duplicates have been dropped.
+func (d *PersistentDedupWriter) Skipped() int64 {
+	return d.skipped.Load()
+}
+
+// Save writes the unexpired seen keys to the store. Call it before the run
+// ends; keys pushed after the last Save are forgotten by the next run.
+func (d *PersistentDedupWriter) Save(ctx context.Context) error {
+	d.mu.Lock()
+	if err := d.loadLocked(ctx); err != nil {
+		d.mu.Unlock()
+		return err
+	}
+	now := clockNow()
+	state := make(map[string]int64, len(d.seen))
+	for k, t := range d.seen {
+		if !d.expired(t, now) {
+			state[k] = t.Unix()
+		}
+	}
+	key := d.key
+	d.mu.Unlock()
+
+	b, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("cafesdk: encode dedup keys: %w", err)
+	}
+	return d.checkpoints().Save(ctx, key, b)
+}
+
+// loadLocked reads the saved keys on first use. d.mu must be held.
+func (d *PersistentDedupWriter) loadLocked(ctx context.Context) error {
+	if d.loaded {
+		return nil
+	}
+	b, ok, err := d.checkpoints().Load(ctx, d.key)
+	if err != nil {
+		return err
+	}
+	state := map[string]int64{}
+	if ok {
+		if err := json.Unmarshal(b, &state); err != nil {
+			return fmt.Errorf("cafesdk: decode dedup keys %q: %w", d.key, err)
+		}
+	}
+
+	now := clockNow()
+	d.seen = make(map[string]time.Time, len(state))
+	for k, sec := range state {
+		if t := time.Unix(sec, 0); !d.expired(t, now) {
+			d.seen[k] = t
+		}
+	}
+	d.loaded = true
+	return nil
+}
+
+func (d *PersistentDedupWriter) expired(pushed, now time.Time) bool {
+	return d.ttl > 0 && now.Sub(pushed) >= d.ttl
+}
+
+func (d *PersistentDedupWriter) checkpoints() CheckpointStore {
+	if d.store != nil {
+		return d.store
+	}
+	return currentCheckpointStore()
+}
@@ -0,0 +1,142 @@
+package cafesdk_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	cafesdk "test/GoSdk"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// idKey keys records by their "id" field.
+func idKey(jsonString string) string {
+	var r struct{ ID string }
+	json.Unmarshal([]byte(jsonString), &r)
+	return r.ID
+}
+
+func TestDedupWriterSkipsDuplicate(t *testing.T) {
+	client, srv := newTestClient(t)
+	d := client.Result.NewDedupWriter(idKey)
+	ctx := context.Background()
+
+	for _, record := range []string{`{"id":"a","v":1}`, `{"id":"a","v":2}`, `{"id":"b"}`} {
+		if _, err := d.PushData(ctx, record); err != nil {
+			t.Fatalf("PushData: %v", err)
+		}
+	}
+	if got := srv.Data(); !equalStrings(got, []string{`{"id":"a","v":1}`, `{"id":"b"}`}) {
+		t.Errorf("server data = %q, want a once and b", got)
+	}
+	if n := d.Skipped(); n != 1 {
+		t.Errorf("Skipped() = %d, want 1", n)
+	}
+}
+
+func TestDedupWriterLRUEviction(t *testing.T) {
+	client, srv := newTestClient(t)
+	d := client.Result.NewDedupWriter(idKey).Limit(2)
+	ctx := context.Background()
+
+	// a is seen again before c is pushed, so c evicts b, the least
+	// recently seen; b then evicts a, and both are pushed again.
+	for _, id := range []string{"a", "b", "a", "c", "b", "a"} {
+		if _, err := d.PushData(ctx, `{"id":"`+id+`"}`); err != nil {
+			t.Fatalf("PushData(%s): %v", id, err)
+		}
+	}
+	want := []string{`{"id":"a"}`, `{"id":"b"}`, `{"id":"c"}`, `{"id":"b"}`, `{"id":"a"}`}
+	if got := srv.Data(); !equalStrings(got, want) {
+		t.Errorf("server data = %q, want %q", got, want)
+	}
+}
+
+func TestDedupWriterForgetsFailedPush(t *testing.T) {
+	client, srv := newTestClient(t)
+	d := client.Result.NewDedupWriter(idKey)
+	ctx := context.Background()
+	srv.FailFunc(cafesdk.MethodPushData, func(call int) error {
+		if call == 1 {
+			return status.Error(codes.InvalidArgument, "rejected")
+		}
+		return nil
+	})
+
+	if _, err := d.PushData(ctx, `{"id":"a"}`); err == nil {
+		t.Fatal("first PushData succeeded against a failing server")
+	}
+	if _, err := d.PushData(ctx, `{"id":"a"}`); err != nil {
+		t.Fatalf("retried PushData: %v", err)
+	}
+	if got := srv.Data(); !equalStrings(got, []string{`{"id":"a"}`}) || d.Skipped() != 0 {
+		t.Errorf("server data = %q, skipped %d; want the retried record delivered", got, d.Skipped())
+	}
+}
+
+// dedupRun pushes records through a PersistentDedupWriter on a fresh
+// client and server, as one actor run, saves its keys and returns what the
+// server received.
+func dedupRun(t *testing.T, store cafesdk.CheckpointStore, ttl time.Duration, records ...string) []string {
+	t.Helper()
+	client, srv := newTestClient(t)
+	d := client.Result.NewPersistentDedupWriter(idKey, store, ttl)
+	ctx := context.Background()
+	for _, record := range records {
+		if _, err := d.PushData(ctx, record); err != nil {
+			t.Fatalf("PushData(%s): %v", record, err)
+		}
+	}
+	if err := d.Save(ctx); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	return srv.Data()
+}
+
+func TestPersistentDedupSkipsAcrossRuns(t *testing.T) {
+	store := cafesdk.FileCheckpointStore{Dir: t.TempDir()}
+
+	if got := dedupRun(t, store, 0, `{"id":"a"}`, `{"id":"b"}`); !equalStrings(got, []string{`{"id":"a"}`, `{"id":"b"}`}) {
+		t.Fatalf("run 1 pushed %q, want a and b", got)
+	}
+	got := dedupRun(t, store, 0, `{"id":"a","v":2}`, `{"id":"c"}`, `{"id":"b"}`)
+	if !equalStrings(got, []string{`{"id":"c"}`}) {
+		t.Errorf("run 2 pushed %q, want only c", got)
+	}
+}
+
+func TestPersistentDedupReemitsExpiredKeys(t *testing.T) {
+	clock := useFakeClock(t)
+	store := cafesdk.FileCheckpointStore{Dir: t.TempDir()}
+
+	dedupRun(t, store, time.Hour, `{"id":"old"}`)
+	clock.Advance(30 * time.Minute)
+	dedupRun(t, store, time.Hour, `{"id":"new"}`)
+	clock.Advance(45 * time.Minute)
+
+	got := dedupRun(t, store, time.Hour, `{"id":"old"}`, `{"id":"new"}`)
+	if !equalStrings(got, []string{`{"id":"old"}`}) {
+		t.Errorf("run 3 pushed %q, want only the expired old key", got)
+	}
+}
+
+func TestPersistentDedupCheckpointKeysAreSeparate(t *testing.T) {
+	store := cafesdk.FileCheckpointStore{Dir: t.TempDir()}
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+
+	products := client.Result.NewPersistentDedupWriter(idKey, store, 0).CheckpointKey("products")
+	products.PushData(ctx, `{"id":"a"}`)
+	if err := products.Save(ctx); err != nil {
+		t.Fatal(err)
+	}
+	reviews := client.Result.NewPersistentDedupWriter(idKey, store, 0).CheckpointKey("reviews")
+	reviews.PushData(ctx, `{"id":"a"}`)
+
+	if got := len(srv.Data()); got != 2 || reviews.Skipped() != 0 {
+		t.Errorf("pushed %d records, reviews skipped %d; want writers under separate keys independent", got, reviews.Skipped())
+	}
+}
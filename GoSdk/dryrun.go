@@ -0,0 +1,44 @@
+package cafesdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+)
+
+var dryRun atomic.Bool
+
+// SetDryRun turns dry-run mode on or off for every Client. In dry-run mode
+// no RPC is made: PushData, PushBatch, SetTableHeader and the log methods
+// write their payload to the local mirror, or to stderr when none is set,
+// and return a Response with the message "dry run". GetInputJSONString
+// returns the local input (see GetInputJSONString), or an empty input when
+// none is given.
+func SetDryRun(enabled bool) {
+	dryRun.Store(enabled)
+}
+
+// dryRunCall reports whether dry-run mode is on, and if so mirrors the call
+// for method with its payload.
+func dryRunCall(method, payload string) bool {
+	if !dryRun.Load() {
+		return false
+	}
+	writeMirror(LevelInfo, "dry run: "+method+" "+payload)
+	return true
+}
+
+func dryRunResponse() *Response {
+	return &Response{Message: "dry run"}
+}
+
+func dryRunHeader(headers []*TableHeaderItem) bool {
+	if !dryRun.Load() {
+		return false
+	}
+	b, err := json.Marshal(headers)
+	if err != nil {
+		return dryRunCall(MethodSetTableHeader, fmt.Sprint(headers))
+	}
+	return dryRunCall(MethodSetTableHeader, string(b))
+}
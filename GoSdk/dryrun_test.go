@@ -0,0 +1,57 @@
+package cafesdk_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	cafesdk "test/GoSdk"
+)
+
+func TestDryRunMakesNoRPCs(t *testing.T) {
+	var buf bytes.Buffer
+	cafesdk.SetLocalMirror(&buf)
+	cafesdk.SetDryRun(true)
+	t.Cleanup(func() {
+		cafesdk.SetDryRun(false)
+		cafesdk.SetLocalMirror(nil)
+	})
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+
+	if input, err := client.Parameter.GetInputJSONString(ctx); err != nil || input != "" {
+		t.Errorf("GetInputJSONString = %q, %v, want an empty input", input, err)
+	}
+	header := []*cafesdk.TableHeaderItem{{Key: "url", Label: "URL", Format: cafesdk.FormatLink}}
+	if _, err := client.Result.SetTableHeader(ctx, header); err != nil {
+		t.Errorf("SetTableHeader: %v", err)
+	}
+	res, err := client.Result.PushData(ctx, `{"url":"https://example.com"}`)
+	if err != nil || res.Message != "dry run" {
+		t.Errorf("PushData = %+v, %v, want the dry run Response", res, err)
+	}
+	if _, err := client.Result.PushBatch(ctx, []string{`{"n":1}`, `{"n":2}`}); err != nil {
+		t.Errorf("PushBatch: %v", err)
+	}
+	if _, err := client.Log.Info(ctx, "hello"); err != nil {
+		t.Errorf("Info: %v", err)
+	}
+
+	if calls := srv.Calls(); len(calls) != 0 {
+		t.Errorf("dry run made %d RPCs: %+v", len(calls), calls)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"dry run: " + cafesdk.MethodSetTableHeader + " ",
+		`"key":"url"`,
+		"dry run: " + cafesdk.MethodPushData + ` {"url":"https://example.com"}`,
+		`{"n":1}`,
+		`{"n":2}`,
+		"hello",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("mirror is missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package cafesdk
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// EncoderOptions control how Push, PushRow and PushRecord encode records.
+// The zero value matches json.Marshal. Numbers need no option: json.Number
+// values, as the input getters and Unmarshal into any produce, are written
+// verbatim, so large integers keep their digits.
+type EncoderOptions struct {
+	// DisableHTMLEscape writes <, > and & in strings as is instead of as
+	// \u003c, \u003e and \u0026, so scraped HTML snippets stay readable.
+	DisableHTMLEscape bool
+	// Indent, if not empty, pretty-prints records with it as the
+	// indentation string. Records are compact by default.
+	Indent string
+}
+
+// SetEncoderOptions changes how the Client's typed push helpers encode
+// records. Records pushed with PushData as JSON strings are sent as given.
+func (r _Result) SetEncoderOptions(opts EncoderOptions) {
+	r.c.encoder.Store(&opts)
+}
+
+func (r _Result) encoderOptions() EncoderOptions {
+	if p := r.c.encoder.Load(); p != nil {
+		return *p
+	}
+	return EncoderOptions{}
+}
+
+// marshal encodes v compactly, escaping HTML unless disabled.
+func (o EncoderOptions) marshal(v any) ([]byte, error) {
+	if !o.DisableHTMLEscape {
+		return json.Marshal(v)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// indent applies Indent to an encoded record.
+func (o EncoderOptions) indent(b []byte) []byte {
+	if o.Indent == "" {
+		return b
+	}
+	var buf bytes.Buffer
+	if json.Indent(&buf, b, "", o.Indent) != nil {
+		return b
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,74 @@
+package cafesdk_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	cafesdk "test/GoSdk"
+)
+
+type snippet struct {
+	HTML string `json:"html"`
+}
+
+func TestPushEscapesHTMLByDefault(t *testing.T) {
+	client, srv := newTestClient(t)
+	if _, err := client.Result.Push(context.Background(), snippet{"<a>&"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := srv.Data(), []string{"{\"html\":\"\\u003ca\\u003e\\u0026\"}"}; !equalStrings(got, want) {
+		t.Errorf("server data = %q, want %q", got, want)
+	}
+}
+
+func TestPushWithHTMLEscapeDisabled(t *testing.T) {
+	client, srv := newTestClient(t)
+	client.Result.SetEncoderOptions(cafesdk.EncoderOptions{DisableHTMLEscape: true})
+
+	if _, err := client.Result.Push(context.Background(), snippet{`<a href="/x">A & B</a>`}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := srv.Data(), []string{`{"html":"<a href=\"/x\">A & B</a>"}`}; !equalStrings(got, want) {
+		t.Errorf("server data = %q, want %q", got, want)
+	}
+}
+
+func TestPushWithIndent(t *testing.T) {
+	client, srv := newTestClient(t)
+	client.Result.SetEncoderOptions(cafesdk.EncoderOptions{DisableHTMLEscape: true, Indent: "  "})
+
+	if _, err := client.Result.Push(context.Background(), snippet{"<a>"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := srv.Data(), []string{"{\n  \"html\": \"<a>\"\n}"}; !equalStrings(got, want) {
+		t.Errorf("server data = %q, want %q", got, want)
+	}
+}
+
+func TestPushKeepsJSONNumberDigits(t *testing.T) {
+	client, srv := newTestClient(t)
+	record := map[string]any{"id": json.Number("12345678901234567890")}
+	if _, err := client.Result.Push(context.Background(), record); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := srv.Data(), []string{`{"id":12345678901234567890}`}; !equalStrings(got, want) {
+		t.Errorf("server data = %q, want %q", got, want)
+	}
+}
+
+func TestEncoderOptionsPerClient(t *testing.T) {
+	escaped, escapedSrv := newTestClient(t)
+	raw, rawSrv := newTestClient(t)
+	raw.Result.SetEncoderOptions(cafesdk.EncoderOptions{DisableHTMLEscape: true})
+	ctx := context.Background()
+
+	escaped.Result.Push(ctx, snippet{"<a>"})
+	raw.Result.Push(ctx, snippet{"<a>"})
+	if got := escapedSrv.Data(); !equalStrings(got, []string{"{\"html\":\"\\u003ca\\u003e\"}"}) {
+		t.Errorf("default client pushed %q", got)
+	}
+	if got := rawSrv.Data(); !equalStrings(got, []string{`{"html":"<a>"}`}) {
+		t.Errorf("configured client pushed %q", got)
+	}
+}
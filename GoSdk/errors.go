@@ -0,0 +1,67 @@
+package cafesdk
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Error classes returned by SDK calls. Test for them with errors.Is; the
+// underlying gRPC status stays reachable through status.Code and errors.As.
+var (
+	ErrUnavailable  = errors.New("cafesdk: platform unavailable")
+	ErrInvalidInput = errors.New("cafesdk: invalid input")
+	ErrTimeout      = errors.New("cafesdk: call timed out")
+	ErrShutdown     = errors.New("cafesdk: sdk shut down")
+)
+
+// callError tags an RPC error with its class.
+type callError struct {
+	class error
+	err   error
+}
+
+func (e *callError) Error() string {
+	return e.class.Error() + ": " + e.err.Error()
+}
+
+func (e *callError) Unwrap() []error {
+	return []error{e.class, e.err}
+}
+
+// classifyError maps gRPC status codes and context expiry onto the error
+// classes, leaving other errors unchanged.
+func classifyError(err error) error {
+	var class error
+	switch status.Code(err) {
+	case codes.Unavailable:
+		class = ErrUnavailable
+	case codes.DeadlineExceeded:
+		class = ErrTimeout
+	case codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition:
+		class = ErrInvalidInput
+	default:
+		if errors.Is(err, context.DeadlineExceeded) {
+			class = ErrTimeout
+		}
+	}
+	if class == nil || errors.Is(err, class) {
+		return err
+	}
+	return &callError{class: class, err: err}
+}
+
+// IsTransient reports whether err is worth retrying: the platform was
+// unavailable or the call timed out.
+func IsTransient(err error) bool {
+	if errors.Is(err, ErrUnavailable) || errors.Is(err, ErrTimeout) {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
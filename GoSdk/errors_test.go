@@ -0,0 +1,66 @@
+package cafesdk_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cafesdk "test/GoSdk"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestErrorClasses(t *testing.T) {
+	tests := []struct {
+		code      codes.Code
+		class     error
+		transient bool
+	}{
+		{codes.Unavailable, cafesdk.ErrUnavailable, true},
+		{codes.DeadlineExceeded, cafesdk.ErrTimeout, true},
+		{codes.InvalidArgument, cafesdk.ErrInvalidInput, false},
+		{codes.OutOfRange, cafesdk.ErrInvalidInput, false},
+		{codes.FailedPrecondition, cafesdk.ErrInvalidInput, false},
+		{codes.Internal, nil, false},
+		{codes.PermissionDenied, nil, false},
+	}
+	classes := []error{cafesdk.ErrUnavailable, cafesdk.ErrTimeout, cafesdk.ErrInvalidInput}
+
+	for _, tt := range tests {
+		t.Run(tt.code.String(), func(t *testing.T) {
+			client, srv := newTestClient(t)
+			srv.Fail(cafesdk.MethodPushData, status.Error(tt.code, "from the server"))
+
+			_, err := client.Result.PushData(context.Background(), `{}`)
+			if status.Code(err) != tt.code {
+				t.Errorf("status.Code = %v, want %v", status.Code(err), tt.code)
+			}
+			for _, class := range classes {
+				if got, want := errors.Is(err, class), class == tt.class; got != want {
+					t.Errorf("errors.Is(err, %v) = %v, want %v", class, got, want)
+				}
+			}
+			if got := cafesdk.IsTransient(err); got != tt.transient {
+				t.Errorf("IsTransient = %v, want %v", got, tt.transient)
+			}
+			var st interface{ GRPCStatus() *status.Status }
+			if !errors.As(err, &st) || st.GRPCStatus().Message() != "from the server" {
+				t.Errorf("underlying status not reachable through errors.As: %v", err)
+			}
+		})
+	}
+}
+
+func TestContextDeadlineIsTimeout(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.Delay(cafesdk.MethodPushData, time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.Result.PushData(ctx, `{}`)
+	if !errors.Is(err, cafesdk.ErrTimeout) || !cafesdk.IsTransient(err) {
+		t.Errorf("PushData past its deadline = %v, want ErrTimeout and transient", err)
+	}
+}
@@ -0,0 +1,91 @@
+package cafesdk
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ThrottledLog sends at most one log message per interval, for progress
+// style logs such as "processed 1000 items" written from a hot loop. Create
+// one with Log.Every; it is safe for concurrent use.
+type ThrottledLog struct {
+	log      _Log
+	interval time.Duration
+
+	mu         sync.Mutex
+	next       time.Time
+	suppressed int
+	latest     *throttledEntry
+}
+
+type throttledEntry struct {
+	level LogLevel
+	text  string
+}
+
+// Every returns a ThrottledLog that sends the first message right away and
+// then at most one per d. A message sent after others were suppressed ends
+// with their count, as in "processed 3000 items (41 suppressed)". Messages
+// suppressed at the end of a run are lost unless Flush is called.
+func (l _Log) Every(d time.Duration) *ThrottledLog {
+	return &ThrottledLog{log: l, interval: d}
+}
+
+func (t *ThrottledLog) Debug(ctx context.Context, text string) (*Response, error) {
+	return t.send(ctx, LevelDebug, text)
+}
+
+func (t *ThrottledLog) Info(ctx context.Context, text string) (*Response, error) {
+	return t.send(ctx, LevelInfo, text)
+}
+
+func (t *ThrottledLog) Warn(ctx context.Context, text string) (*Response, error) {
+	return t.send(ctx, LevelWarn, text)
+}
+
+func (t *ThrottledLog) Error(ctx context.Context, text string) (*Response, error) {
+	return t.send(ctx, LevelError, text)
+}
+
+// Flush sends the latest suppressed message, if any, with the count of
+// messages suppressed before it, regardless of the interval.
+func (t *ThrottledLog) Flush(ctx context.Context) (*Response, error) {
+	t.mu.Lock()
+	e, suppressed := t.latest, t.suppressed-1
+	t.latest, t.suppressed = nil, 0
+	t.next = clockNow().Add(t.interval)
+	t.mu.Unlock()
+
+	if e == nil {
+		return &Response{}, nil
+	}
+	return t.log.send(ctx, e.level, withSuppressed(e.text, suppressed))
+}
+
+// send logs text if the interval has passed and otherwise keeps it as the
+// latest message, returning an empty Response without an RPC.
+func (t *ThrottledLog) send(ctx context.Context, level LogLevel, text string) (*Response, error) {
+	t.mu.Lock()
+	now := clockNow()
+	if now.Before(t.next) {
+		t.suppressed++
+		t.latest = &throttledEntry{level: level, text: text}
+		t.mu.Unlock()
+		return &Response{}, nil
+	}
+	suppressed := t.suppressed
+	t.latest, t.suppressed = nil, 0
+	t.next = now.Add(t.interval)
+	t.mu.Unlock()
+
+	return t.log.send(ctx, level, withSuppressed(text, suppressed))
+}
+
+func withSuppressed(text string, n int) string {
+	if n <= 0 {
+		return text
+	}
+	return fmt.Sprintf("%s (%d suppressed)", text, n)
+}
@@ -0,0 +1,62 @@
+package cafesdk_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	cafesdk "test/GoSdk"
+)
+
+func TestEveryCoalescesWithinInterval(t *testing.T) {
+	clock := useFakeClock(t)
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+
+	progress := client.Log.Every(time.Minute)
+	for i := 1; i <= 50; i++ {
+		if _, err := progress.Info(ctx, fmt.Sprintf("processed %d items", i)); err != nil {
+			t.Fatalf("Info(%d): %v", i, err)
+		}
+		clock.Advance(time.Second)
+	}
+	if got := logTexts(srv); len(got) != 1 || got[0] != "processed 1 items" {
+		t.Fatalf("logs within the first interval = %q, want only the first message", got)
+	}
+
+	clock.Advance(10 * time.Second)
+	if _, err := progress.Info(ctx, "processed 51 items"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"processed 1 items", "processed 51 items (49 suppressed)"}
+	if got := logTexts(srv); !equalStrings(got, want) {
+		t.Errorf("logs = %q, want %q", got, want)
+	}
+}
+
+func TestEveryFlushSendsLatestSuppressed(t *testing.T) {
+	useFakeClock(t)
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+
+	progress := client.Log.Every(time.Hour)
+	progress.Info(ctx, "page 1")
+	progress.Info(ctx, "page 2")
+	progress.Warn(ctx, "page 3")
+	progress.Warn(ctx, "page 4")
+	if _, err := progress.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := progress.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	logs := srv.Logs()
+	if len(logs) != 2 {
+		t.Fatalf("Logs() = %+v, want the first message and one flushed", logs)
+	}
+	if logs[1].Level != cafesdk.LevelWarn || logs[1].Text != "page 4 (2 suppressed)" {
+		t.Errorf("flushed = %+v, want Warn %q", logs[1], "page 4 (2 suppressed)")
+	}
+}
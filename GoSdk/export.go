@@ -0,0 +1,103 @@
+package cafesdk
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ErrNotCaptured is returned by ExportCSV when records are not being
+// captured locally.
+var ErrNotCaptured = errors.New("cafesdk: pushed records are not captured; enable dry run or Result.SetCapture")
+
+// capturedRecords keeps the records pushed to the default dataset for
+// ExportCSV.
+type capturedRecords struct {
+	mu      sync.Mutex
+	enabled bool
+	records []string
+}
+
+// SetCapture keeps a local copy of every record the Client pushes to the
+// default dataset from now on, so ExportCSV can write them out. Records are
+// captured in dry-run mode without it. Turning capture off drops what was
+// kept.
+func (r _Result) SetCapture(enabled bool) {
+	r.c.captured.mu.Lock()
+	defer r.c.captured.mu.Unlock()
+	r.c.captured.enabled = enabled
+	if !enabled {
+		r.c.captured.records = nil
+	}
+}
+
+func (s *capturedRecords) add(jsonString string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.enabled || dryRun.Load() {
+		s.records = append(s.records, jsonString)
+	}
+}
+
+// ExportCSV writes the records captured so far (see SetCapture) to w as CSV
+// for inspection after a local run. The first row holds the keys of the
+// last header set with SetTableHeader, which also give the column order.
+// Fields not in the header are left out, and the cells of missing or null
+// fields are empty. Strings and numbers are written as is and other values
+// as JSON. Records that are not JSON objects are skipped. It returns
+// ErrNotCaptured when nothing is being captured and ErrNoHeader before a
+// header is set.
+func (r _Result) ExportCSV(w io.Writer) error {
+	r.c.captured.mu.Lock()
+	records := r.c.captured.records
+	capturing := r.c.captured.enabled || dryRun.Load() || records != nil
+	r.c.captured.mu.Unlock()
+	if !capturing {
+		return ErrNotCaptured
+	}
+	keys := r.c.header.headerKeys()
+	if keys == nil {
+		return ErrNoHeader
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(keys); err != nil {
+		return err
+	}
+	row := make([]string, len(keys))
+	for _, record := range records {
+		var fields map[string]json.RawMessage
+		if json.Unmarshal([]byte(record), &fields) != nil || fields == nil {
+			continue
+		}
+		for i, key := range keys {
+			row[i] = csvCell(fields[key])
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("cafesdk: export csv: %w", err)
+	}
+	return nil
+}
+
+func csvCell(raw json.RawMessage) string {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return ""
+	}
+	if raw[0] == '"' {
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			return s
+		}
+	}
+	return string(raw)
+}
@@ -0,0 +1,89 @@
+package cafesdk_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	cafesdk "test/GoSdk"
+)
+
+func exportCSV(t *testing.T, client *cafesdk.Client) string {
+	t.Helper()
+	var out strings.Builder
+	if err := client.Result.ExportCSV(&out); err != nil {
+		t.Fatalf("ExportCSV: %v", err)
+	}
+	return out.String()
+}
+
+func TestExportCSVFollowsHeaderOrder(t *testing.T) {
+	client, _ := newTestClient(t)
+	client.Result.SetCapture(true)
+	ctx := context.Background()
+
+	header := []*cafesdk.TableHeaderItem{column("title"), column("price"), column("url")}
+	if _, err := client.Result.SetTableHeader(ctx, header); err != nil {
+		t.Fatal(err)
+	}
+	client.Result.PushData(ctx, `{"url":"https://a.example","price":9.5,"title":"A, with comma","extra":1}`)
+	client.Result.PushData(ctx, `{"price":12,"title":"B","url":"https://b.example"}`)
+
+	want := "title,price,url\n" +
+		"\"A, with comma\",9.5,https://a.example\n" +
+		"B,12,https://b.example\n"
+	if got := exportCSV(t, client); got != want {
+		t.Errorf("ExportCSV =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestExportCSVEmptyCellsForMissingFields(t *testing.T) {
+	client, _ := newTestClient(t)
+	client.Result.SetCapture(true)
+	ctx := context.Background()
+
+	client.Result.SetTableHeader(ctx, []*cafesdk.TableHeaderItem{column("id"), column("name"), column("tags")})
+	client.Result.PushData(ctx, `{"id":1}`)
+	client.Result.PushData(ctx, `{"id":2,"name":null,"tags":["x","y"]}`)
+	client.Result.PushData(ctx, `["not","an","object"]`)
+	client.Result.PushData(ctx, `{"name":"only name"}`)
+
+	want := "id,name,tags\n" +
+		"1,,\n" +
+		"2,,\"[\"\"x\"\",\"\"y\"\"]\"\n" +
+		",only name,\n"
+	if got := exportCSV(t, client); got != want {
+		t.Errorf("ExportCSV =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestExportCSVInDryRun(t *testing.T) {
+	cafesdk.SetDryRun(true)
+	t.Cleanup(func() { cafesdk.SetDryRun(false) })
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+
+	client.Result.SetTableHeader(ctx, []*cafesdk.TableHeaderItem{column("n")})
+	client.Result.PushBatch(ctx, []string{`{"n":1}`, `{"n":2}`})
+	if got, want := exportCSV(t, client), "n\n1\n2\n"; got != want {
+		t.Errorf("ExportCSV = %q, want %q", got, want)
+	}
+	if n := len(srv.Calls()); n != 0 {
+		t.Errorf("dry run made %d RPCs", n)
+	}
+}
+
+func TestExportCSVErrors(t *testing.T) {
+	client, _ := newTestClient(t)
+	var out strings.Builder
+
+	if err := client.Result.ExportCSV(&out); !errors.Is(err, cafesdk.ErrNotCaptured) {
+		t.Errorf("ExportCSV without capture = %v, want ErrNotCaptured", err)
+	}
+	client.Result.SetCapture(true)
+	client.Result.PushData(context.Background(), `{"n":1}`)
+	if err := client.Result.ExportCSV(&out); !errors.Is(err, cafesdk.ErrNoHeader) {
+		t.Errorf("ExportCSV without a header = %v, want ErrNoHeader", err)
+	}
+}
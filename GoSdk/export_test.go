@@ -0,0 +1,45 @@
+package cafesdk
+
+import (
+	"testing"
+	"time"
+)
+
+// UseDefaultClient makes c the default Client behind the package-level
+// functions and Parameter, Result and Log until the test ends, so tests of
+// package-level behavior do not share the real default Client.
+func UseDefaultClient(t testing.TB, c *Client) {
+	t.Helper()
+	prev := defaultClient
+	set := func(c *Client) {
+		defaultClient = c
+		Parameter, Result, Log = c.Parameter, c.Result, c.Log
+	}
+	set(c)
+	t.Cleanup(func() { set(prev) })
+}
+
+// ResetProgress forgets when progress was last sent, so the throttle of one
+// test does not carry over to the next.
+func ResetProgress(t testing.TB) {
+	t.Helper()
+	reset := func() {
+		progressMu.Lock()
+		progressSent = time.Time{}
+		progressMu.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+// ResetRunInfo drops the cached RunInfo, before the test and after it.
+func ResetRunInfo(t testing.TB) {
+	t.Helper()
+	reset := func() {
+		runInfoMu.Lock()
+		runInfo, runInfoLoaded = RunMetadata{}, false
+		runInfoMu.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
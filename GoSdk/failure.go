@@ -0,0 +1,63 @@
+package cafesdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// FailureLogPrefix starts the Error log line that carries a failure report.
+// The platform has no failure RPC, so ReportFailure sends, regardless of
+// SetLogLevel,
+// "[cafesdk:failure] {"code":"LOGIN_REQUIRED","message":"...","details":{...}}".
+const FailureLogPrefix = "[cafesdk:failure] "
+
+// FailureCodePanic is the failure code Recover reports.
+const FailureCodePanic = "PANIC"
+
+type failureReport struct {
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// failureState remembers whether a Client has reported its failure.
+type failureState struct {
+	mu   sync.Mutex
+	sent bool
+}
+
+// ReportFailure reports why the run failed on the default Client. See
+// Client.ReportFailure.
+func ReportFailure(ctx context.Context, code, message string, details map[string]any) (*Response, error) {
+	return defaultClient.ReportFailure(ctx, code, message, details)
+}
+
+// ReportFailure sends a structured failure record, with a machine-readable
+// code the platform can categorize by, and flushes buffered logs so the
+// record is delivered before the actor exits. A run has one failure: once a
+// report is sent, later calls return an empty Response without an RPC.
+func (c *Client) ReportFailure(ctx context.Context, code, message string, details map[string]any) (*Response, error) {
+	c.failure.mu.Lock()
+	defer c.failure.mu.Unlock()
+
+	if c.failure.sent {
+		return &Response{}, nil
+	}
+
+	b, err := json.Marshal(failureReport{Code: code, Message: message, Details: details})
+	if err != nil {
+		return nil, fmt.Errorf("cafesdk: marshal failure details: %w", err)
+	}
+	res, err := c.Log.emit(ctx, LevelError, FailureLogPrefix+string(b))
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Log.Flush(ctx); err != nil {
+		return nil, err
+	}
+
+	c.failure.sent = true
+	return res, nil
+}
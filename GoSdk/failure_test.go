@@ -0,0 +1,92 @@
+package cafesdk_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	cafesdk "test/GoSdk"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestReportFailurePayload(t *testing.T) {
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+
+	details := map[string]any{"url": "https://example.com/login", "status": 401}
+	if _, err := client.ReportFailure(ctx, "LOGIN_REQUIRED", "session expired", details); err != nil {
+		t.Fatalf("ReportFailure: %v", err)
+	}
+
+	logs := srv.Logs()
+	if len(logs) != 1 || logs[0].Level != cafesdk.LevelError {
+		t.Fatalf("Logs() = %+v, want one Error line", logs)
+	}
+	payload, ok := strings.CutPrefix(logs[0].Text, cafesdk.FailureLogPrefix)
+	if !ok {
+		t.Fatalf("log line %q lacks %q", logs[0].Text, cafesdk.FailureLogPrefix)
+	}
+	var got map[string]any
+	if err := json.Unmarshal([]byte(payload), &got); err != nil {
+		t.Fatalf("payload %q: %v", payload, err)
+	}
+	want := map[string]any{
+		"code":    "LOGIN_REQUIRED",
+		"message": "session expired",
+		"details": map[string]any{"url": "https://example.com/login", "status": float64(401)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("payload = %v, want %v", got, want)
+	}
+}
+
+func TestReportFailureSentOnce(t *testing.T) {
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+
+	for range 3 {
+		if _, err := client.ReportFailure(ctx, "BLOCKED", "captcha", nil); err != nil {
+			t.Fatalf("ReportFailure: %v", err)
+		}
+	}
+	if n := len(srv.CallsTo(cafesdk.MethodLogError)); n != 1 {
+		t.Errorf("%d failure RPCs, want 1", n)
+	}
+	if text := srv.Logs()[0].Text; strings.Contains(text, "details") {
+		t.Errorf("payload %q has details, want them omitted when nil", text)
+	}
+}
+
+func TestReportFailureIgnoresLogLevel(t *testing.T) {
+	cafesdk.SetLogLevel(cafesdk.LevelError + 1)
+	t.Cleanup(func() { cafesdk.SetLogLevel(cafesdk.LevelDebug) })
+	client, srv := newTestClient(t)
+
+	if _, err := client.ReportFailure(context.Background(), "BLOCKED", "captcha", nil); err != nil {
+		t.Fatalf("ReportFailure: %v", err)
+	}
+	if n := len(srv.CallsTo(cafesdk.MethodLogError)); n != 1 {
+		t.Errorf("%d failure RPCs with the level filter above Error, want 1", n)
+	}
+}
+
+func TestReportFailureRetriesAfterError(t *testing.T) {
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+	srv.Fail(cafesdk.MethodLogError, status.Error(codes.InvalidArgument, "rejected"))
+
+	if _, err := client.ReportFailure(ctx, "BLOCKED", "captcha", nil); err == nil {
+		t.Fatal("ReportFailure succeeded against a failing server")
+	}
+	srv.Fail(cafesdk.MethodLogError, nil)
+	if _, err := client.ReportFailure(ctx, "BLOCKED", "captcha", nil); err != nil {
+		t.Fatalf("ReportFailure after the server recovered: %v", err)
+	}
+	if logs := srv.Logs(); len(logs) != 1 {
+		t.Errorf("Logs() = %+v, want the failure delivered once", logs)
+	}
+}
@@ -0,0 +1,35 @@
+package cafesdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// MaxFileSize is the largest file PushFile accepts. Base64 grows the data by
+// a third, so the record stays under gRPC's default 4 MB message limit.
+const MaxFileSize = 2 << 20
+
+// ErrFileTooLarge is returned by PushFile for files over MaxFileSize.
+var ErrFileTooLarge = errors.New("cafesdk: file exceeds MaxFileSize")
+
+// fileRecord is the record PushFile pushes; Data is base64-encoded by
+// encoding/json.
+type fileRecord struct {
+	Name string `json:"file_name"`
+	MIME string `json:"mime_type"`
+	Size int    `json:"size"`
+	Data []byte `json:"content_base64"`
+}
+
+// PushFile pushes a binary artifact such as a screenshot or PDF. The
+// platform has no file upload RPC, so the file is pushed as one result
+// record holding its name, MIME type, size and base64 content, and the
+// platform assigns no URL; the returned Response is that of the push.
+// Files over MaxFileSize are rejected without an RPC.
+func (r _Result) PushFile(ctx context.Context, name, mime string, data []byte) (*Response, error) {
+	if len(data) > MaxFileSize {
+		return nil, fmt.Errorf("%w: %s is %d bytes, limit %d", ErrFileTooLarge, name, len(data), MaxFileSize)
+	}
+	return r.Push(ctx, fileRecord{Name: name, MIME: mime, Size: len(data), Data: data})
+}
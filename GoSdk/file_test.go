@@ -0,0 +1,60 @@
+package cafesdk_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	cafesdk "test/GoSdk"
+)
+
+func TestPushFileRoundTrip(t *testing.T) {
+	client, srv := newTestClient(t)
+	data := []byte{0x89, 'P', 'N', 'G', 0, 0xff, '\n'}
+
+	if _, err := client.Result.PushFile(context.Background(), "shot.png", "image/png", data); err != nil {
+		t.Fatalf("PushFile: %v", err)
+	}
+
+	got := srv.Data()
+	if len(got) != 1 {
+		t.Fatalf("server received %d records, want 1", len(got))
+	}
+	var record struct {
+		Name string `json:"file_name"`
+		MIME string `json:"mime_type"`
+		Size int    `json:"size"`
+		Data []byte `json:"content_base64"`
+	}
+	if err := json.Unmarshal([]byte(got[0]), &record); err != nil {
+		t.Fatalf("record %q: %v", got[0], err)
+	}
+	if record.Name != "shot.png" || record.MIME != "image/png" || record.Size != len(data) || !bytes.Equal(record.Data, data) {
+		t.Errorf("record = %+v, want the file back", record)
+	}
+}
+
+func TestPushFileTooLarge(t *testing.T) {
+	client, srv := newTestClient(t)
+
+	_, err := client.Result.PushFile(context.Background(), "big.pdf", "application/pdf", make([]byte, cafesdk.MaxFileSize+1))
+	if !errors.Is(err, cafesdk.ErrFileTooLarge) {
+		t.Fatalf("PushFile = %v, want ErrFileTooLarge", err)
+	}
+	if n := len(srv.Calls()); n != 0 {
+		t.Errorf("oversized file made %d RPCs", n)
+	}
+}
+
+func TestPushFileAtLimit(t *testing.T) {
+	client, srv := newTestClient(t)
+
+	if _, err := client.Result.PushFile(context.Background(), "max.bin", "application/octet-stream", make([]byte, cafesdk.MaxFileSize)); err != nil {
+		t.Fatalf("PushFile at MaxFileSize: %v", err)
+	}
+	if n := len(srv.Data()); n != 1 {
+		t.Errorf("server received %d records, want 1", n)
+	}
+}
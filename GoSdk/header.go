@@ -0,0 +1,340 @@
+package cafesdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Column formats accepted by SetTableHeader.
+const (
+	FormatText    = "text"
+	FormatInteger = "integer"
+	FormatNumber  = "number"
+	FormatBoolean = "boolean"
+	FormatArray   = "array"
+	FormatObject  = "object"
+	FormatLink    = "link"
+	FormatImage   = "image"
+	FormatDate    = "date"
+)
+
+var knownFormats = map[string]struct{}{
+	FormatText: {}, FormatInteger: {}, FormatNumber: {}, FormatBoolean: {}, FormatArray: {},
+	FormatObject: {}, FormatLink: {}, FormatImage: {}, FormatDate: {},
+}
+
+// rawFormatPrefix marks a format returned by FormatRaw.
+const rawFormatPrefix = "cafesdk-raw:"
+
+// FormatRaw returns format marked so SetTableHeader accepts it without
+// checking, for platform formats this SDK does not know about yet. The mark
+// is removed before the header is sent.
+func FormatRaw(format string) string {
+	return rawFormatPrefix + format
+}
+
+// prepareHeader checks the format of every column and returns the columns
+// to send, with FormatRaw marks removed from copies of the marked ones. A
+// column without a format is sent as is, for the platform's default.
+func prepareHeader(headers []*TableHeaderItem) ([]*TableHeaderItem, error) {
+	out, copied := headers, false
+	for i, h := range headers {
+		format := h.GetFormat()
+		if raw, ok := strings.CutPrefix(format, rawFormatPrefix); ok {
+			if !copied {
+				out, copied = append([]*TableHeaderItem(nil), headers...), true
+			}
+			item := proto.Clone(h).(*TableHeaderItem)
+			item.Format = raw
+			out[i] = item
+			continue
+		}
+		if _, ok := knownFormats[format]; !ok && format != "" {
+			return nil, fmt.Errorf("cafesdk: table header key %q has unknown format %q (use FormatRaw to pass it through)", h.GetKey(), format)
+		}
+	}
+	return out, nil
+}
+
+var headerValidation atomic.Bool
+
+// headerTracker records, per Client, the keys of the last header set, used
+// by PushRow and VerifyHeader, and while header validation is enabled the
+// keys of pushed records.
+type headerTracker struct {
+	mu   sync.Mutex
+	keys []string
+	seen map[string]struct{}
+}
+
+// sentHeaders remembers the encoding of the last header sent per dataset,
+// so an identical SetTableHeader can skip the RPC.
+type sentHeaders struct {
+	mu   sync.Mutex
+	last map[string][]byte
+}
+
+func (s *sentHeaders) unchanged(dataset string, encoded []byte) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	last, ok := s.last[dataset]
+	return ok && bytes.Equal(last, encoded)
+}
+
+func (s *sentHeaders) record(dataset string, encoded []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.last == nil {
+		s.last = map[string][]byte{}
+	}
+	s.last[dataset] = encoded
+}
+
+// SetHeaderValidation turns on tracking of pushed record keys so
+// VerifyHeader can report mismatches with the header. It is off by default
+// because every pushed record is parsed while it is on.
+func SetHeaderValidation(enabled bool) {
+	headerValidation.Store(enabled)
+}
+
+// VerifyHeader compares the last header set with the keys of the records
+// pushed since validation was enabled. Each mismatch is logged with Log.Warn
+// and returned; nothing is reported while validation is off.
+func (r _Result) VerifyHeader(ctx context.Context) []string {
+	if !headerValidation.Load() {
+		return nil
+	}
+	t := &r.c.header
+	t.mu.Lock()
+	var warnings []string
+	inHeader := make(map[string]struct{}, len(t.keys))
+	for _, key := range t.keys {
+		inHeader[key] = struct{}{}
+		if _, ok := t.seen[key]; !ok {
+			warnings = append(warnings, fmt.Sprintf("table header key %q is not present in any pushed record", key))
+		}
+	}
+	var extra []string
+	for key := range t.seen {
+		if _, ok := inHeader[key]; !ok {
+			extra = append(extra, key)
+		}
+	}
+	t.mu.Unlock()
+
+	sort.Strings(extra)
+	for _, key := range extra {
+		warnings = append(warnings, fmt.Sprintf("pushed record key %q has no table header column", key))
+	}
+
+	for _, w := range warnings {
+		r.c.Log.Warn(ctx, w)
+	}
+	return warnings
+}
+
+func (t *headerTracker) trackHeader(headers []*TableHeaderItem) {
+	keys := make([]string, 0, len(headers))
+	for _, h := range headers {
+		keys = append(keys, h.GetKey())
+	}
+
+	t.mu.Lock()
+	t.keys = keys
+	t.mu.Unlock()
+}
+
+// headerKeys returns the keys of the last header set, or nil if none was.
+func (t *headerTracker) headerKeys() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.keys
+}
+
+func (t *headerTracker) trackRecord(jsonString string) {
+	if !headerValidation.Load() {
+		return
+	}
+	var record map[string]json.RawMessage
+	if json.Unmarshal([]byte(jsonString), &record) != nil {
+		return
+	}
+
+	t.mu.Lock()
+	if t.seen == nil {
+		t.seen = map[string]struct{}{}
+	}
+	for key := range record {
+		t.seen[key] = struct{}{}
+	}
+	t.mu.Unlock()
+}
+
+// Column alignments accepted by HeaderBuilder.Align.
+const (
+	AlignLeft   = "left"
+	AlignRight  = "right"
+	AlignCenter = "center"
+)
+
+// ColumnHintsHeader is the gRPC metadata key carrying the display hints of a
+// header sent by HeaderBuilder.Send, as a JSON array of ColumnHint.
+// TableHeaderItem has no fields for them, and a platform that does not read
+// the key renders the columns with its defaults.
+const ColumnHintsHeader = "x-column-hints"
+
+// ColumnHint is the display hint of one column, as sent in
+// ColumnHintsHeader.
+type ColumnHint struct {
+	Key   string `json:"key"`
+	Width int    `json:"width,omitempty"` // pixels
+	Align string `json:"align,omitempty"`
+}
+
+// HeaderBuilder assembles a table header one column at a time. Each method
+// names the column format, so no format strings are spelled out by hand.
+type HeaderBuilder struct {
+	r     _Result
+	items []*TableHeaderItem
+	hints []ColumnHint
+	err   error
+}
+
+// NewHeaderBuilder returns an empty HeaderBuilder whose Send sets the
+// header through r.
+func (r _Result) NewHeaderBuilder() *HeaderBuilder {
+	return &HeaderBuilder{r: r}
+}
+
+func (b *HeaderBuilder) Text(key, label string) *HeaderBuilder {
+	return b.add(key, label, FormatText)
+}
+
+func (b *HeaderBuilder) Integer(key, label string) *HeaderBuilder {
+	return b.add(key, label, FormatInteger)
+}
+
+func (b *HeaderBuilder) Number(key, label string) *HeaderBuilder {
+	return b.add(key, label, FormatNumber)
+}
+
+func (b *HeaderBuilder) Boolean(key, label string) *HeaderBuilder {
+	return b.add(key, label, FormatBoolean)
+}
+
+func (b *HeaderBuilder) Array(key, label string) *HeaderBuilder {
+	return b.add(key, label, FormatArray)
+}
+
+func (b *HeaderBuilder) Object(key, label string) *HeaderBuilder {
+	return b.add(key, label, FormatObject)
+}
+
+func (b *HeaderBuilder) Link(key, label string) *HeaderBuilder {
+	return b.add(key, label, FormatLink)
+}
+
+func (b *HeaderBuilder) Image(key, label string) *HeaderBuilder {
+	return b.add(key, label, FormatImage)
+}
+
+func (b *HeaderBuilder) Date(key, label string) *HeaderBuilder {
+	return b.add(key, label, FormatDate)
+}
+
+// Width hints the display width, in pixels, of the column added last. Only
+// Send sends hints.
+func (b *HeaderBuilder) Width(px int) *HeaderBuilder {
+	if px <= 0 {
+		return b.fail(fmt.Errorf("cafesdk: column width must be positive, got %d", px))
+	}
+	return b.hint(func(h *ColumnHint) { h.Width = px })
+}
+
+// Align hints the alignment of the column added last: AlignLeft, AlignRight
+// or AlignCenter. Only Send sends hints.
+func (b *HeaderBuilder) Align(align string) *HeaderBuilder {
+	switch align {
+	case AlignLeft, AlignRight, AlignCenter:
+	default:
+		return b.fail(fmt.Errorf("cafesdk: unknown column alignment %q", align))
+	}
+	return b.hint(func(h *ColumnHint) { h.Align = align })
+}
+
+// Build returns the header columns in the order they were added. It fails
+// if two columns share a key or a Width or Align hint was invalid.
+func (b *HeaderBuilder) Build() ([]*TableHeaderItem, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	seen := make(map[string]struct{}, len(b.items))
+	for _, item := range b.items {
+		if _, ok := seen[item.Key]; ok {
+			return nil, fmt.Errorf("cafesdk: duplicate table header key %q", item.Key)
+		}
+		seen[item.Key] = struct{}{}
+	}
+	return append([]*TableHeaderItem(nil), b.items...), nil
+}
+
+// Hints returns the hints of the columns given a Width or Align, in the
+// order the columns were added.
+func (b *HeaderBuilder) Hints() []ColumnHint {
+	var hints []ColumnHint
+	for _, h := range b.hints {
+		if h.Width > 0 || h.Align != "" {
+			hints = append(hints, h)
+		}
+	}
+	return hints
+}
+
+// Send builds the header and sets it as SetTableHeader does, with the
+// hints in ColumnHintsHeader. It sends at once, even with
+// SetHeaderCoalescing, and a change of hints alone is sent again.
+func (b *HeaderBuilder) Send(ctx context.Context) (*Response, error) {
+	items, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	var hints string
+	if h := b.Hints(); h != nil {
+		encoded, err := json.Marshal(h)
+		if err != nil {
+			return nil, fmt.Errorf("cafesdk: encode column hints: %w", err)
+		}
+		hints = string(encoded)
+	}
+	return b.r.sendTableHeader(ctx, "", items, hints, false)
+}
+
+func (b *HeaderBuilder) add(key, label, format string) *HeaderBuilder {
+	b.items = append(b.items, &TableHeaderItem{Label: label, Key: key, Format: format})
+	b.hints = append(b.hints, ColumnHint{Key: key})
+	return b
+}
+
+func (b *HeaderBuilder) hint(set func(*ColumnHint)) *HeaderBuilder {
+	if len(b.hints) == 0 {
+		return b.fail(errors.New("cafesdk: column hint given before any column"))
+	}
+	set(&b.hints[len(b.hints)-1])
+	return b
+}
+
+func (b *HeaderBuilder) fail(err error) *HeaderBuilder {
+	if b.err == nil {
+		b.err = err
+	}
+	return b
+}
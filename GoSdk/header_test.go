@@ -0,0 +1,275 @@
+package cafesdk_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	cafesdk "test/GoSdk"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestVerifyHeaderWarnsOnTypo(t *testing.T) {
+	cafesdk.SetHeaderValidation(true)
+	t.Cleanup(func() { cafesdk.SetHeaderValidation(false) })
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+
+	header := []*cafesdk.TableHeaderItem{
+		{Key: "title", Label: "Title", Format: cafesdk.FormatText},
+		{Key: "pirce", Label: "Price", Format: cafesdk.FormatNumber},
+	}
+	if _, err := client.Result.SetTableHeader(ctx, header); err != nil {
+		t.Fatalf("SetTableHeader: %v", err)
+	}
+	if _, err := client.Result.PushData(ctx, `{"title":"pen","price":1.5}`); err != nil {
+		t.Fatalf("PushData: %v", err)
+	}
+
+	warnings := client.Result.VerifyHeader(ctx)
+	if len(warnings) != 2 || !strings.Contains(warnings[0], `"pirce"`) || !strings.Contains(warnings[1], `"price"`) {
+		t.Fatalf("VerifyHeader = %q, want the typo and the uncovered key", warnings)
+	}
+	var logged []string
+	for _, line := range srv.Logs() {
+		if line.Level == cafesdk.LevelWarn {
+			logged = append(logged, line.Text)
+		}
+	}
+	if !equalStrings(logged, warnings) {
+		t.Errorf("Warn lines = %q, want %q", logged, warnings)
+	}
+}
+
+func TestVerifyHeaderOffByDefault(t *testing.T) {
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+
+	header := []*cafesdk.TableHeaderItem{{Key: "pirce", Label: "Price", Format: cafesdk.FormatNumber}}
+	if _, err := client.Result.SetTableHeader(ctx, header); err != nil {
+		t.Fatalf("SetTableHeader: %v", err)
+	}
+	if warnings := client.Result.VerifyHeader(ctx); warnings != nil {
+		t.Errorf("VerifyHeader = %q with validation off, want nil", warnings)
+	}
+	if n := len(srv.Logs()); n != 0 {
+		t.Errorf("%d log lines with validation off", n)
+	}
+}
+
+func TestHeaderBuilderColumnTypes(t *testing.T) {
+	b := cafesdk.Result.NewHeaderBuilder()
+	items, err := b.Text("t", "T").Integer("i", "I").Number("n", "N").Boolean("b", "B").
+		Array("a", "A").Object("o", "O").Link("l", "L").Image("img", "Img").Date("d", "D").Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	want := []struct{ key, label, format string }{
+		{"t", "T", cafesdk.FormatText},
+		{"i", "I", cafesdk.FormatInteger},
+		{"n", "N", cafesdk.FormatNumber},
+		{"b", "B", cafesdk.FormatBoolean},
+		{"a", "A", cafesdk.FormatArray},
+		{"o", "O", cafesdk.FormatObject},
+		{"l", "L", cafesdk.FormatLink},
+		{"img", "Img", cafesdk.FormatImage},
+		{"d", "D", cafesdk.FormatDate},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("Build returned %d columns, want %d", len(items), len(want))
+	}
+	for i, w := range want {
+		if got := items[i]; got.Key != w.key || got.Label != w.label || got.Format != w.format {
+			t.Errorf("column %d = %q/%q/%q, want %q/%q/%q", i, got.Key, got.Label, got.Format, w.key, w.label, w.format)
+		}
+	}
+}
+
+func TestHeaderBuilderDuplicateKey(t *testing.T) {
+	_, err := cafesdk.Result.NewHeaderBuilder().Text("url", "URL").Link("url", "Link").Build()
+	if err == nil || !strings.Contains(err.Error(), `duplicate table header key "url"`) {
+		t.Errorf("Build = %v, want a duplicate key error", err)
+	}
+}
+
+func TestSetTableHeaderKnownFormat(t *testing.T) {
+	client, srv := newTestClient(t)
+
+	header := []*cafesdk.TableHeaderItem{{Key: "url", Label: "URL", Format: cafesdk.FormatLink}}
+	if _, err := client.Result.SetTableHeader(context.Background(), header); err != nil {
+		t.Fatalf("SetTableHeader: %v", err)
+	}
+	if got := srv.Header(); len(got) != 1 || got[0].Format != cafesdk.FormatLink {
+		t.Errorf("server header = %v", got)
+	}
+}
+
+func TestSetTableHeaderRejectsUnknownFormat(t *testing.T) {
+	client, srv := newTestClient(t)
+
+	header := []*cafesdk.TableHeaderItem{{Key: "title", Label: "Title", Format: "txet"}}
+	_, err := client.Result.SetTableHeader(context.Background(), header)
+	if err == nil || !strings.Contains(err.Error(), `unknown format "txet"`) {
+		t.Fatalf("SetTableHeader = %v, want an unknown format error", err)
+	}
+	if n := srv.HeaderCalls(); n != 0 {
+		t.Errorf("rejected header made %d RPCs", n)
+	}
+}
+
+func TestSetTableHeaderUnsetFormat(t *testing.T) {
+	client, srv := newTestClient(t)
+
+	header := []*cafesdk.TableHeaderItem{{Key: "title", Label: "Title"}, column("url")}
+	if _, err := client.Result.SetTableHeader(context.Background(), header); err != nil {
+		t.Fatalf("SetTableHeader with an unset format: %v", err)
+	}
+	if got := srv.Header(); len(got) != 2 || got[0].Format != "" {
+		t.Errorf("server header = %v, want title sent without a format", got)
+	}
+}
+
+func TestSetTableHeaderRawFormat(t *testing.T) {
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+
+	item := &cafesdk.TableHeaderItem{Key: "trend", Label: "Trend", Format: cafesdk.FormatRaw("sparkline")}
+	if _, err := client.Result.SetTableHeader(ctx, []*cafesdk.TableHeaderItem{item}); err != nil {
+		t.Fatalf("SetTableHeader: %v", err)
+	}
+	if got := srv.Header(); len(got) != 1 || got[0].Format != "sparkline" {
+		t.Errorf("server header = %v, want format sparkline", got)
+	}
+	if item.Format != cafesdk.FormatRaw("sparkline") {
+		t.Errorf("caller's item format changed to %q", item.Format)
+	}
+
+	// Passing a format through once does not make it known.
+	plain := []*cafesdk.TableHeaderItem{{Key: "trend", Label: "Trend", Format: "sparkline"}}
+	if _, err := client.Result.SetTableHeader(ctx, plain); err == nil {
+		t.Error("SetTableHeader accepted an unmarked unknown format after FormatRaw")
+	}
+}
+
+func TestHeaderBuilderSendsHints(t *testing.T) {
+	client, srv := newTestClient(t)
+	b := client.Result.NewHeaderBuilder().
+		Link("url", "URL").Width(320).Align(cafesdk.AlignLeft).
+		Text("title", "Title").
+		Number("price", "Price").Align(cafesdk.AlignRight)
+
+	if _, err := b.Send(context.Background()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if got := srv.Header(); len(got) != 3 || got[0].Key != "url" || got[2].Format != cafesdk.FormatNumber {
+		t.Errorf("Header() = %v, want the three columns", got)
+	}
+	calls := srv.CallsTo(cafesdk.MethodSetTableHeader)
+	if len(calls) != 1 {
+		t.Fatalf("%d SetTableHeader calls, want 1", len(calls))
+	}
+	values := calls[0].Metadata.Get(cafesdk.ColumnHintsHeader)
+	if len(values) != 1 {
+		t.Fatalf("%s = %q, want one value", cafesdk.ColumnHintsHeader, values)
+	}
+	var hints []cafesdk.ColumnHint
+	if err := json.Unmarshal([]byte(values[0]), &hints); err != nil {
+		t.Fatalf("hints %q: %v", values[0], err)
+	}
+	want := []cafesdk.ColumnHint{
+		{Key: "url", Width: 320, Align: cafesdk.AlignLeft},
+		{Key: "price", Align: cafesdk.AlignRight},
+	}
+	if !reflect.DeepEqual(hints, want) {
+		t.Errorf("hints = %+v, want %+v", hints, want)
+	}
+
+	// The same columns with other hints are sent again.
+	b = client.Result.NewHeaderBuilder().
+		Link("url", "URL").Width(200).
+		Text("title", "Title").
+		Number("price", "Price").Align(cafesdk.AlignRight)
+	if _, err := b.Send(context.Background()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if n := len(srv.CallsTo(cafesdk.MethodSetTableHeader)); n != 2 {
+		t.Errorf("%d SetTableHeader calls after changing a hint, want 2", n)
+	}
+}
+
+func TestHeaderBuilderWithoutHintsSendsNoMetadata(t *testing.T) {
+	client, srv := newTestClient(t)
+	if _, err := client.Result.NewHeaderBuilder().Text("title", "Title").Send(context.Background()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	calls := srv.CallsTo(cafesdk.MethodSetTableHeader)
+	if len(calls) != 1 || calls[0].Metadata.Get(cafesdk.ColumnHintsHeader) != nil {
+		t.Errorf("calls = %+v, want one without %s", calls, cafesdk.ColumnHintsHeader)
+	}
+}
+
+func TestHeaderBuilderInvalidHints(t *testing.T) {
+	tests := []struct {
+		name string
+		b    *cafesdk.HeaderBuilder
+		want string
+	}{
+		{"width", cafesdk.Result.NewHeaderBuilder().Text("t", "T").Width(0), "width must be positive"},
+		{"align", cafesdk.Result.NewHeaderBuilder().Text("t", "T").Align("justify"), `unknown column alignment "justify"`},
+		{"no column", cafesdk.Result.NewHeaderBuilder().Width(10), "before any column"},
+	}
+	for _, tt := range tests {
+		if _, err := tt.b.Build(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: Build = %v, want an error containing %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestSetTableHeaderSkipsIdenticalHeader(t *testing.T) {
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+	header := func(priceFormat string) []*cafesdk.TableHeaderItem {
+		return []*cafesdk.TableHeaderItem{
+			{Key: "title", Label: "Title", Format: cafesdk.FormatText},
+			{Key: "price", Label: "Price", Format: priceFormat},
+		}
+	}
+	calls := func() int { return len(srv.CallsTo(cafesdk.MethodSetTableHeader)) }
+
+	if _, err := client.Result.SetTableHeader(ctx, header(cafesdk.FormatNumber)); err != nil || calls() != 1 {
+		t.Fatalf("first SetTableHeader = %v, %d calls, want 1", err, calls())
+	}
+	res, err := client.Result.SetTableHeader(ctx, header(cafesdk.FormatNumber))
+	if err != nil || !res.OK() || calls() != 1 {
+		t.Errorf("identical SetTableHeader = %+v, %v, %d calls, want a success without an RPC", res, err, calls())
+	}
+	if _, err := client.Result.SetTableHeader(ctx, header(cafesdk.FormatText)); err != nil || calls() != 2 {
+		t.Errorf("changed SetTableHeader = %v, %d calls, want 2", err, calls())
+	}
+	if _, err := client.Result.ForceSetTableHeader(ctx, header(cafesdk.FormatText)); err != nil || calls() != 3 {
+		t.Errorf("ForceSetTableHeader = %v, %d calls, want 3", err, calls())
+	}
+}
+
+func TestSetTableHeaderResentAfterFailure(t *testing.T) {
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+	header := []*cafesdk.TableHeaderItem{{Key: "title", Label: "Title", Format: cafesdk.FormatText}}
+
+	srv.Fail(cafesdk.MethodSetTableHeader, status.Error(codes.Internal, "down"))
+	if _, err := client.Result.SetTableHeader(ctx, header); err == nil {
+		t.Fatal("SetTableHeader succeeded against a failing server")
+	}
+	srv.Fail(cafesdk.MethodSetTableHeader, nil)
+	if _, err := client.Result.SetTableHeader(ctx, header); err != nil {
+		t.Fatalf("SetTableHeader: %v", err)
+	}
+	if got := srv.HeaderCalls(); got != 1 {
+		t.Errorf("HeaderCalls() = %d, want the header delivered after the failure", got)
+	}
+}
@@ -0,0 +1,56 @@
+package cafesdk
+
+import (
+	"context"
+	"time"
+)
+
+// HeartbeatLogPrefix starts the Debug log lines sent as heartbeats. They go
+// straight to the platform, bypassing the level filter and log buffering.
+const HeartbeatLogPrefix = "[cafesdk:heartbeat] "
+
+// DefaultHeartbeatInterval is the heartbeat interval StartHeartbeat uses when
+// given none.
+const DefaultHeartbeatInterval = 30 * time.Second
+
+// Heartbeat periodically tells the platform the actor is alive.
+type Heartbeat struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// StartHeartbeat sends a heartbeat every interval until ctx is cancelled,
+// Stop is called or the SDK shuts down (see Shutdown). Failed heartbeats
+// are ignored; the next tick tries again. A non-positive interval selects
+// DefaultHeartbeatInterval.
+func StartHeartbeat(ctx context.Context, interval time.Duration) *Heartbeat {
+	if interval <= 0 {
+		interval = DefaultHeartbeatInterval
+	}
+	ctx, cancel := defaultClient.derive(ctx)
+	h := &Heartbeat{cancel: cancel, done: make(chan struct{})}
+	go h.run(ctx, interval)
+	return h
+}
+
+// Stop ends the heartbeat and waits for its goroutine to exit.
+func (h *Heartbeat) Stop() {
+	h.cancel()
+	<-h.done
+}
+
+func (h *Heartbeat) run(ctx context.Context, interval time.Duration) {
+	defer close(h.done)
+
+	ticker := currentClock().NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case t := <-ticker.C():
+			Log.deliver(ctx, LevelDebug, HeartbeatLogPrefix+t.UTC().Format(time.RFC3339))
+		}
+	}
+}
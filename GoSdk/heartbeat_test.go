@@ -0,0 +1,104 @@
+package cafesdk_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	cafesdk "test/GoSdk"
+	"test/GoSdk/cafesdktest"
+)
+
+// beats returns the heartbeat lines srv has received.
+func beats(srv *cafesdktest.Server) []string {
+	var out []string
+	for _, line := range srv.Logs() {
+		if strings.HasPrefix(line.Text, cafesdk.HeartbeatLogPrefix) {
+			out = append(out, line.Text)
+		}
+	}
+	return out
+}
+
+// waitForBeats waits until srv has received n heartbeats.
+func waitForBeats(t *testing.T, srv *cafesdktest.Server, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(beats(srv)) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d heartbeats, want %d", len(beats(srv)), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestHeartbeatFiresEachInterval(t *testing.T) {
+	clock := useFakeClock(t)
+	client, srv := newTestClient(t)
+	cafesdk.UseDefaultClient(t, client)
+
+	h := cafesdk.StartHeartbeat(context.Background(), 10*time.Second)
+	defer h.Stop()
+	clock.BlockUntil(1)
+	for i := 1; i <= 5; i++ {
+		clock.Advance(10 * time.Second)
+		waitForBeats(t, srv, i)
+	}
+
+	got := beats(srv)
+	if len(got) != 5 {
+		t.Fatalf("got %d heartbeats over 50s, want 5", len(got))
+	}
+	want := cafesdk.HeartbeatLogPrefix + clock.Now().UTC().Format(time.RFC3339)
+	if got[4] != want {
+		t.Errorf("last heartbeat = %q, want %q", got[4], want)
+	}
+}
+
+func TestHeartbeatStopsOnCancel(t *testing.T) {
+	clock := useFakeClock(t)
+	client, srv := newTestClient(t)
+	cafesdk.UseDefaultClient(t, client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h := cafesdk.StartHeartbeat(ctx, time.Second)
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	waitForBeats(t, srv, 1)
+
+	cancel()
+	stopped := make(chan struct{})
+	go func() {
+		h.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("heartbeat goroutine still running after cancel")
+	}
+
+	clock.Advance(10 * time.Second)
+	time.Sleep(20 * time.Millisecond)
+	if n := len(beats(srv)); n != 1 {
+		t.Errorf("got %d heartbeats after cancel, want none", n-1)
+	}
+}
+
+func TestHeartbeatDefaultInterval(t *testing.T) {
+	clock := useFakeClock(t)
+	client, srv := newTestClient(t)
+	cafesdk.UseDefaultClient(t, client)
+
+	h := cafesdk.StartHeartbeat(context.Background(), 0)
+	defer h.Stop()
+	clock.BlockUntil(1)
+	clock.Advance(cafesdk.DefaultHeartbeatInterval - time.Second)
+	time.Sleep(20 * time.Millisecond)
+	if n := len(beats(srv)); n != 0 {
+		t.Fatalf("got %d heartbeats before the default interval", n)
+	}
+	clock.Advance(time.Second)
+	waitForBeats(t, srv, 1)
+}
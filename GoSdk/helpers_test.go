@@ -0,0 +1,18 @@
+package cafesdk_test
+
+import (
+	"testing"
+
+	cafesdk "test/GoSdk"
+	"test/GoSdk/cafesdktest"
+)
+
+// newTestClient starts a test server and a Client connected to it, closed
+// when the test ends.
+func newTestClient(t *testing.T, opts ...cafesdk.Option) (*cafesdk.Client, *cafesdktest.Server) {
+	t.Helper()
+	srv := cafesdktest.Start(t)
+	client := cafesdk.New(append([]cafesdk.Option{cafesdk.WithAddress(srv.Addr)}, opts...)...)
+	t.Cleanup(func() { client.Close() })
+	return client, srv
+}
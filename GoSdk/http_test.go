@@ -0,0 +1,183 @@
+package cafesdk_test
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	cafesdk "test/GoSdk"
+)
+
+// proxyFor returns the proxy client's transport would use for target.
+func proxyFor(t *testing.T, client *http.Client, target string) string {
+	t.Helper()
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Transport is %T, want *http.Transport", client.Transport)
+	}
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := transport.Proxy(req)
+	if err != nil {
+		t.Fatalf("Proxy(%s): %v", target, err)
+	}
+	if u == nil {
+		return ""
+	}
+	return u.String()
+}
+
+func TestNewHTTPClientProxy(t *testing.T) {
+	tests := []struct {
+		name      string
+		proxyAuth string
+		opts      cafesdk.HTTPClientOptions
+		want      string
+	}{
+		{name: "no proxy", want: ""},
+		{name: "PROXY_AUTH", proxyAuth: "user:pass", want: "socks5://user:pass@" + cafesdk.ProxyDomain},
+		{name: "explicit", opts: cafesdk.HTTPClientOptions{ProxyURL: "http://proxy.local:8080"}, want: "http://proxy.local:8080"},
+		{name: "explicit over PROXY_AUTH", proxyAuth: "user:pass", opts: cafesdk.HTTPClientOptions{ProxyURL: "socks5://other:1080"}, want: "socks5://other:1080"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PROXY_AUTH", tt.proxyAuth)
+			for _, env := range []string{"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy"} {
+				t.Setenv(env, "")
+			}
+
+			client, err := cafesdk.NewHTTPClient(tt.opts)
+			if err != nil {
+				t.Fatalf("NewHTTPClient: %v", err)
+			}
+			if got := proxyFor(t, client, "https://example.com/"); got != tt.want {
+				t.Errorf("proxy = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewHTTPClientBadProxyURL(t *testing.T) {
+	if _, err := cafesdk.NewHTTPClient(cafesdk.HTTPClientOptions{ProxyURL: "://bad"}); err == nil {
+		t.Error("NewHTTPClient accepted a malformed proxy URL")
+	}
+}
+
+func TestNewHTTPClientTimeout(t *testing.T) {
+	client, err := cafesdk.NewHTTPClient(cafesdk.HTTPClientOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Timeout <= 0 {
+		t.Errorf("default Timeout = %v, want a bound", client.Timeout)
+	}
+	client, err = cafesdk.NewHTTPClient(cafesdk.HTTPClientOptions{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Timeout != 5*time.Second {
+		t.Errorf("Timeout = %v, want 5s", client.Timeout)
+	}
+}
+
+func TestNewHTTPClientVerifiesCertificates(t *testing.T) {
+	site := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	t.Cleanup(site.Close)
+
+	client, err := cafesdk.NewHTTPClient(cafesdk.HTTPClientOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tc := client.Transport.(*http.Transport).TLSClientConfig; tc != nil && tc.InsecureSkipVerify {
+		t.Fatal("default client skips certificate verification")
+	}
+	_, err = client.Get(site.URL)
+	var unknownAuthority x509.UnknownAuthorityError
+	if !errors.As(err, &unknownAuthority) {
+		t.Errorf("Get with a self-signed certificate = %v, want an unknown authority error", err)
+	}
+}
+
+func TestNewHTTPClientInsecureSkipTLSVerify(t *testing.T) {
+	sdk, srv := newTestClient(t)
+	cafesdk.UseDefaultClient(t, sdk)
+	site := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	t.Cleanup(site.Close)
+
+	client, err := cafesdk.NewHTTPClient(cafesdk.HTTPClientOptions{InsecureSkipTLSVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tc := client.Transport.(*http.Transport).TLSClientConfig; tc == nil || !tc.InsecureSkipVerify {
+		t.Fatal("InsecureSkipTLSVerify did not disable certificate verification")
+	}
+	resp, err := client.Get(site.URL)
+	if err != nil {
+		t.Fatalf("Get with a self-signed certificate: %v", err)
+	}
+	resp.Body.Close()
+
+	// The warning waits for a call: building the client must neither dial
+	// the platform nor lock the connection settings.
+	if n := len(srv.Calls()); n != 0 {
+		t.Fatalf("NewHTTPClient made %d RPCs", n)
+	}
+	if err := cafesdk.SetAddress(srv.Addr); err != nil {
+		t.Fatalf("SetAddress after NewHTTPClient: %v", err)
+	}
+	if _, err := sdk.Result.PushData(context.Background(), `{}`); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(srv.Logs()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if logs := srv.Logs(); len(logs) != 1 || logs[0].Level != cafesdk.LevelWarn || !strings.Contains(logs[0].Text, "verification disabled") {
+		t.Errorf("Logs() = %+v, want one Warn about disabled verification", logs)
+	}
+}
+
+func TestNewHTTPClientEnvironmentProxy(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       map[string]string
+		proxyAuth string
+		opts      cafesdk.HTTPClientOptions
+		target    string
+		want      string
+	}{
+		{name: "HTTPS_PROXY", env: map[string]string{"HTTPS_PROXY": "http://tls-proxy:3128"}, target: "https://example.com/", want: "http://tls-proxy:3128"},
+		{name: "HTTP_PROXY", env: map[string]string{"HTTP_PROXY": "http://plain-proxy:3128"}, target: "http://example.com/", want: "http://plain-proxy:3128"},
+		{name: "HTTP_PROXY skips https", env: map[string]string{"HTTP_PROXY": "http://plain-proxy:3128"}, target: "https://example.com/", want: ""},
+		{name: "lower case", env: map[string]string{"https_proxy": "http://lower:3128"}, target: "https://example.com/", want: "http://lower:3128"},
+		{name: "NO_PROXY host", env: map[string]string{"HTTPS_PROXY": "http://tls-proxy:3128", "NO_PROXY": "example.com"}, target: "https://example.com/", want: ""},
+		{name: "NO_PROXY subdomain", env: map[string]string{"HTTPS_PROXY": "http://tls-proxy:3128", "NO_PROXY": "internal.local,.example.com"}, target: "https://api.example.com/", want: ""},
+		{name: "NO_PROXY other host", env: map[string]string{"HTTPS_PROXY": "http://tls-proxy:3128", "NO_PROXY": "example.com"}, target: "https://example.org/", want: "http://tls-proxy:3128"},
+		{name: "PROXY_AUTH over env", env: map[string]string{"HTTPS_PROXY": "http://tls-proxy:3128"}, proxyAuth: "user:pass", target: "https://example.com/", want: "socks5://user:pass@" + cafesdk.ProxyDomain},
+		{name: "explicit over env", env: map[string]string{"HTTPS_PROXY": "http://tls-proxy:3128"}, opts: cafesdk.HTTPClientOptions{ProxyURL: "http://proxy.local:8080"}, target: "https://example.com/", want: "http://proxy.local:8080"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PROXY_AUTH", tt.proxyAuth)
+			for _, env := range []string{"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy"} {
+				t.Setenv(env, tt.env[env])
+			}
+
+			client, err := cafesdk.NewHTTPClient(tt.opts)
+			if err != nil {
+				t.Fatalf("NewHTTPClient: %v", err)
+			}
+			if got := proxyFor(t, client, tt.target); got != tt.want {
+				t.Errorf("proxy for %s = %q, want %q", tt.target, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package cafesdk
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// HTTPRetryPolicy controls DoWithRetry. A zero RetryPolicy selects
+// DefaultRetryPolicy. Its Retryable is ignored: network errors and
+// RetryStatuses decide what is retried.
+type HTTPRetryPolicy struct {
+	RetryPolicy
+	// RetryStatuses lists response codes worth retrying; it defaults to 429,
+	// 502, 503 and 504.
+	RetryStatuses []int
+	// RetryNonIdempotent also retries methods such as POST. Their body is
+	// rewound through Request.GetBody, which http.NewRequest sets for
+	// in-memory bodies.
+	RetryNonIdempotent bool
+}
+
+var defaultRetryStatuses = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// DoWithRetry sends req with client, retrying network errors and retryable
+// status codes with backoff. A Retry-After header replaces the computed
+// backoff. It gives up early rather than wait past the request context's
+// deadline, as measured on the SDK's Clock, returning the last response or
+// error. Each attempt's request
+// context carries its attempt number for AttemptFromContext.
+func DoWithRetry(client *http.Client, req *http.Request, policy HTTPRetryPolicy) (*http.Response, error) {
+	if policy.RetryPolicy.isZero() {
+		policy.RetryPolicy = DefaultRetryPolicy
+	}
+	statuses := policy.RetryStatuses
+	if statuses == nil {
+		statuses = defaultRetryStatuses
+	}
+
+	ctx := req.Context()
+	rewindable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	retryable := rewindable && (isIdempotent(req.Method) || policy.RetryNonIdempotent)
+
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("cafesdk: rewind request body: %w", err)
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+		req = req.WithContext(withAttempt(ctx, attempt))
+
+		resp, err := client.Do(req)
+		if !retryable || attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			return resp, err
+		}
+
+		wait := policy.backoff(attempt)
+		if err == nil {
+			if !slices.Contains(statuses, resp.StatusCode) {
+				return resp, nil
+			}
+			if d, ok := retryAfter(resp); ok {
+				wait = d
+			}
+		}
+		if deadline, ok := ctx.Deadline(); ok && clockNow().Add(wait).After(deadline) {
+			return resp, err
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if err := Sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(clockNow()), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,173 @@
+package cafesdk_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cafesdk "test/GoSdk"
+	"test/GoSdk/cafesdktest"
+)
+
+// useFakeClock makes the SDK wait on a fake clock until the test ends.
+func useFakeClock(t *testing.T) *cafesdktest.FakeClock {
+	t.Helper()
+	clock := cafesdktest.NewFakeClock(time.Unix(1_000_000, 0))
+	cafesdk.SetClock(clock)
+	t.Cleanup(func() { cafesdk.SetClock(nil) })
+	return clock
+}
+
+// flakySite answers with the given status codes in turn, then 200, and
+// records the body of every request.
+type flakySite struct {
+	mu         sync.Mutex
+	statuses   []int
+	retryAfter string
+	bodies     []string
+	hit        chan struct{}
+}
+
+func newFlakySite(t *testing.T, retryAfter string, statuses ...int) (*flakySite, string) {
+	t.Helper()
+	s := &flakySite{statuses: statuses, retryAfter: retryAfter, hit: make(chan struct{}, 100)}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return s, srv.URL
+}
+
+func (s *flakySite) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	s.bodies = append(s.bodies, string(body))
+	code := http.StatusOK
+	if len(s.statuses) > 0 {
+		code, s.statuses = s.statuses[0], s.statuses[1:]
+	}
+	s.mu.Unlock()
+
+	if code != http.StatusOK && s.retryAfter != "" {
+		w.Header().Set("Retry-After", s.retryAfter)
+	}
+	w.WriteHeader(code)
+	s.hit <- struct{}{}
+}
+
+func (s *flakySite) requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.bodies...)
+}
+
+func TestDoWithRetry503Then200(t *testing.T) {
+	site, url := newFlakySite(t, "", http.StatusServiceUnavailable)
+	req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader("payload"))
+	policy := cafesdk.HTTPRetryPolicy{
+		RetryPolicy:        cafesdk.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond},
+		RetryNonIdempotent: true,
+	}
+
+	resp, err := cafesdk.DoWithRetry(http.DefaultClient, req, policy)
+	if err != nil {
+		t.Fatalf("DoWithRetry: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+	if got := site.requests(); !equalStrings(got, []string{"payload", "payload"}) {
+		t.Errorf("request bodies = %q, want the body sent twice", got)
+	}
+}
+
+func TestDoWithRetryHonorsRetryAfter(t *testing.T) {
+	clock := useFakeClock(t)
+	site, url := newFlakySite(t, "120", http.StatusServiceUnavailable)
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	policy := cafesdk.HTTPRetryPolicy{RetryPolicy: cafesdk.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}}
+
+	done := make(chan *http.Response)
+	go func() {
+		resp, _ := cafesdk.DoWithRetry(http.DefaultClient, req, policy)
+		done <- resp
+	}()
+	<-site.hit
+	clock.BlockUntil(1)
+	clock.Advance(119 * time.Second)
+	select {
+	case <-done:
+		t.Fatal("retried before Retry-After elapsed")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	clock.Advance(time.Second)
+	resp := <-done
+	if resp == nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("response after Retry-After = %v, want 200", resp)
+	}
+	resp.Body.Close()
+	if n := len(site.requests()); n != 2 {
+		t.Errorf("site saw %d requests, want 2", n)
+	}
+}
+
+func TestDoWithRetryCancelledDuringBackoff(t *testing.T) {
+	clock := useFakeClock(t)
+	site, url := newFlakySite(t, "3600", http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPut, url, strings.NewReader("payload"))
+	policy := cafesdk.HTTPRetryPolicy{RetryPolicy: cafesdk.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}}
+
+	errc := make(chan error)
+	go func() {
+		_, err := cafesdk.DoWithRetry(http.DefaultClient, req, policy)
+		errc <- err
+	}()
+	<-site.hit
+	clock.BlockUntil(1)
+	cancel()
+
+	select {
+	case err := <-errc:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("DoWithRetry = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("DoWithRetry still waiting after cancellation")
+	}
+	if n := len(site.requests()); n != 1 {
+		t.Errorf("site saw %d requests, want 1", n)
+	}
+}
+
+func TestDoWithRetryDeadlineReadsClock(t *testing.T) {
+	clock := cafesdktest.NewFakeClock(time.Now().Add(2 * time.Hour))
+	cafesdk.SetClock(clock)
+	t.Cleanup(func() { cafesdk.SetClock(nil) })
+
+	site, url := newFlakySite(t, "60", http.StatusServiceUnavailable)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	policy := cafesdk.HTTPRetryPolicy{RetryPolicy: cafesdk.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}}
+
+	// By the clock the deadline has passed, so the 60s Retry-After is not
+	// waited out even though an hour of real time remains.
+	resp, err := cafesdk.DoWithRetry(http.DefaultClient, req, policy)
+	if err != nil {
+		t.Fatalf("DoWithRetry: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want the 503 returned without retrying", resp.StatusCode)
+	}
+	if n := len(site.requests()); n != 1 {
+		t.Errorf("site saw %d requests, want 1", n)
+	}
+}
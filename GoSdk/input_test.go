@@ -0,0 +1,88 @@
+package cafesdk_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// withArgs replaces the command line for the rest of the test.
+func withArgs(t *testing.T, args ...string) {
+	t.Helper()
+	saved := os.Args
+	os.Args = append([]string{saved[0]}, args...)
+	t.Cleanup(func() { os.Args = saved })
+}
+
+// unsetenv unsets key for the rest of the test.
+func unsetenv(t *testing.T, key string) {
+	t.Helper()
+	t.Setenv(key, "")
+	os.Unsetenv(key)
+}
+
+func writeInput(t *testing.T, name, input string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(input), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLocalInputPrecedence(t *testing.T) {
+	flagFile := writeInput(t, "flag.json", `{"from":"flag"}`)
+	envFile := writeInput(t, "env.json", `{"from":"file"}`)
+
+	tests := []struct {
+		name     string
+		args     []string
+		json     string // CAFE_INPUT_JSON; unset if empty
+		file     string // CAFE_INPUT_FILE; unset if empty
+		want     string
+		wantRPCs int
+	}{
+		{"flag beats everything", []string{"--input", flagFile}, `{"from":"env"}`, envFile, `{"from":"flag"}`, 0},
+		{"flag with equals", []string{"--input=" + flagFile}, "", "", `{"from":"flag"}`, 0},
+		{"env beats file", nil, `{"from":"env"}`, envFile, `{"from":"env"}`, 0},
+		{"file", nil, "", envFile, `{"from":"file"}`, 0},
+		{"flag after -- is ignored", []string{"--", "--input", flagFile}, "", "", `{"from":"server"}`, 1},
+		{"server", nil, "", "", `{"from":"server"}`, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withArgs(t, tt.args...)
+			unsetenv(t, "CAFE_INPUT_JSON")
+			unsetenv(t, "CAFE_INPUT_FILE")
+			if tt.json != "" {
+				t.Setenv("CAFE_INPUT_JSON", tt.json)
+			}
+			if tt.file != "" {
+				t.Setenv("CAFE_INPUT_FILE", tt.file)
+			}
+			client, srv := newTestClient(t)
+			srv.SetInput(`{"from":"server"}`)
+
+			got, err := client.Parameter.GetInputJSONString(context.Background())
+			if err != nil || got != tt.want {
+				t.Errorf("GetInputJSONString = %q, %v, want %q", got, err, tt.want)
+			}
+			if n := len(srv.Calls()); n != tt.wantRPCs {
+				t.Errorf("made %d RPCs, want %d", n, tt.wantRPCs)
+			}
+		})
+	}
+}
+
+func TestLocalInputMissingFile(t *testing.T) {
+	withArgs(t, "--input", filepath.Join(t.TempDir(), "missing.json"))
+	client, srv := newTestClient(t)
+
+	if _, err := client.Parameter.GetInputJSONString(context.Background()); err == nil {
+		t.Error("GetInputJSONString succeeded with a missing --input file")
+	}
+	if n := len(srv.Calls()); n != 0 {
+		t.Errorf("made %d RPCs, want 0", n)
+	}
+}
@@ -0,0 +1,210 @@
+package cafesdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InputValidationError lists every problem found by the validate tags of
+// the struct passed to Parameter.Unmarshal. It wraps ErrInvalidInput.
+type InputValidationError struct {
+	Problems []string
+}
+
+func (e *InputValidationError) Error() string {
+	return "cafesdk: invalid input: " + strings.Join(e.Problems, "; ")
+}
+
+func (e *InputValidationError) Unwrap() error {
+	return ErrInvalidInput
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyInputTags fills the fields of the struct v points to whose input key
+// is absent or null from their default tags, then checks their validate
+// tags against m, the parsed input.
+func applyInputTags(v any, m map[string]any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	var problems []string
+	walkInputStruct(rv.Elem(), m, "", &problems)
+	if len(problems) > 0 {
+		return &InputValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func walkInputStruct(sv reflect.Value, m map[string]any, prefix string, problems *[]string) {
+	st := sv.Type()
+	for i := range st.NumField() {
+		f := st.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		fv := sv.Field(i)
+
+		name, ok := jsonFieldName(f)
+		if !ok {
+			continue
+		}
+		if name == "" {
+			// An embedded struct without a JSON name has its fields
+			// promoted into the enclosing object.
+			if f.Anonymous && fv.Kind() == reflect.Struct {
+				walkInputStruct(fv, m, prefix, problems)
+			}
+			continue
+		}
+		path := prefix + name
+		raw, present := lookupInputKey(m, name)
+		present = present && raw != nil
+
+		if def, ok := f.Tag.Lookup("default"); ok && !present {
+			if err := setDefault(fv, def); err != nil {
+				*problems = append(*problems, fmt.Sprintf("%s: bad default %q: %v", path, def, err))
+			}
+		}
+		if rules, ok := f.Tag.Lookup("validate"); ok {
+			_, hasDefault := f.Tag.Lookup("default")
+			checkRules(fv, rules, path, hasDefault || (present && !isEmpty(raw)), problems)
+		}
+
+		nested := fv
+		if nested.Kind() == reflect.Pointer && !nested.IsNil() {
+			nested = nested.Elem()
+		}
+		if nested.Kind() == reflect.Struct && nested.Type() != reflect.TypeOf(time.Time{}) {
+			sub, _ := raw.(map[string]any)
+			walkInputStruct(nested, sub, path+".", problems)
+		}
+	}
+}
+
+// jsonFieldName returns the key encoding/json uses for f, "" for an
+// embedded struct it flattens, and false for a field it skips.
+func jsonFieldName(f reflect.StructField) (string, bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name != "" {
+		return name, true
+	}
+	if f.Anonymous {
+		return "", true
+	}
+	return f.Name, true
+}
+
+// lookupInputKey finds the value for the field key name the way
+// encoding/json matches object keys to fields: an exact match first, then
+// any key equal to name under Unicode case folding.
+func lookupInputKey(m map[string]any, name string) (any, bool) {
+	if v, ok := m[name]; ok {
+		return v, true
+	}
+	for key, v := range m {
+		if strings.EqualFold(key, name) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// setDefault parses def into fv. Strings are taken as is and durations
+// parsed with time.ParseDuration; anything else is decoded as JSON, so
+// numbers and booleans are written plainly and slices as '["a","b"]'.
+func setDefault(fv reflect.Value, def string) error {
+	switch {
+	case fv.Type() == durationType:
+		d, err := time.ParseDuration(def)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	case fv.Kind() == reflect.String:
+		fv.SetString(def)
+		return nil
+	}
+	return json.Unmarshal([]byte(def), fv.Addr().Interface())
+}
+
+// checkRules applies a comma-separated validate tag: required, min=N and
+// max=N (bounds on numbers, and on the length of strings, slices and maps)
+// and oneof=a|b|c. Only required applies to a field that was not given.
+func checkRules(fv reflect.Value, rules, path string, given bool, problems *[]string) {
+	for _, rule := range strings.Split(rules, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "":
+		case "required":
+			if !given {
+				*problems = append(*problems, path+" is required")
+			}
+		case "min", "max", "oneof":
+			if !given {
+				continue
+			}
+			checkRule(fv, name, arg, path, problems)
+		default:
+			*problems = append(*problems, fmt.Sprintf("%s: unknown validate rule %q", path, name))
+		}
+	}
+}
+
+func checkRule(fv reflect.Value, name, arg, path string, problems *[]string) {
+	switch name {
+	case "min", "max":
+		bound, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			*problems = append(*problems, fmt.Sprintf("%s: bad %s bound %q", path, name, arg))
+			return
+		}
+		n, what, ok := measure(fv)
+		if !ok {
+			*problems = append(*problems, fmt.Sprintf("%s: %s does not apply to %s", path, name, fv.Type()))
+			return
+		}
+		if name == "min" && n < bound {
+			*problems = append(*problems, fmt.Sprintf("%s %s must be at least %s", path, what, arg))
+		}
+		if name == "max" && n > bound {
+			*problems = append(*problems, fmt.Sprintf("%s %s must be at most %s", path, what, arg))
+		}
+	case "oneof":
+		if fv.Kind() != reflect.String {
+			*problems = append(*problems, fmt.Sprintf("%s: oneof does not apply to %s", path, fv.Type()))
+			return
+		}
+		allowed := strings.Split(arg, "|")
+		if s := fv.String(); !slices.Contains(allowed, s) {
+			*problems = append(*problems, fmt.Sprintf("%s must be one of %s, not %q", path, strings.Join(allowed, ", "), s))
+		}
+	}
+}
+
+// measure returns what min and max compare for fv: its value for numbers
+// and its length for strings, slices and maps.
+func measure(fv reflect.Value) (float64, string, bool) {
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(fv.Int()), "value", true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(fv.Uint()), "value", true
+	case reflect.Float32, reflect.Float64:
+		return fv.Float(), "value", true
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return float64(fv.Len()), "length", true
+	}
+	return 0, "", false
+}
@@ -0,0 +1,82 @@
+package cafesdk_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cafesdk "test/GoSdk"
+)
+
+type taggedInput struct {
+	URL      string        `validate:"required"`
+	MaxPages int           `json:"maxPages" default:"10" validate:"min=1,max=100"`
+	Mode     string        `json:"mode" default:"fast" validate:"oneof=fast|full"`
+	Timeout  time.Duration `json:"timeout" default:"30s"`
+	Proxy    struct {
+		Group string `json:"group" validate:"required"`
+	} `json:"proxy"`
+}
+
+func unmarshalTagged(t *testing.T, input string) (taggedInput, error) {
+	t.Helper()
+	client, srv := newTestClient(t)
+	srv.SetInput(input)
+	var in taggedInput
+	err := client.Parameter.Unmarshal(context.Background(), &in)
+	return in, err
+}
+
+func TestUnmarshalAppliesDefaults(t *testing.T) {
+	in, err := unmarshalTagged(t, `{"URL":"http://x","mode":null,"proxy":{"group":"RES"}}`)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if in.MaxPages != 10 || in.Mode != "fast" || in.Timeout != 30*time.Second {
+		t.Errorf("defaults = %d, %q, %v; want 10, fast, 30s", in.MaxPages, in.Mode, in.Timeout)
+	}
+}
+
+func TestUnmarshalRequiredViolation(t *testing.T) {
+	_, err := unmarshalTagged(t, `{"maxPages":0,"mode":"slow"}`)
+	var verr *cafesdk.InputValidationError
+	if !errors.As(err, &verr) || !errors.Is(err, cafesdk.ErrInvalidInput) {
+		t.Fatalf("Unmarshal = %v, want an *InputValidationError wrapping ErrInvalidInput", err)
+	}
+	want := []string{
+		"URL is required",
+		"maxPages value must be at least 1",
+		"mode must be one of fast, full, not \"slow\"",
+		"proxy.group is required",
+	}
+	if !equalStrings(verr.Problems, want) {
+		t.Errorf("Problems = %q, want %q", verr.Problems, want)
+	}
+}
+
+func TestUnmarshalDefaultsAndRequiredMixed(t *testing.T) {
+	// "url" and "MAXPAGES" differ in case from the field keys; encoding/json
+	// still decodes them, so the tags must see them as given.
+	in, err := unmarshalTagged(t, `{"MAXPAGES":5,"url":"http://x","proxy":{}}`)
+	var verr *cafesdk.InputValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Unmarshal = %v, want an *InputValidationError", err)
+	}
+	if want := []string{"proxy.group is required"}; !equalStrings(verr.Problems, want) {
+		t.Errorf("Problems = %q, want %q", verr.Problems, want)
+	}
+	if in.URL != "http://x" || in.MaxPages != 5 || in.Mode != "fast" {
+		t.Errorf("Unmarshal = %+v, want the given URL and maxPages and the default mode", in)
+	}
+}
+
+func TestUnmarshalCaseInsensitiveKeys(t *testing.T) {
+	in, err := unmarshalTagged(t, `{"maxPages":5,"URL":"http://x","Proxy":{"Group":"RES"}}`)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if in.MaxPages != 5 || in.URL != "http://x" || in.Proxy.Group != "RES" {
+		t.Errorf("Unmarshal = %+v", in)
+	}
+}
@@ -0,0 +1,54 @@
+package cafesdk_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	cafesdk "test/GoSdk"
+
+	"google.golang.org/grpc"
+)
+
+// recordingInterceptor appends name and the method of every call it wraps
+// to calls.
+func recordingInterceptor(mu *sync.Mutex, calls *[]string, name string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		mu.Lock()
+		*calls = append(*calls, name+" "+method)
+		mu.Unlock()
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func TestUnaryInterceptorWrapsEveryCall(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	client, srv := newTestClient(t,
+		cafesdk.WithUnaryInterceptor(recordingInterceptor(&mu, &calls, "outer")),
+		cafesdk.WithUnaryInterceptor(recordingInterceptor(&mu, &calls, "inner")),
+	)
+	srv.SetInput(`{}`)
+	ctx := context.Background()
+
+	client.Parameter.GetInputJSONString(ctx)
+	client.Result.SetTableHeader(ctx, []*cafesdk.TableHeaderItem{{Key: "k", Label: "K", Format: cafesdk.FormatText}})
+	client.Result.PushData(ctx, `{}`)
+	client.Log.Info(ctx, "hello")
+
+	var want []string
+	for _, method := range []string{
+		"/cafesdk.Parameter/GetInputJSONString", "/cafesdk.Result/SetTableHeader",
+		"/cafesdk.Result/PushData", "/cafesdk.Log/Info",
+	} {
+		want = append(want, "outer "+method, "inner "+method)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !equalStrings(calls, want) {
+		t.Errorf("intercepted %q, want %q", calls, want)
+	}
+	if n := len(srv.Calls()); n != 4 {
+		t.Errorf("server saw %d calls, want 4", n)
+	}
+}
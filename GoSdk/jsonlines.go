@@ -0,0 +1,49 @@
+package cafesdk
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// maxJSONLine is the longest line PushJSONLines reads, gRPC's default
+// message size limit.
+const maxJSONLine = 4 << 20
+
+// PushJSONLines pushes each line of src, one JSON object per line, through a
+// Writer, so output streamed to a file or pipe never has to be held in
+// memory. Blank lines are skipped. It stops at the first line that is not a
+// JSON object, reporting its line number in an ErrInvalidInput error, and
+// returns how many records were delivered, including those before the bad
+// line.
+func (r _Result) PushJSONLines(ctx context.Context, src io.Reader) (int, error) {
+	w := r.NewWriter(ctx, WriterOptions{})
+
+	scanner := bufio.NewScanner(src)
+	scanner.Buffer(nil, maxJSONLine)
+
+	var readErr error
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		if text[0] != '{' || !json.Valid(text) {
+			readErr = fmt.Errorf("%w: line %d is not a JSON object: %q", ErrInvalidInput, line, snippet(string(text)))
+			break
+		}
+		if readErr = w.Write(string(text)); readErr != nil {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil && readErr == nil {
+		readErr = fmt.Errorf("cafesdk: read JSON lines: %w", err)
+	}
+
+	closeErr := w.Close()
+	return int(w.Delivered()), errors.Join(readErr, closeErr)
+}
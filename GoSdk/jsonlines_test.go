@@ -0,0 +1,48 @@
+package cafesdk_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	cafesdk "test/GoSdk"
+)
+
+func TestPushJSONLines(t *testing.T) {
+	client, srv := newTestClient(t)
+	src := strings.NewReader("{\"n\":1}\n\n  {\"n\":2}  \r\n{\"n\":3}")
+
+	n, err := client.Result.PushJSONLines(context.Background(), src)
+	if err != nil || n != 3 {
+		t.Fatalf("PushJSONLines = %d, %v, want 3, nil", n, err)
+	}
+	if got := srv.Data(); !equalStrings(got, []string{`{"n":1}`, `{"n":2}`, `{"n":3}`}) {
+		t.Errorf("Data() = %q", got)
+	}
+}
+
+func TestPushJSONLinesMalformedLine(t *testing.T) {
+	client, srv := newTestClient(t)
+	src := strings.NewReader("{\"n\":1}\n\n{\"n\":2}\n{\"n\":\n{\"n\":4}\n")
+
+	n, err := client.Result.PushJSONLines(context.Background(), src)
+	if !errors.Is(err, cafesdk.ErrInvalidInput) || !strings.Contains(err.Error(), "line 4") {
+		t.Errorf("PushJSONLines error = %v, want ErrInvalidInput at line 4", err)
+	}
+	if n != 2 {
+		t.Errorf("delivered %d records, want the 2 before the bad line", n)
+	}
+	if got := srv.Data(); !equalStrings(got, []string{`{"n":1}`, `{"n":2}`}) {
+		t.Errorf("Data() = %q, want only the lines before the bad one", got)
+	}
+}
+
+func TestPushJSONLinesRejectsNonObject(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	_, err := client.Result.PushJSONLines(context.Background(), strings.NewReader("[1,2]\n"))
+	if !errors.Is(err, cafesdk.ErrInvalidInput) || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("PushJSONLines = %v, want ErrInvalidInput at line 1", err)
+	}
+}
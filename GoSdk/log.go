@@ -0,0 +1,334 @@
+package cafesdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LogLevel orders log severities from Debug to Error.
+type LogLevel int32
+
+const (
+	LevelDebug LogLevel = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	}
+	return fmt.Sprintf("LogLevel(%d)", int32(l))
+}
+
+var logLevel atomic.Int32
+
+// SetLogLevel drops log calls below level before any RPC is made; they
+// return an empty Response and no error. The default, LevelDebug, sends
+// everything.
+func SetLogLevel(level LogLevel) {
+	logLevel.Store(int32(level))
+}
+
+var (
+	mirrorMu sync.Mutex
+	mirror   io.Writer
+)
+
+// SetLocalMirror copies every log message that passes the level filter to w
+// as a timestamped, level-prefixed line, whether or not the RPC succeeds.
+// Pass os.Stderr when developing outside the platform, or nil to stop.
+func SetLocalMirror(w io.Writer) {
+	mirrorMu.Lock()
+	mirror = w
+	mirrorMu.Unlock()
+}
+
+func writeMirror(level LogLevel, text string) {
+	mirrorMu.Lock()
+	defer mirrorMu.Unlock()
+
+	w := mirror
+	if w == nil && dryRun.Load() {
+		w = os.Stderr
+	}
+	if w != nil {
+		fmt.Fprintf(w, "%s [%s] %s\n", clockNow().Format(time.RFC3339Nano), level, text)
+	}
+}
+
+var (
+	exitMu   sync.Mutex
+	exitFunc = os.Exit
+)
+
+// SetExitFunc replaces os.Exit as the final step of Log.Fatal. Pass a
+// function that panics to unwind instead of exiting, or a recorder in tests.
+func SetExitFunc(exit func(code int)) {
+	exitMu.Lock()
+	exitFunc = exit
+	exitMu.Unlock()
+}
+
+func exit(code int) {
+	exitMu.Lock()
+	f := exitFunc
+	exitMu.Unlock()
+	f(code)
+}
+
+func (l _Log) Debug(ctx context.Context, text string) (*Response, error) {
+	return l.send(ctx, LevelDebug, text)
+}
+
+func (l _Log) Info(ctx context.Context, text string) (*Response, error) {
+	return l.send(ctx, LevelInfo, text)
+}
+
+func (l _Log) Warn(ctx context.Context, text string) (*Response, error) {
+	return l.send(ctx, LevelWarn, text)
+}
+
+func (l _Log) Error(ctx context.Context, text string) (*Response, error) {
+	return l.send(ctx, LevelError, text)
+}
+
+// Fatal logs text at Error level, drains result writers and buffered logs,
+// closes the connection and exits with status 1 through the exit function.
+func (l _Log) Fatal(ctx context.Context, text string) {
+	l.Error(ctx, text)
+	if l.c == defaultClient {
+		Close()
+	} else {
+		l.c.Close()
+	}
+	exit(1)
+}
+
+func (l _Log) send(ctx context.Context, level LogLevel, text string) (*Response, error) {
+	if level < LogLevel(logLevel.Load()) {
+		return &Response{}, nil
+	}
+	return l.emit(ctx, level, text)
+}
+
+// queuedWarnings holds SDK warnings raised where no call may be made; see
+// warnLater.
+type queuedWarnings struct {
+	queued atomic.Bool
+	mu     sync.Mutex
+	texts  []string
+}
+
+// warnLater queues an SDK warning raised by a constructor such as
+// NewHTTPClient. It is logged once a later call has connected, so raising
+// it neither dials the platform nor stops the connection from being
+// configured.
+func (l _Log) warnLater(text string) {
+	w := &l.c.warnings
+	w.mu.Lock()
+	w.texts = append(w.texts, text)
+	w.queued.Store(true)
+	w.mu.Unlock()
+}
+
+// sendWarnings logs the queued warnings in order from a background
+// goroutine, so the call that connected does not wait for them. The sends
+// end when the Client shuts down.
+func (c *Client) sendWarnings() {
+	w := &c.warnings
+	if !w.queued.Load() {
+		return
+	}
+	w.mu.Lock()
+	texts := w.texts
+	w.texts = nil
+	w.queued.Store(false)
+	w.mu.Unlock()
+	if len(texts) == 0 {
+		return
+	}
+
+	ctx, cancel := c.derive(context.Background())
+	go func() {
+		defer cancel()
+		for _, text := range texts {
+			c.Log.send(ctx, LevelWarn, text)
+		}
+	}()
+}
+
+// emit mirrors, records, buffers or delivers a message that passed the
+// level filter.
+func (l _Log) emit(ctx context.Context, level LogLevel, text string) (*Response, error) {
+	writeMirror(level, text)
+	recordLog(level, text)
+	if b := activeLogBuffer.Load(); b != nil {
+		queued, err := b.add(ctx, l, level, text)
+		if err != nil {
+			return nil, err
+		}
+		if queued {
+			return &Response{}, nil
+		}
+	}
+	return l.deliver(ctx, level, text)
+}
+
+// deliver sends one log message to the platform.
+func (l _Log) deliver(ctx context.Context, level LogLevel, text string) (*Response, error) {
+	if dryRun.Load() {
+		return dryRunResponse(), nil
+	}
+	return invoke(ctx, l.c, logMethods[level], func(ctx context.Context) (*Response, error) {
+		return l.rpc(ctx, level, &LogBody{Log: text})
+	})
+}
+
+func (l _Log) rpc(ctx context.Context, level LogLevel, body *LogBody) (*Response, error) {
+	switch level {
+	case LevelDebug:
+		return l.c.logClient.Debug(ctx, body)
+	case LevelInfo:
+		return l.c.logClient.Info(ctx, body)
+	case LevelWarn:
+		return l.c.logClient.Warn(ctx, body)
+	}
+	return l.c.logClient.Error(ctx, body)
+}
+
+var logAttempt atomic.Bool
+
+// SetLogAttempt makes the KV log methods, and Logger, add an "attempt" field
+// holding AttemptFromContext(ctx) when ctx comes from an SDK retry. A field
+// named "attempt" passed by the caller is left as is.
+func SetLogAttempt(enabled bool) {
+	logAttempt.Store(enabled)
+}
+
+// structuredLog is the JSON body sent by the KV log methods.
+type structuredLog struct {
+	Message string         `json:"message"`
+	Fields  map[string]any `json:"fields,omitempty"`
+}
+
+func (l _Log) DebugKV(ctx context.Context, msg string, fields map[string]any) (*Response, error) {
+	return l.kv(ctx, l.Debug, msg, fields)
+}
+
+func (l _Log) InfoKV(ctx context.Context, msg string, fields map[string]any) (*Response, error) {
+	return l.kv(ctx, l.Info, msg, fields)
+}
+
+func (l _Log) WarnKV(ctx context.Context, msg string, fields map[string]any) (*Response, error) {
+	return l.kv(ctx, l.Warn, msg, fields)
+}
+
+func (l _Log) ErrorKV(ctx context.Context, msg string, fields map[string]any) (*Response, error) {
+	return l.kv(ctx, l.Error, msg, fields)
+}
+
+// kv encodes msg and fields as {"message": ..., "fields": {...}} and sends it
+// at the level of logf.
+func (_Log) kv(ctx context.Context, logf func(context.Context, string) (*Response, error), msg string, fields map[string]any) (*Response, error) {
+	if n := AttemptFromContext(ctx); n > 0 && logAttempt.Load() {
+		if _, ok := fields["attempt"]; !ok {
+			merged := make(map[string]any, len(fields)+1)
+			for k, v := range fields {
+				merged[k] = v
+			}
+			merged["attempt"] = n
+			fields = merged
+		}
+	}
+	b, err := json.Marshal(structuredLog{Message: msg, Fields: fields})
+	if err != nil {
+		return nil, fmt.Errorf("cafesdk: marshal log fields: %w", err)
+	}
+	return logf(ctx, string(b))
+}
+
+func (l _Log) Debugf(ctx context.Context, format string, args ...any) (*Response, error) {
+	return l.Debug(ctx, fmt.Sprintf(format, args...))
+}
+
+func (l _Log) Infof(ctx context.Context, format string, args ...any) (*Response, error) {
+	return l.Info(ctx, fmt.Sprintf(format, args...))
+}
+
+func (l _Log) Warnf(ctx context.Context, format string, args ...any) (*Response, error) {
+	return l.Warn(ctx, fmt.Sprintf(format, args...))
+}
+
+func (l _Log) Errorf(ctx context.Context, format string, args ...any) (*Response, error) {
+	return l.Error(ctx, fmt.Sprintf(format, args...))
+}
+
+// Logger sends structured logs that carry a fixed set of base fields. The
+// zero value has no base fields and logs through the package-level Log.
+type Logger struct {
+	log    _Log
+	fields map[string]any
+}
+
+// With returns a Logger whose structured logs include fields.
+func (l _Log) With(fields map[string]any) Logger {
+	return Logger{log: l}.With(fields)
+}
+
+// With returns a copy of l with fields added to its base fields.
+func (l Logger) With(fields map[string]any) Logger {
+	return Logger{log: l.log, fields: l.merge(fields)}
+}
+
+func (l Logger) target() _Log {
+	if l.log.c == nil {
+		return Log
+	}
+	return l.log
+}
+
+func (l Logger) Debug(ctx context.Context, msg string, fields map[string]any) (*Response, error) {
+	return l.target().DebugKV(ctx, msg, l.merge(fields))
+}
+
+func (l Logger) Info(ctx context.Context, msg string, fields map[string]any) (*Response, error) {
+	return l.target().InfoKV(ctx, msg, l.merge(fields))
+}
+
+func (l Logger) Warn(ctx context.Context, msg string, fields map[string]any) (*Response, error) {
+	return l.target().WarnKV(ctx, msg, l.merge(fields))
+}
+
+func (l Logger) Error(ctx context.Context, msg string, fields map[string]any) (*Response, error) {
+	return l.target().ErrorKV(ctx, msg, l.merge(fields))
+}
+
+// merge returns the base fields overlaid with fields, leaving both intact.
+func (l Logger) merge(fields map[string]any) map[string]any {
+	if len(fields) == 0 {
+		return l.fields
+	}
+	merged := make(map[string]any, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return merged
+}
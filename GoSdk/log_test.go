@@ -0,0 +1,434 @@
+package cafesdk_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	cafesdk "test/GoSdk"
+	"test/GoSdk/cafesdktest"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// decodeLog parses a structured log body sent by the KV methods.
+func decodeLog(t *testing.T, text string) (msg string, fields map[string]any) {
+	t.Helper()
+	var body struct {
+		Message string         `json:"message"`
+		Fields  map[string]any `json:"fields"`
+	}
+	if err := json.Unmarshal([]byte(text), &body); err != nil {
+		t.Fatalf("log body %q is not valid JSON: %v", text, err)
+	}
+	return body.Message, body.Fields
+}
+
+func TestInfoKVBody(t *testing.T) {
+	client, srv := newTestClient(t)
+
+	fields := map[string]any{
+		"url":    "https://example.com",
+		"status": 200,
+		"ok":     true,
+		"page":   map[string]any{"index": 3, "tags": []string{"a", "b"}},
+	}
+	if _, err := client.Log.InfoKV(context.Background(), "fetched", fields); err != nil {
+		t.Fatalf("InfoKV: %v", err)
+	}
+
+	logs := srv.Logs()
+	if len(logs) != 1 || logs[0].Level != cafesdk.LevelInfo {
+		t.Fatalf("Logs() = %+v, want one Info line", logs)
+	}
+	msg, got := decodeLog(t, logs[0].Text)
+	want := map[string]any{
+		"url":    "https://example.com",
+		"status": 200.0,
+		"ok":     true,
+		"page":   map[string]any{"index": 3.0, "tags": []any{"a", "b"}},
+	}
+	if msg != "fetched" || !reflect.DeepEqual(got, want) {
+		t.Errorf("body = %q, %v; want fetched, %v", msg, got, want)
+	}
+}
+
+func TestInfof(t *testing.T) {
+	client, srv := newTestClient(t)
+
+	if _, err := client.Log.Infof(context.Background(), "x=%d", 5); err != nil {
+		t.Fatalf("Infof: %v", err)
+	}
+	if logs := srv.Logs(); len(logs) != 1 || logs[0].Level != cafesdk.LevelInfo || logs[0].Text != "x=5" {
+		t.Errorf("Logs() = %+v, want one Info line x=5", logs)
+	}
+}
+
+func TestLogLevelFilter(t *testing.T) {
+	cafesdk.SetLogLevel(cafesdk.LevelWarn)
+	t.Cleanup(func() { cafesdk.SetLogLevel(cafesdk.LevelDebug) })
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+
+	for _, logf := range []func(context.Context, string) (*cafesdk.Response, error){client.Log.Debug, client.Log.Info} {
+		if res, err := logf(ctx, "quiet"); err != nil || res == nil {
+			t.Fatalf("filtered log = %v, %v; want an empty Response", res, err)
+		}
+	}
+	if n := len(srv.Calls()); n != 0 {
+		t.Fatalf("Debug and Info below Warn made %d RPCs", n)
+	}
+
+	client.Log.Warn(ctx, "careful")
+	client.Log.Error(ctx, "broken")
+	var methods []string
+	for _, call := range srv.Calls() {
+		methods = append(methods, call.Method)
+	}
+	if want := []string{cafesdk.MethodLogWarn, cafesdk.MethodLogError}; !equalStrings(methods, want) {
+		t.Errorf("calls = %q, want %q", methods, want)
+	}
+}
+
+// useLogBuffering turns on buffered logging with a batch size and interval
+// large enough that only Flush sends, until the test ends.
+func useLogBuffering(t *testing.T) {
+	t.Helper()
+	if err := cafesdk.SetLogBuffering(&cafesdk.LogBufferOptions{BatchSize: 10_000, FlushInterval: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cafesdk.SetLogBuffering(nil) })
+}
+
+func TestLogBufferFlushInOrder(t *testing.T) {
+	useLogBuffering(t)
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+
+	want := make([]string, 500)
+	for i := range want {
+		want[i] = fmt.Sprintf("line %d", i)
+		if _, err := client.Log.Info(ctx, want[i]); err != nil {
+			t.Fatalf("Info: %v", err)
+		}
+	}
+	if n := len(srv.Logs()); n != 0 {
+		t.Fatalf("%d lines sent before Flush", n)
+	}
+	if err := client.Log.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	var got []string
+	for _, line := range srv.Logs() {
+		got = append(got, line.Text)
+	}
+	if !equalStrings(got, want) {
+		t.Errorf("server received %d lines, want all 500 in order", len(got))
+	}
+}
+
+func TestLogBufferRequeuesTransientFailure(t *testing.T) {
+	useLogBuffering(t)
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+	srv.FailFunc(cafesdk.MethodLogInfo, func(call int) error {
+		if call == 2 {
+			return status.Error(codes.Unavailable, "restarting")
+		}
+		return nil
+	})
+
+	for _, text := range []string{"a", "b", "c"} {
+		client.Log.Info(ctx, text)
+	}
+	if err := client.Log.Flush(ctx); status.Code(err) != codes.Unavailable {
+		t.Fatalf("first Flush = %v, want Unavailable", err)
+	}
+	if err := client.Log.Flush(ctx); err != nil {
+		t.Fatalf("second Flush: %v", err)
+	}
+
+	var got []string
+	for _, line := range srv.Logs() {
+		got = append(got, line.Text)
+	}
+	if !equalStrings(got, []string{"a", "b", "c"}) {
+		t.Errorf("server lines = %q, want a b c", got)
+	}
+}
+
+func TestLogBufferDropsRejectedEntry(t *testing.T) {
+	useLogBuffering(t)
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+	srv.FailFunc(cafesdk.MethodLogInfo, func(call int) error {
+		if call == 2 {
+			return status.Error(codes.InvalidArgument, "too long")
+		}
+		return nil
+	})
+
+	dropped := client.Log.DroppedCount()
+	for _, text := range []string{"a", "b", "c"} {
+		client.Log.Info(ctx, text)
+	}
+	if err := client.Log.Flush(ctx); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("Flush = %v, want the InvalidArgument rejection", err)
+	}
+	if n := client.Log.DroppedCount() - dropped; n != 1 {
+		t.Errorf("DroppedCount grew by %d, want 1", n)
+	}
+
+	var got []string
+	for _, line := range srv.Logs() {
+		got = append(got, line.Text)
+	}
+	if !equalStrings(got, []string{"a", "c"}) {
+		t.Errorf("server lines = %q, want a c", got)
+	}
+	if err := client.Log.Flush(ctx); err != nil || len(srv.CallsTo(cafesdk.MethodLogInfo)) != 3 {
+		t.Errorf("rejected entry was kept: Flush = %v, %d calls", err, len(srv.CallsTo(cafesdk.MethodLogInfo)))
+	}
+}
+
+func TestLocalMirror(t *testing.T) {
+	clock := cafesdktest.NewFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	cafesdk.SetClock(clock)
+	var buf bytes.Buffer
+	cafesdk.SetLocalMirror(&buf)
+	t.Cleanup(func() {
+		cafesdk.SetLocalMirror(nil)
+		cafesdk.SetClock(nil)
+	})
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+
+	if _, err := client.Log.Warn(ctx, "careful"); err != nil {
+		t.Fatalf("Warn: %v", err)
+	}
+	srv.Fail(cafesdk.MethodLogError, status.Error(codes.Internal, "down"))
+	if _, err := client.Log.Error(ctx, "broken"); err == nil {
+		t.Fatal("Error succeeded against a failing server")
+	}
+
+	if logs := srv.Logs(); len(logs) != 1 || logs[0].Text != "careful" {
+		t.Errorf("Logs() = %+v, want the Warn line", logs)
+	}
+	want := fmt.Sprintf("2024-05-01T12:00:00Z [%s] careful\n2024-05-01T12:00:00Z [%s] broken\n", cafesdk.LevelWarn, cafesdk.LevelError)
+	if got := buf.String(); got != want {
+		t.Errorf("mirror = %q, want %q", got, want)
+	}
+}
+
+func TestLoggerWith(t *testing.T) {
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+
+	base := client.Log.With(map[string]any{"url": "https://a", "index": 1})
+	item := base.With(map[string]any{"index": 2})
+	item.Info(ctx, "item", map[string]any{"price": 9, "url": "https://b"})
+	base.Info(ctx, "base", nil)
+
+	logs := srv.Logs()
+	if len(logs) != 2 {
+		t.Fatalf("Logs() = %+v, want two lines", logs)
+	}
+	msg, fields := decodeLog(t, logs[0].Text)
+	if want := map[string]any{"url": "https://b", "index": 2.0, "price": 9.0}; msg != "item" || !reflect.DeepEqual(fields, want) {
+		t.Errorf("derived logger body = %q, %v; want item, %v", msg, fields, want)
+	}
+	msg, fields = decodeLog(t, logs[1].Text)
+	if want := map[string]any{"url": "https://a", "index": 1.0}; msg != "base" || !reflect.DeepEqual(fields, want) {
+		t.Errorf("base logger body = %q, %v; want base, %v", msg, fields, want)
+	}
+}
+
+func TestFatalFlushesBeforeExit(t *testing.T) {
+	useLogBuffering(t)
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+
+	var exitCode, logsAtExit, dataAtExit int
+	exited := false
+	cafesdk.SetExitFunc(func(code int) {
+		exited, exitCode = true, code
+		logsAtExit, dataAtExit = len(srv.Logs()), len(srv.Data())
+	})
+	t.Cleanup(func() { cafesdk.SetExitFunc(os.Exit) })
+
+	w := client.Result.NewWriter(ctx, cafesdk.WriterOptions{BatchSize: 100, FlushInterval: time.Hour})
+	w.Write(`{"n":1}`)
+	client.Log.Info(ctx, "working")
+	client.Log.Fatal(ctx, "giving up")
+
+	if !exited || exitCode != 1 {
+		t.Fatalf("exit called = %v with %d, want status 1", exited, exitCode)
+	}
+	if logsAtExit != 2 || dataAtExit != 1 {
+		t.Errorf("at exit the server had %d log lines and %d records, want 2 and 1", logsAtExit, dataAtExit)
+	}
+	if logs := srv.Logs(); len(logs) == 2 && (logs[1].Level != cafesdk.LevelError || logs[1].Text != "giving up") {
+		t.Errorf("last line = %+v, want the Error line", logs[1])
+	}
+	if _, err := client.Result.PushData(ctx, `{}`); !errors.Is(err, cafesdk.ErrClosed) {
+		t.Errorf("PushData after Fatal = %v, want ErrClosed", err)
+	}
+}
+
+// fillLogQueue buffers logs in a queue of 2 in front of a slow server, and
+// logs a and b, which a flush takes off the queue and is still sending, then
+// c and d, which fill the queue again.
+func fillLogQueue(t *testing.T, overflow cafesdk.LogOverflow) (*cafesdk.Client, *cafesdktest.Server) {
+	t.Helper()
+	if err := cafesdk.SetLogBuffering(&cafesdk.LogBufferOptions{BatchSize: 10_000, FlushInterval: time.Hour, QueueSize: 2, Overflow: overflow}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cafesdk.SetLogBuffering(nil) })
+	client, srv := newTestClient(t)
+	srv.Delay(cafesdk.MethodLogInfo, 100*time.Millisecond)
+	ctx := context.Background()
+
+	client.Log.Info(ctx, "a")
+	client.Log.Info(ctx, "b")
+	deadline := time.Now().Add(5 * time.Second)
+	for len(srv.CallsTo(cafesdk.MethodLogInfo)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("full queue was not flushed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	client.Log.Info(ctx, "c")
+	client.Log.Info(ctx, "d")
+	return client, srv
+}
+
+func logTexts(srv *cafesdktest.Server) []string {
+	var texts []string
+	for _, line := range srv.Logs() {
+		texts = append(texts, line.Text)
+	}
+	return texts
+}
+
+func TestLogBufferOverflowDrop(t *testing.T) {
+	tests := []struct {
+		overflow cafesdk.LogOverflow
+		want     []string
+	}{
+		{cafesdk.OverflowDropNewest, []string{"a", "b", "c", "d"}},
+		{cafesdk.OverflowDropOldest, []string{"a", "b", "e", "f"}},
+	}
+	for _, tt := range tests {
+		client, srv := fillLogQueue(t, tt.overflow)
+		ctx := context.Background()
+		dropped := client.Log.DroppedCount()
+
+		for _, text := range []string{"e", "f"} {
+			if _, err := client.Log.Info(ctx, text); err != nil {
+				t.Errorf("overflow %d: Info(%s) = %v, want it to return at once", tt.overflow, text, err)
+			}
+		}
+		if n := client.Log.DroppedCount() - dropped; n != 2 {
+			t.Errorf("overflow %d: DroppedCount grew by %d, want 2", tt.overflow, n)
+		}
+		if err := cafesdk.SetLogBuffering(nil); err != nil {
+			t.Fatalf("SetLogBuffering(nil): %v", err)
+		}
+		if got := logTexts(srv); !equalStrings(got, tt.want) {
+			t.Errorf("overflow %d: server lines = %q, want %q", tt.overflow, got, tt.want)
+		}
+	}
+}
+
+func TestLogBufferRequeueKeepsQueueSize(t *testing.T) {
+	tests := []struct {
+		overflow cafesdk.LogOverflow
+		want     []string
+	}{
+		{cafesdk.OverflowDropNewest, []string{"a", "b", "c"}},
+		{cafesdk.OverflowDropOldest, []string{"c", "d", "e"}},
+		{cafesdk.OverflowBlock, []string{"c", "d", "e"}},
+	}
+	for _, tt := range tests {
+		if err := cafesdk.SetLogBuffering(&cafesdk.LogBufferOptions{BatchSize: 10_000, FlushInterval: time.Hour, QueueSize: 3, Overflow: tt.overflow}); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { cafesdk.SetLogBuffering(nil) })
+		client, srv := newTestClient(t)
+		srv.Delay(cafesdk.MethodLogInfo, 100*time.Millisecond)
+		srv.FailFunc(cafesdk.MethodLogInfo, func(call int) error {
+			if call == 1 {
+				return status.Error(codes.Unavailable, "restarting")
+			}
+			return nil
+		})
+		ctx := context.Background()
+		dropped := client.Log.DroppedCount()
+
+		client.Log.Info(ctx, "a")
+		client.Log.Info(ctx, "b")
+		flushed := make(chan error)
+		go func() { flushed <- client.Log.Flush(ctx) }()
+		deadline := time.Now().Add(5 * time.Second)
+		for len(srv.CallsTo(cafesdk.MethodLogInfo)) == 0 {
+			if time.Now().After(deadline) {
+				t.Fatal("queue was not flushed")
+			}
+			time.Sleep(time.Millisecond)
+		}
+		// The queue is empty while a and b are in flight, so c to e fit;
+		// putting a and b back then overflows it by two.
+		for _, text := range []string{"c", "d", "e"} {
+			client.Log.Info(ctx, text)
+		}
+		if err := <-flushed; status.Code(err) != codes.Unavailable {
+			t.Fatalf("overflow %d: Flush = %v, want Unavailable", tt.overflow, err)
+		}
+		if n := client.Log.DroppedCount() - dropped; n != 2 {
+			t.Errorf("overflow %d: DroppedCount grew by %d, want 2", tt.overflow, n)
+		}
+		if err := cafesdk.SetLogBuffering(nil); err != nil {
+			t.Fatalf("overflow %d: SetLogBuffering(nil): %v", tt.overflow, err)
+		}
+		if got := logTexts(srv); !equalStrings(got, tt.want) {
+			t.Errorf("overflow %d: server lines = %q, want %q", tt.overflow, got, tt.want)
+		}
+	}
+}
+
+func TestLogBufferOverflowBlock(t *testing.T) {
+	client, srv := fillLogQueue(t, cafesdk.OverflowBlock)
+	dropped := client.Log.DroppedCount()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.Log.Info(ctx, "gave up"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Info on a full queue = %v, want DeadlineExceeded", err)
+	}
+	if n := client.Log.DroppedCount() - dropped; n != 1 {
+		t.Errorf("DroppedCount grew by %d, want 1 for the abandoned call", n)
+	}
+
+	// Without a deadline the call waits for the flush to make room.
+	start := time.Now()
+	if _, err := client.Log.Info(context.Background(), "e"); err != nil {
+		t.Fatalf("Info: %v", err)
+	}
+	if time.Since(start) < 50*time.Millisecond {
+		t.Error("Info on a full queue returned without waiting")
+	}
+	if err := cafesdk.SetLogBuffering(nil); err != nil {
+		t.Fatalf("SetLogBuffering(nil): %v", err)
+	}
+	if got := logTexts(srv); !equalStrings(got, []string{"a", "b", "c", "d", "e"}) {
+		t.Errorf("server lines = %q, want a to e", got)
+	}
+}
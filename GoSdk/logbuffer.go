@@ -0,0 +1,263 @@
+package cafesdk
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultLogBatchSize     = 50
+	defaultLogFlushInterval = time.Second
+)
+
+// LogBufferOptions tunes buffered logging. Zero values select the defaults.
+type LogBufferOptions struct {
+	// BatchSize is the number of queued messages that triggers a flush.
+	BatchSize int
+	// FlushInterval is the longest a message waits before being flushed.
+	FlushInterval time.Duration
+	// QueueSize caps the number of queued messages; zero leaves the queue
+	// unbounded. Overflow decides what a Log call does when it is full, and
+	// which messages are dropped when a failed flush puts back more than
+	// fit.
+	QueueSize int
+	Overflow  LogOverflow
+}
+
+// LogOverflow is what a buffered Log call does when the queue is full.
+type LogOverflow int
+
+const (
+	// OverflowBlock waits for a flush to make room, or for the call's
+	// context to be done; it is the default.
+	OverflowBlock LogOverflow = iota
+	// OverflowDropOldest discards the oldest queued message.
+	OverflowDropOldest
+	// OverflowDropNewest discards the message being logged.
+	OverflowDropNewest
+)
+
+var droppedLogs atomic.Int64
+
+// DroppedCount returns how many log messages a full buffer has discarded
+// since the process started, including those given up on by a blocked call
+// whose context was done and those a flush dropped because the platform
+// rejected them with a non-transient error. See LogBufferOptions.QueueSize.
+func (_Log) DroppedCount() int64 {
+	return droppedLogs.Load()
+}
+
+type logEntry struct {
+	log   _Log
+	level LogLevel
+	text  string
+}
+
+type logBuffer struct {
+	opts LogBufferOptions
+
+	mu      sync.Mutex
+	entries []logEntry
+	closed  bool
+	// space is closed, and cleared, when a flush takes entries off a full
+	// queue or the buffer closes.
+	space chan struct{}
+
+	// flushMu keeps concurrent flushes from interleaving messages.
+	flushMu sync.Mutex
+	kick    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+var activeLogBuffer atomic.Pointer[logBuffer]
+
+// SetLogBuffering queues log messages and sends them in the background in
+// batches, in the order they were logged. Buffered Log calls return an empty
+// Response immediately, unless a bounded queue is full (see
+// LogBufferOptions.QueueSize). Passing nil flushes what is queued and restores
+// synchronous logging. Close and Log.Flush also flush the queue.
+func SetLogBuffering(opts *LogBufferOptions) error {
+	var b *logBuffer
+	if opts != nil {
+		b = newLogBuffer(*opts)
+	}
+	if old := activeLogBuffer.Swap(b); old != nil {
+		return old.close(context.Background())
+	}
+	return nil
+}
+
+// Flush sends every buffered log message. It is a no-op when buffering is
+// off.
+func (_Log) Flush(ctx context.Context) error {
+	if b := activeLogBuffer.Load(); b != nil {
+		return b.flush(ctx)
+	}
+	return nil
+}
+
+func newLogBuffer(opts LogBufferOptions) *logBuffer {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultLogBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultLogFlushInterval
+	}
+
+	b := &logBuffer{
+		opts: opts,
+		kick: make(chan struct{}, 1),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// add queues an entry, or drops it under the overflow policy, reporting
+// false once the buffer has been closed. A blocked add returns ctx.Err()
+// when ctx is done first.
+func (b *logBuffer) add(ctx context.Context, log _Log, level LogLevel, text string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for !b.closed && b.full() {
+		switch b.opts.Overflow {
+		case OverflowDropNewest:
+			droppedLogs.Add(1)
+			return true, nil
+		case OverflowDropOldest:
+			b.entries = b.entries[1:]
+			droppedLogs.Add(1)
+			continue
+		}
+
+		if b.space == nil {
+			b.space = make(chan struct{})
+		}
+		space := b.space
+		b.mu.Unlock()
+		select {
+		case <-space:
+			b.mu.Lock()
+		case <-ctx.Done():
+			b.mu.Lock()
+			droppedLogs.Add(1)
+			return true, ctx.Err()
+		}
+	}
+	if b.closed {
+		return false, nil
+	}
+
+	b.entries = append(b.entries, logEntry{log: log, level: level, text: text})
+	if len(b.entries) >= b.opts.BatchSize || b.full() {
+		b.kickLocked()
+	}
+	return true, nil
+}
+
+func (b *logBuffer) full() bool {
+	return b.opts.QueueSize > 0 && len(b.entries) >= b.opts.QueueSize
+}
+
+// trimLocked drops the entries beyond QueueSize, counting them in
+// DroppedCount: the newest under OverflowDropNewest, the oldest otherwise,
+// as a requeue puts messages that already failed once at the front. b.mu
+// must be held.
+func (b *logBuffer) trimLocked() {
+	over := len(b.entries) - b.opts.QueueSize
+	if b.opts.QueueSize <= 0 || over <= 0 {
+		return
+	}
+	if b.opts.Overflow == OverflowDropNewest {
+		b.entries = b.entries[:b.opts.QueueSize]
+	} else {
+		b.entries = b.entries[over:]
+	}
+	droppedLogs.Add(int64(over))
+}
+
+// wakeLocked releases adds blocked on a full queue. b.mu must be held.
+func (b *logBuffer) wakeLocked() {
+	if b.space != nil {
+		close(b.space)
+		b.space = nil
+	}
+}
+
+// kickLocked asks the run goroutine to flush. b.mu must be held.
+func (b *logBuffer) kickLocked() {
+	select {
+	case b.kick <- struct{}{}:
+	default:
+	}
+}
+
+func (b *logBuffer) run() {
+	defer close(b.done)
+
+	ticker := currentClock().NewTicker(b.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C():
+		case <-b.kick:
+		}
+		b.flush(context.Background())
+	}
+}
+
+// flush sends queued entries in order. On a transient error, or once ctx is
+// done, the entry and those after it are put back at the front of the queue
+// for the next flush, trimmed to QueueSize. An entry the platform rejects otherwise is dropped,
+// counted by DroppedCount, and the flush goes on; the first such error is
+// returned.
+func (b *logBuffer) flush(ctx context.Context) error {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	b.mu.Lock()
+	entries := b.entries
+	b.entries = nil
+	b.wakeLocked()
+	b.mu.Unlock()
+
+	var rejected error
+	for i, e := range entries {
+		_, err := e.log.deliver(ctx, e.level, e.text)
+		if err == nil {
+			continue
+		}
+		if !IsTransient(err) && ctx.Err() == nil {
+			droppedLogs.Add(1)
+			if rejected == nil {
+				rejected = err
+			}
+			continue
+		}
+		b.mu.Lock()
+		b.entries = append(entries[i:len(entries):len(entries)], b.entries...)
+		b.trimLocked()
+		b.mu.Unlock()
+		return err
+	}
+	return rejected
+}
+
+func (b *logBuffer) close(ctx context.Context) error {
+	b.mu.Lock()
+	b.closed = true
+	b.wakeLocked()
+	b.mu.Unlock()
+
+	close(b.stop)
+	<-b.done
+	return b.flush(ctx)
+}
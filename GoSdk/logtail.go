@@ -0,0 +1,106 @@
+package cafesdk
+
+import (
+	"sync"
+	"time"
+)
+
+// subscriberBuffer is the channel capacity given to each Log.Subscribe
+// subscriber.
+const subscriberBuffer = 256
+
+// LogEntry is a log message recorded for in-process inspection.
+type LogEntry struct {
+	Time  time.Time
+	Level LogLevel
+	Text  string
+}
+
+// logTail holds the recent-entries ring and the live subscribers. It is
+// shared by all Clients, like the log mirror.
+var logTail struct {
+	mu   sync.Mutex
+	ring []LogEntry // capacity is the configured history size
+	next int        // index of the oldest entry once ring is full
+	subs map[chan LogEntry]struct{}
+}
+
+// SetLogHistory keeps the last capacity log messages that pass the level
+// filter in memory for Log.Recent, so tooling and tests can read back what
+// the actor logged without a server. A non-positive capacity turns history
+// off and drops what was kept; it is off by default.
+func SetLogHistory(capacity int) {
+	logTail.mu.Lock()
+	defer logTail.mu.Unlock()
+
+	logTail.ring, logTail.next = nil, 0
+	if capacity > 0 {
+		logTail.ring = make([]LogEntry, 0, capacity)
+	}
+}
+
+// Recent returns up to the last n log messages kept by SetLogHistory, oldest
+// first. A non-positive n returns all of them.
+func (_Log) Recent(n int) []LogEntry {
+	logTail.mu.Lock()
+	defer logTail.mu.Unlock()
+
+	ring := logTail.ring
+	all := make([]LogEntry, 0, len(ring))
+	all = append(all, ring[logTail.next:]...)
+	all = append(all, ring[:logTail.next]...)
+	if n > 0 && n < len(all) {
+		all = all[len(all)-n:]
+	}
+	return all
+}
+
+// Subscribe returns a channel receiving every log message that passes the
+// level filter from now on, and a function that ends the subscription and
+// closes the channel. A subscriber that falls more than a few hundred
+// entries behind misses messages rather than slowing down logging.
+func (_Log) Subscribe() (<-chan LogEntry, func()) {
+	ch := make(chan LogEntry, subscriberBuffer)
+
+	logTail.mu.Lock()
+	if logTail.subs == nil {
+		logTail.subs = make(map[chan LogEntry]struct{})
+	}
+	logTail.subs[ch] = struct{}{}
+	logTail.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			logTail.mu.Lock()
+			delete(logTail.subs, ch)
+			logTail.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// recordLog adds a message to the history and hands it to subscribers.
+func recordLog(level LogLevel, text string) {
+	logTail.mu.Lock()
+	defer logTail.mu.Unlock()
+
+	if cap(logTail.ring) == 0 && len(logTail.subs) == 0 {
+		return
+	}
+	e := LogEntry{Time: clockNow(), Level: level, Text: text}
+	if ring := logTail.ring; cap(ring) > 0 {
+		if len(ring) < cap(ring) {
+			logTail.ring = append(ring, e)
+		} else {
+			ring[logTail.next] = e
+			logTail.next = (logTail.next + 1) % len(ring)
+		}
+	}
+	for ch := range logTail.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package cafesdk_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	cafesdk "test/GoSdk"
+)
+
+func useLogHistory(t *testing.T, capacity int) {
+	t.Helper()
+	cafesdk.SetLogHistory(capacity)
+	t.Cleanup(func() { cafesdk.SetLogHistory(0) })
+}
+
+func entryTexts(entries []cafesdk.LogEntry) []string {
+	var texts []string
+	for _, e := range entries {
+		texts = append(texts, e.Text)
+	}
+	return texts
+}
+
+func TestLogHistoryKeepsLastN(t *testing.T) {
+	useLogHistory(t, 3)
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+
+	for i := 1; i <= 5; i++ {
+		client.Log.Info(ctx, fmt.Sprintf("line %d", i))
+	}
+	client.Log.Warn(ctx, "line 6")
+
+	if got, want := entryTexts(client.Log.Recent(0)), []string{"line 4", "line 5", "line 6"}; !equalStrings(got, want) {
+		t.Errorf("Recent(0) = %q, want %q", got, want)
+	}
+	recent := client.Log.Recent(2)
+	if got, want := entryTexts(recent), []string{"line 5", "line 6"}; !equalStrings(got, want) {
+		t.Errorf("Recent(2) = %q, want %q", got, want)
+	}
+	if recent[1].Level != cafesdk.LevelWarn || recent[1].Time.IsZero() {
+		t.Errorf("Recent(2)[1] = %+v, want a timestamped Warn entry", recent[1])
+	}
+	if got := len(client.Log.Recent(10)); got != 3 {
+		t.Errorf("Recent(10) returned %d entries, want the 3 kept", got)
+	}
+}
+
+func TestLogHistoryOffByDefault(t *testing.T) {
+	client, _ := newTestClient(t)
+	client.Log.Info(context.Background(), "not kept")
+	if got := client.Log.Recent(0); len(got) != 0 {
+		t.Errorf("Recent(0) without SetLogHistory = %q, want none", entryTexts(got))
+	}
+}
+
+func TestLogHistorySkipsFilteredLevels(t *testing.T) {
+	useLogHistory(t, 10)
+	cafesdk.SetLogLevel(cafesdk.LevelWarn)
+	t.Cleanup(func() { cafesdk.SetLogLevel(cafesdk.LevelDebug) })
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+
+	client.Log.Info(ctx, "quiet")
+	client.Log.Error(ctx, "loud")
+	if got, want := entryTexts(client.Log.Recent(0)), []string{"loud"}; !equalStrings(got, want) {
+		t.Errorf("Recent(0) = %q, want %q", got, want)
+	}
+}
+
+func TestLogSubscribeReceivesNewEntries(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+	client.Log.Info(ctx, "before")
+
+	entries, unsubscribe := client.Log.Subscribe()
+	client.Log.Info(ctx, "first")
+	client.Log.Error(ctx, "second")
+
+	for _, want := range []string{"first", "second"} {
+		select {
+		case e := <-entries:
+			if e.Text != want {
+				t.Errorf("received %q, want %q", e.Text, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no entry received, want %q", want)
+		}
+	}
+
+	unsubscribe()
+	unsubscribe()
+	client.Log.Info(ctx, "after")
+	if e, ok := <-entries; ok {
+		t.Errorf("received %q after unsubscribe, want the channel closed", e.Text)
+	}
+}
@@ -0,0 +1,88 @@
+package cafesdk
+
+import (
+	"bytes"
+	"context"
+	"sync"
+)
+
+// maxLogLine is the longest line a LineWriter buffers before sending it
+// without waiting for its newline.
+const maxLogLine = 64 << 10
+
+// LineWriter is an io.Writer that sends each line written to it as a log
+// message, for libraries that log to an io.Writer such as log.New. Bytes
+// after the last newline are held until the line is completed or Flush is
+// called. It is safe for concurrent use.
+type LineWriter struct {
+	ctx   context.Context
+	log   _Log
+	level LogLevel
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+// LogWriter returns a LineWriter that logs through the package-level Log
+// at level with ctx.
+func LogWriter(ctx context.Context, level LogLevel) *LineWriter {
+	return Log.Writer(ctx, level)
+}
+
+// Writer returns a LineWriter that logs through l at level with ctx.
+func (l _Log) Writer(ctx context.Context, level LogLevel) *LineWriter {
+	return &LineWriter{ctx: ctx, log: l, level: level}
+}
+
+// Write sends every complete line in p, without its line ending, and
+// buffers the rest. Empty lines are dropped. It always consumes all of p and
+// returns the first error from sending a line.
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	var firstErr error
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 && len(w.buf) < maxLogLine {
+			break
+		}
+		end, next := i, i+1
+		if i < 0 {
+			end, next = maxLogLine, maxLogLine
+		}
+		if err := w.sendLocked(w.buf[:end]); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		w.buf = w.buf[next:]
+	}
+	if len(w.buf) == 0 {
+		w.buf = nil
+	}
+	return len(p), firstErr
+}
+
+// Flush sends the buffered partial line, if any.
+func (w *LineWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	line := w.buf
+	w.buf = nil
+	return w.sendLocked(line)
+}
+
+// Close flushes the writer. The LineWriter stays usable afterwards.
+func (w *LineWriter) Close() error {
+	return w.Flush()
+}
+
+func (w *LineWriter) sendLocked(line []byte) error {
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	if len(line) == 0 {
+		return nil
+	}
+	_, err := w.log.send(w.ctx, w.level, string(line))
+	return err
+}
@@ -0,0 +1,72 @@
+package cafesdk_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"testing"
+
+	cafesdk "test/GoSdk"
+)
+
+func TestLineWriterSplitsLines(t *testing.T) {
+	client, srv := newTestClient(t)
+	w := client.Log.Writer(context.Background(), cafesdk.LevelWarn)
+
+	for _, chunk := range []string{"first li", "ne\nsecond\r\n\n", "third"} {
+		if n, err := w.Write([]byte(chunk)); err != nil || n != len(chunk) {
+			t.Fatalf("Write(%q) = %d, %v", chunk, n, err)
+		}
+	}
+	want := []string{"first line", "second"}
+	if got := logTexts(srv); !equalStrings(got, want) {
+		t.Fatalf("before Flush logs = %q, want %q", got, want)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want = append(want, "third")
+	if got := logTexts(srv); !equalStrings(got, want) {
+		t.Errorf("after Close logs = %q, want %q", got, want)
+	}
+	for _, line := range srv.Logs() {
+		if line.Level != cafesdk.LevelWarn {
+			t.Errorf("%q logged at %v, want Warn", line.Text, line.Level)
+		}
+	}
+}
+
+func TestLineWriterWithStdLogger(t *testing.T) {
+	client, srv := newTestClient(t)
+	logger := log.New(client.Log.Writer(context.Background(), cafesdk.LevelInfo), "lib: ", 0)
+
+	for i := 1; i <= 3; i++ {
+		logger.Printf("step %d", i)
+	}
+	want := []string{"lib: step 1", "lib: step 2", "lib: step 3"}
+	if got := logTexts(srv); !equalStrings(got, want) {
+		t.Errorf("logs = %q, want %q", got, want)
+	}
+}
+
+func TestLineWriterSendsOverlongPartialLine(t *testing.T) {
+	client, srv := newTestClient(t)
+	w := client.Log.Writer(context.Background(), cafesdk.LevelInfo)
+
+	// A partial line is not buffered past 64 KiB: the first 64 KiB go out
+	// without waiting for the newline.
+	fmt.Fprint(w, strings.Repeat("x", 64<<10+10))
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	got := logTexts(srv)
+	if len(got) != 2 || len(got[0]) != 64<<10 || got[1] != "xxxxxxxxxx" {
+		lens := make([]int, len(got))
+		for i, s := range got {
+			lens[i] = len(s)
+		}
+		t.Errorf("line lengths = %v, want [65536 10]", lens)
+	}
+}
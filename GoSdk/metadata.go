@@ -0,0 +1,38 @@
+package cafesdk
+
+import (
+	"context"
+
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type metadataKey struct{}
+
+// WithMetadata returns a copy of ctx whose SDK calls send md as gRPC
+// metadata, for platforms that expect tenant or auth headers. It merges with
+// metadata from earlier WithMetadata calls on ctx, md winning for keys set
+// in both. Keys are sent lower-cased, as gRPC requires. Like the other
+// SDK interceptors, it does not apply to a connection given with WithConn.
+func WithMetadata(ctx context.Context, md map[string]string) context.Context {
+	parent, _ := ctx.Value(metadataKey{}).(map[string]string)
+	merged := make(map[string]string, len(parent)+len(md))
+	for k, v := range parent {
+		merged[k] = v
+	}
+	for k, v := range md {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, metadataKey{}, merged)
+}
+
+func metadataInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if md, ok := ctx.Value(metadataKey{}).(map[string]string); ok && len(md) > 0 {
+		kv := make([]string, 0, 2*len(md))
+		for k, v := range md {
+			kv = append(kv, k, v)
+		}
+		ctx = metadata.AppendToOutgoingContext(ctx, kv...)
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
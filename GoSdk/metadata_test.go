@@ -0,0 +1,55 @@
+package cafesdk_test
+
+import (
+	"context"
+	"testing"
+
+	cafesdk "test/GoSdk"
+)
+
+func TestWithMetadataKeysReachServer(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.SetInput(`{}`)
+
+	ctx := cafesdk.WithMetadata(context.Background(), map[string]string{"x-tenant": "acme", "x-auth": "old"})
+	ctx = cafesdk.WithMetadata(ctx, map[string]string{"x-auth": "new", "X-Request-ID": "r1"})
+	if _, err := client.Result.PushData(ctx, `{}`); err != nil {
+		t.Fatalf("PushData: %v", err)
+	}
+	if _, err := client.Log.Info(ctx, "hi"); err != nil {
+		t.Fatalf("Info: %v", err)
+	}
+	if _, err := client.Parameter.GetInputJSONString(ctx); err != nil {
+		t.Fatalf("GetInputJSONString: %v", err)
+	}
+
+	want := map[string]string{"x-tenant": "acme", "x-auth": "new", "x-request-id": "r1"}
+	calls := srv.Calls()
+	if len(calls) != 3 {
+		t.Fatalf("Calls() = %d calls, want 3", len(calls))
+	}
+	for _, call := range calls {
+		for key, value := range want {
+			if got := call.Metadata.Get(key); len(got) != 1 || got[0] != value {
+				t.Errorf("%s: %s = %q, want [%s]", call.Method, key, got, value)
+			}
+		}
+	}
+}
+
+func TestWithMetadataDoesNotLeakToParent(t *testing.T) {
+	client, srv := newTestClient(t)
+	parent := cafesdk.WithMetadata(context.Background(), map[string]string{"x-tenant": "acme"})
+	_ = cafesdk.WithMetadata(parent, map[string]string{"x-extra": "1"})
+
+	if _, err := client.Result.PushData(parent, `{}`); err != nil {
+		t.Fatal(err)
+	}
+	md := srv.CallsTo(cafesdk.MethodPushData)[0].Metadata
+	if got := md.Get("x-extra"); len(got) != 0 {
+		t.Errorf("parent context sent x-extra = %q", got)
+	}
+	if got := md.Get("x-tenant"); len(got) != 1 || got[0] != "acme" {
+		t.Errorf("x-tenant = %q, want [acme]", got)
+	}
+}
@@ -0,0 +1,100 @@
+package cafesdk
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	grpc "google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// Method names reported to observers and accepted by SetTimeout, in
+// "Service.Method" form.
+const (
+	MethodGetInputJSONString = "Parameter.GetInputJSONString"
+	MethodSetTableHeader     = "Result.SetTableHeader"
+	MethodPushData           = "Result.PushData"
+	MethodLogDebug           = "Log.Debug"
+	MethodLogInfo            = "Log.Info"
+	MethodLogWarn            = "Log.Warn"
+	MethodLogError           = "Log.Error"
+	MethodHealthCheck        = "grpc.health.v1.Health.Check"
+)
+
+var knownMethods = map[string]struct{}{
+	MethodGetInputJSONString: {}, MethodSetTableHeader: {}, MethodPushData: {}, MethodLogDebug: {},
+	MethodLogInfo: {}, MethodLogWarn: {}, MethodLogError: {}, MethodHealthCheck: {},
+}
+
+var logMethods = map[LogLevel]string{
+	LevelDebug: MethodLogDebug,
+	LevelInfo:  MethodLogInfo,
+	LevelWarn:  MethodLogWarn,
+	LevelError: MethodLogError,
+}
+
+// MetricsObserver receives one callback per RPC the SDK makes, with the
+// method name, its latency and its error (nil on success). Implementations
+// must be safe for concurrent use and should return quickly.
+type MetricsObserver interface {
+	ObserveRPC(method string, latency time.Duration, err error)
+}
+
+type noopObserver struct{}
+
+func (noopObserver) ObserveRPC(string, time.Duration, error) {}
+
+var metricsObserver atomic.Pointer[MetricsObserver]
+
+// SetMetricsObserver registers obs for every RPC; nil restores the no-op
+// default. It may be called at any time.
+func SetMetricsObserver(obs MetricsObserver) {
+	if obs == nil {
+		obs = noopObserver{}
+	}
+	metricsObserver.Store(&obs)
+}
+
+func currentObserver() MetricsObserver {
+	if obs := metricsObserver.Load(); obs != nil {
+		return *obs
+	}
+	return noopObserver{}
+}
+
+// methodName turns "/cafesdk.Result/PushData" into "Result.PushData".
+func methodName(fullMethod string) string {
+	return strings.Replace(strings.TrimPrefix(fullMethod, "/cafesdk."), "/", ".", 1)
+}
+
+var callTrace atomic.Pointer[func(method string, reqBytes int, dur time.Duration, err error)]
+
+// SetCallTrace registers fn to be called after every RPC with the method
+// name, the serialized request size in bytes, the latency and the error
+// (nil on success), for performance tuning. Requests are only measured
+// while a trace is set; nil, the default, removes it.
+func SetCallTrace(fn func(method string, reqBytes int, dur time.Duration, err error)) {
+	if fn == nil {
+		callTrace.Store(nil)
+		return
+	}
+	callTrace.Store(&fn)
+}
+
+func metricsInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := clockNow()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	elapsed := clockSince(start)
+
+	currentObserver().ObserveRPC(methodName(method), elapsed, err)
+	if trace := callTrace.Load(); trace != nil {
+		var size int
+		if msg, ok := req.(proto.Message); ok {
+			size = proto.Size(msg)
+		}
+		(*trace)(methodName(method), size, elapsed, err)
+	}
+	return err
+}
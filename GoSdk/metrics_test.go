@@ -0,0 +1,134 @@
+package cafesdk_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	cafesdk "test/GoSdk"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeObserver counts RPCs and errors per method.
+type fakeObserver struct {
+	mu      sync.Mutex
+	methods []string
+	errors  map[string]int
+}
+
+func (o *fakeObserver) ObserveRPC(method string, latency time.Duration, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.methods = append(o.methods, method)
+	if err != nil {
+		o.errors[method]++
+	}
+}
+
+func useObserver(t *testing.T) *fakeObserver {
+	t.Helper()
+	obs := &fakeObserver{errors: map[string]int{}}
+	cafesdk.SetMetricsObserver(obs)
+	t.Cleanup(func() { cafesdk.SetMetricsObserver(nil) })
+	return obs
+}
+
+func TestMetricsObserverMethodNames(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.SetInput(`{}`)
+	obs := useObserver(t)
+	ctx := context.Background()
+
+	client.Parameter.GetInputJSONString(ctx)
+	client.Result.SetTableHeader(ctx, []*cafesdk.TableHeaderItem{{Key: "k", Label: "K", Format: cafesdk.FormatText}})
+	client.Result.PushData(ctx, `{}`)
+	client.Log.Debug(ctx, "d")
+	client.Log.Info(ctx, "i")
+	client.Log.Warn(ctx, "w")
+	client.Log.Error(ctx, "e")
+
+	want := []string{
+		cafesdk.MethodGetInputJSONString, cafesdk.MethodSetTableHeader, cafesdk.MethodPushData,
+		cafesdk.MethodLogDebug, cafesdk.MethodLogInfo, cafesdk.MethodLogWarn, cafesdk.MethodLogError,
+	}
+	obs.mu.Lock()
+	defer obs.mu.Unlock()
+	if !equalStrings(obs.methods, want) {
+		t.Errorf("observed %q, want %q", obs.methods, want)
+	}
+	if len(obs.errors) != 0 {
+		t.Errorf("errors = %v, want none", obs.errors)
+	}
+}
+
+func TestMetricsObserverCountsErrors(t *testing.T) {
+	client, srv := newTestClient(t)
+	obs := useObserver(t)
+	ctx := context.Background()
+	srv.FailFunc(cafesdk.MethodPushData, func(call int) error {
+		if call%2 == 0 {
+			return status.Error(codes.Internal, "down")
+		}
+		return nil
+	})
+
+	for range 4 {
+		client.Result.PushData(ctx, `{}`)
+	}
+
+	obs.mu.Lock()
+	defer obs.mu.Unlock()
+	if n := len(obs.methods); n != 4 {
+		t.Errorf("observed %d RPCs, want 4", n)
+	}
+	if n := obs.errors[cafesdk.MethodPushData]; n != 2 {
+		t.Errorf("PushData errors = %d, want 2", n)
+	}
+}
+
+type tracedCall struct {
+	method   string
+	reqBytes int
+	dur      time.Duration
+	err      error
+}
+
+func TestCallTraceSizeAndDuration(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.Delay(cafesdk.MethodPushData, 10*time.Millisecond)
+	var (
+		mu    sync.Mutex
+		calls []tracedCall
+	)
+	cafesdk.SetCallTrace(func(method string, reqBytes int, dur time.Duration, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, tracedCall{method, reqBytes, dur, err})
+	})
+	t.Cleanup(func() { cafesdk.SetCallTrace(nil) })
+
+	record := `{"url":"https://example.com/a-reasonably-long-path"}`
+	if _, err := client.Result.PushData(context.Background(), record); err != nil {
+		t.Fatalf("PushData: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(calls) != 1 {
+		t.Fatalf("trace called %d times, want 1: %+v", len(calls), calls)
+	}
+	c := calls[0]
+	if c.method != cafesdk.MethodPushData || c.err != nil {
+		t.Errorf("trace = %+v, want a successful %s", c, cafesdk.MethodPushData)
+	}
+	// The record plus a few bytes of protobuf framing.
+	if c.reqBytes < len(record) || c.reqBytes > len(record)+16 {
+		t.Errorf("reqBytes = %d, want about %d", c.reqBytes, len(record))
+	}
+	if c.dur < 10*time.Millisecond {
+		t.Errorf("dur = %v, want at least the server delay", c.dur)
+	}
+}
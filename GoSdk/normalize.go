@@ -0,0 +1,75 @@
+package cafesdk
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// SetKeyNormalizer makes Push rename the top-level keys of map records
+// with normalize before encoding them, so records assembled with
+// inconsistent casing line up with the header keys; SnakeCase is a common
+// choice. Nested maps, structs and records pushed as JSON strings are left
+// alone. Two keys that normalize to the same one fail the push. Passing nil
+// turns normalization off.
+func (r _Result) SetKeyNormalizer(normalize func(string) string) {
+	if normalize == nil {
+		r.c.normalizer.Store(nil)
+		return
+	}
+	r.c.normalizer.Store(&normalize)
+}
+
+// normalizeKeys returns v with its keys normalized when v is a map with
+// string keys and a normalizer is set, and v unchanged otherwise.
+func (r _Result) normalizeKeys(v any) (any, error) {
+	p := r.c.normalizer.Load()
+	if p == nil {
+		return v, nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return v, nil
+	}
+
+	normalize := *p
+	out := make(map[string]any, rv.Len())
+	from := make(map[string]string, rv.Len())
+	for it := rv.MapRange(); it.Next(); {
+		key := it.Key().String()
+		norm := normalize(key)
+		if prev, ok := from[norm]; ok {
+			return nil, fmt.Errorf("cafesdk: record keys %q and %q both normalize to %q", prev, key, norm)
+		}
+		from[norm] = key
+		out[norm] = it.Value().Interface()
+	}
+	return out, nil
+}
+
+// SnakeCase converts a key such as "productName", "ProductName",
+// "product-name" or "Product Name" to "product_name". An upper-case run is
+// kept as one word, so "HTTPStatus" becomes "http_status".
+func SnakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		switch {
+		case r == '-' || r == '_' || unicode.IsSpace(r):
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), "_") {
+				b.WriteByte('_')
+			}
+			continue
+		case unicode.IsUpper(r):
+			prevLower := i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]))
+			nextLower := i > 0 && i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsLower(runes[i+1])
+			if (prevLower || nextLower) && !strings.HasSuffix(b.String(), "_") {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return strings.TrimSuffix(b.String(), "_")
+}
@@ -0,0 +1,105 @@
+package cafesdk_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	cafesdk "test/GoSdk"
+)
+
+func TestSnakeCase(t *testing.T) {
+	for in, want := range map[string]string{
+		"productName":   "product_name",
+		"ProductName":   "product_name",
+		"product-name":  "product_name",
+		"Product Name":  "product_name",
+		"HTTPStatus":    "http_status",
+		"price2Value":   "price2_value",
+		"already_snake": "already_snake",
+		"_leading":      "leading",
+		"":              "",
+	} {
+		if got := cafesdk.SnakeCase(in); got != want {
+			t.Errorf("SnakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func pushedFields(t *testing.T, record string) map[string]any {
+	t.Helper()
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(record), &fields); err != nil {
+		t.Fatalf("pushed record %q: %v", record, err)
+	}
+	return fields
+}
+
+func TestKeyNormalizerTopLevelOnly(t *testing.T) {
+	client, srv := newTestClient(t)
+	client.Result.SetKeyNormalizer(cafesdk.SnakeCase)
+
+	record := map[string]any{
+		"productName": "Widget",
+		"UnitPrice":   9.5,
+		"sellerInfo":  map[string]any{"shopName": "Acme"},
+	}
+	if _, err := client.Result.Push(context.Background(), record); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	want := map[string]any{
+		"product_name": "Widget",
+		"unit_price":   9.5,
+		"seller_info":  map[string]any{"shopName": "Acme"},
+	}
+	if got := pushedFields(t, srv.Data()[0]); !reflect.DeepEqual(got, want) {
+		t.Errorf("pushed %v, want %v", got, want)
+	}
+}
+
+func TestKeyNormalizerNilLeavesKeys(t *testing.T) {
+	client, srv := newTestClient(t)
+	client.Result.SetKeyNormalizer(strings.ToUpper)
+	client.Result.SetKeyNormalizer(nil)
+
+	if _, err := client.Result.Push(context.Background(), map[string]any{"productName": "Widget"}); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	if got := srv.Data(); !equalStrings(got, []string{`{"productName":"Widget"}`}) {
+		t.Errorf("Data() = %q, want the keys untouched", got)
+	}
+}
+
+func TestKeyNormalizerCollision(t *testing.T) {
+	client, srv := newTestClient(t)
+	client.Result.SetKeyNormalizer(cafesdk.SnakeCase)
+
+	_, err := client.Result.Push(context.Background(), map[string]any{"productName": 1, "product_name": 2})
+	if err == nil || !strings.Contains(err.Error(), `normalize to "product_name"`) {
+		t.Errorf("Push = %v, want a collision error", err)
+	}
+	if n := len(srv.Calls()); n != 0 {
+		t.Errorf("%d RPCs, want none", n)
+	}
+}
+
+func TestKeyNormalizerSkipsStructsAndStrings(t *testing.T) {
+	client, srv := newTestClient(t)
+	client.Result.SetKeyNormalizer(cafesdk.SnakeCase)
+	ctx := context.Background()
+
+	type item struct {
+		ProductName string
+	}
+	if _, err := client.Result.Push(ctx, item{ProductName: "Widget"}); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	if _, err := client.Result.PushData(ctx, `{"productName":"Widget"}`); err != nil {
+		t.Fatalf("PushData: %v", err)
+	}
+	if got := srv.Data(); !equalStrings(got, []string{`{"ProductName":"Widget"}`, `{"productName":"Widget"}`}) {
+		t.Errorf("Data() = %q, want both unchanged", got)
+	}
+}
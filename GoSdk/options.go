@@ -0,0 +1,160 @@
+package cafesdk
+
+import (
+	"time"
+
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// Option configures how the platform connection is dialed.
+type Option func(*dialConfig)
+
+type dialConfig struct {
+	address            string
+	tls                *TLSConfig
+	conn               grpc.ClientConnInterface
+	unaryInterceptors  []grpc.UnaryClientInterceptor
+	streamInterceptors []grpc.StreamClientInterceptor
+	keepalive          *keepalive.ClientParameters
+	callOptions        []grpc.CallOption
+	compression        bool
+	reconnectAfter     int
+}
+
+// Configure applies opts to the default Client's connection. Like
+// SetAddress, it must be called before the first SDK call establishes the
+// connection.
+func Configure(opts ...Option) error {
+	return defaultClient.configure("Configure", opts...)
+}
+
+// WithAddress sets the platform gRPC address.
+func WithAddress(addr string) Option {
+	return func(c *dialConfig) {
+		c.address = addr
+	}
+}
+
+// WithReconnect makes the Client dial a fresh connection, with the same
+// address, TLS and options, after failures consecutive calls found the
+// platform unavailable, so it recovers when a restarted platform is not
+// picked up by the old connection. The default is 3; zero or less turns
+// reconnection off. Connections injected with WithConn are never replaced.
+func WithReconnect(failures int) Option {
+	return func(c *dialConfig) {
+		c.reconnectAfter = failures
+	}
+}
+
+// WithTLS connects to the platform over TLS; nil selects the default
+// insecure transport.
+func WithTLS(cfg *TLSConfig) Option {
+	return func(c *dialConfig) {
+		c.tls = cfg
+	}
+}
+
+// WithConn makes the Client use conn, such as an in-memory connection or a
+// fake in tests, instead of dialing. The connection is used as is: address,
+// TLS and dial options are ignored, and Close leaves it open.
+func WithConn(conn grpc.ClientConnInterface) Option {
+	return func(c *dialConfig) {
+		c.conn = conn
+	}
+}
+
+// WithUnaryInterceptor adds interceptors around every unary SDK RPC. They
+// run in the order given, outside the SDK's own interceptors, and calls
+// accumulate across options.
+func WithUnaryInterceptor(interceptors ...grpc.UnaryClientInterceptor) Option {
+	return func(c *dialConfig) {
+		c.unaryInterceptors = append(c.unaryInterceptors, interceptors...)
+	}
+}
+
+// WithStreamInterceptor adds interceptors around streaming RPCs made on the
+// connection, in the order given.
+func WithStreamInterceptor(interceptors ...grpc.StreamClientInterceptor) Option {
+	return func(c *dialConfig) {
+		c.streamInterceptors = append(c.streamInterceptors, interceptors...)
+	}
+}
+
+// DefaultKeepalive pings an idle connection every 10 seconds and drops it
+// when a ping goes unanswered for 10 more, so a dead platform connection is
+// noticed within about 20 seconds.
+var DefaultKeepalive = keepalive.ClientParameters{
+	Time:                10 * time.Second,
+	Timeout:             10 * time.Second,
+	PermitWithoutStream: true,
+}
+
+// WithKeepalive enables gRPC keepalive pings; zero Time and Timeout take
+// the DefaultKeepalive values. Keepalive is off unless this option is used:
+// servers reject clients that ping more often than their enforcement
+// policy allows (every 5 minutes by default in grpc-go), so the platform
+// must be configured to accept the chosen interval.
+func WithKeepalive(params keepalive.ClientParameters) Option {
+	if params.Time == 0 {
+		params.Time = DefaultKeepalive.Time
+	}
+	if params.Timeout == 0 {
+		params.Timeout = DefaultKeepalive.Timeout
+	}
+	return func(c *dialConfig) {
+		c.keepalive = &params
+	}
+}
+
+// WithMaxSendMsgSize caps the size of requests the SDK sends. gRPC sets no
+// cap of its own, but the platform rejects requests over its receive limit,
+// 4 MB by default in grpc-go, with ResourceExhausted; capping sends at that
+// limit makes an oversized push fail before it is sent. Every request is
+// held in memory whole, so prefer splitting very large payloads into
+// several records.
+func WithMaxSendMsgSize(bytes int) Option {
+	return func(c *dialConfig) {
+		c.callOptions = append(c.callOptions, grpc.MaxCallSendMsgSize(bytes))
+	}
+}
+
+// WithMaxRecvMsgSize raises the largest response the SDK will accept, such
+// as a big input JSON, from the 4 MB default.
+func WithMaxRecvMsgSize(bytes int) Option {
+	return func(c *dialConfig) {
+		c.callOptions = append(c.callOptions, grpc.MaxCallRecvMsgSize(bytes))
+	}
+}
+
+// WithCompression gzip-compresses requests, which pays off for large pushes
+// to a remote platform. If the platform turns out not to accept gzip, the
+// rejected call is resent uncompressed and compression is dropped for the
+// rest of the connection.
+func WithCompression(enabled bool) Option {
+	return func(c *dialConfig) {
+		c.compression = enabled
+	}
+}
+
+// keepaliveParams builds the keepalive dial option; tests replace it to see
+// the parameters the connection is dialed with.
+var keepaliveParams = grpc.WithKeepaliveParams
+
+func (c *dialConfig) dialOptions() []grpc.DialOption {
+	unary := append(append([]grpc.UnaryClientInterceptor(nil), c.unaryInterceptors...), correlationInterceptor, metadataInterceptor, metricsInterceptor, rpcDebugInterceptor)
+	if c.compression {
+		unary = append(unary, (&gzipFallback{}).intercept)
+	}
+	opts := []grpc.DialOption{grpc.WithChainUnaryInterceptor(unary...)}
+	if len(c.streamInterceptors) > 0 {
+		opts = append(opts, grpc.WithChainStreamInterceptor(c.streamInterceptors...))
+	}
+	if c.keepalive != nil {
+		opts = append(opts, keepaliveParams(*c.keepalive))
+	}
+	if len(c.callOptions) > 0 {
+		opts = append(opts, grpc.WithDefaultCallOptions(c.callOptions...))
+	}
+	return opts
+}
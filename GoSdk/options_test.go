@@ -0,0 +1,49 @@
+package cafesdk
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// dialedKeepalive returns the keepalive parameters cfg dials with, or nil
+// when it adds no keepalive option.
+func dialedKeepalive(t *testing.T, cfg *dialConfig) *keepalive.ClientParameters {
+	t.Helper()
+	var got *keepalive.ClientParameters
+	prev := keepaliveParams
+	keepaliveParams = func(params keepalive.ClientParameters) grpc.DialOption {
+		if got != nil {
+			t.Errorf("keepalive option added twice: %+v, then %+v", *got, params)
+		}
+		got = &params
+		return prev(params)
+	}
+	defer func() { keepaliveParams = prev }()
+
+	cfg.dialOptions()
+	return got
+}
+
+func TestWithKeepaliveDialOption(t *testing.T) {
+	if got := dialedKeepalive(t, &dialConfig{}); got != nil {
+		t.Fatalf("keepalive on by default: %+v", *got)
+	}
+
+	custom := keepalive.ClientParameters{Time: 30 * time.Second, Timeout: 5 * time.Second}
+	var cfg dialConfig
+	WithKeepalive(custom)(&cfg)
+	if got := dialedKeepalive(t, &cfg); got == nil || *got != custom {
+		t.Errorf("dialed keepalive = %v, want %+v", got, custom)
+	}
+}
+
+func TestWithKeepaliveDefaults(t *testing.T) {
+	var cfg dialConfig
+	WithKeepalive(keepalive.ClientParameters{PermitWithoutStream: true})(&cfg)
+	if got := dialedKeepalive(t, &cfg); got == nil || *got != DefaultKeepalive {
+		t.Errorf("dialed keepalive = %v, want DefaultKeepalive %+v", got, DefaultKeepalive)
+	}
+}
@@ -0,0 +1,411 @@
+package cafesdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxSnippet bounds how much of the input JSON is quoted in decode errors.
+const maxSnippet = 200
+
+// Local input sources, used by GetInputJSONString in place of the RPC.
+const (
+	inputFlag    = "--input"
+	inputJSONEnv = "CAFE_INPUT_JSON"
+	inputFileEnv = "CAFE_INPUT_FILE"
+)
+
+// ErrEmptyInput is returned when the platform provides no input JSON.
+var ErrEmptyInput = errors.New("cafesdk: input JSON is empty")
+
+// ErrKeyNotFound is returned by the typed getters when the key is absent or
+// null in the input JSON.
+var ErrKeyNotFound = errors.New("cafesdk: input key not found")
+
+// inputCache holds the input JSON, fetched once per Client and shared by
+// Unmarshal and the typed getters; Refresh replaces it.
+type inputCache struct {
+	mu     sync.Mutex
+	loaded bool
+	raw    string
+	data   map[string]any
+}
+
+// GetInputJSONStringWithRetry is GetInputJSONString retried on transient
+// errors, for actors started before the parameter service is ready. It makes
+// up to maxAttempts attempts with the backoff of DefaultRetryPolicy,
+// independently of SetRetryPolicy.
+func (p _Parameter) GetInputJSONStringWithRetry(ctx context.Context, maxAttempts int) (string, error) {
+	policy := DefaultRetryPolicy
+	policy.MaxAttempts = maxAttempts
+	return withRetry(ctx, p.c, policy, func(ctx context.Context) (string, error) {
+		return p.GetInputJSONString(ctx)
+	})
+}
+
+// Unmarshal decodes the input JSON into v. When v points to a struct, its
+// fields may carry two more tags, so the struct can describe the whole
+// input schema:
+//
+//	type Input struct {
+//		URL      string        `json:"url" validate:"required"`
+//		MaxPages int           `json:"max_pages" default:"10" validate:"min=1,max=500"`
+//		Delay    time.Duration `json:"delay" default:"2s"`
+//		Mode     string        `json:"mode" default:"fast" validate:"oneof=fast|full"`
+//	}
+//
+// A default is applied when the field's key is absent or null. The validate
+// rules are required (present and not empty, or defaulted), min=N and max=N
+// (bounds on numbers and on lengths) and oneof=a|b. Every violation is
+// reported at once in an *InputValidationError. Nested structs are checked
+// too, with dotted paths in the messages.
+func (p _Parameter) Unmarshal(ctx context.Context, v any) error {
+	inputJSON, m, err := p.input(ctx)
+	if err != nil {
+		return err
+	}
+	if err := decodeInput(inputJSON, v); err != nil {
+		return err
+	}
+	return applyInputTags(v, m)
+}
+
+// Refresh re-fetches the input JSON for actors whose input can change during
+// a run. Later getters observe the new input.
+func (p _Parameter) Refresh(ctx context.Context) error {
+	p.c.input.mu.Lock()
+	defer p.c.input.mu.Unlock()
+
+	_, _, err := p.loadLocked(ctx)
+	return err
+}
+
+// GetInput fetches the input JSON and decodes it into a value of type T.
+func GetInput[T any](ctx context.Context) (T, error) {
+	var v T
+	err := Parameter.Unmarshal(ctx, &v)
+	return v, err
+}
+
+// GetString returns the string at key, which may be a dotted path such as
+// "pagination.cursor" for nested objects.
+func (p _Parameter) GetString(ctx context.Context, key string) (string, error) {
+	v, err := p.lookup(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", typeMismatch(key, v, "a string")
+	}
+	return s, nil
+}
+
+// GetInt returns the integer at key. Numbers with a fractional part are
+// reported as a type mismatch.
+func (p _Parameter) GetInt(ctx context.Context, key string) (int, error) {
+	v, err := p.lookup(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, typeMismatch(key, v, "an integer")
+	}
+	i, err := strconv.Atoi(n.String())
+	if err != nil {
+		return 0, typeMismatch(key, v, "an integer")
+	}
+	return i, nil
+}
+
+// GetFloat64 returns the number at key.
+func (p _Parameter) GetFloat64(ctx context.Context, key string) (float64, error) {
+	v, err := p.lookup(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, typeMismatch(key, v, "a number")
+	}
+	return n.Float64()
+}
+
+// GetBool returns the boolean at key.
+func (p _Parameter) GetBool(ctx context.Context, key string) (bool, error) {
+	v, err := p.lookup(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, typeMismatch(key, v, "a boolean")
+	}
+	return b, nil
+}
+
+// GetStringSlice returns the array of strings at key. An empty array gives
+// an empty, non-nil slice.
+func (p _Parameter) GetStringSlice(ctx context.Context, key string) ([]string, error) {
+	items, err := p.array(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, len(items))
+	for i, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			return nil, typeMismatch(fmt.Sprintf("%s[%d]", key, i), item, "a string")
+		}
+		out[i] = s
+	}
+	return out, nil
+}
+
+// GetObjectSlice decodes the array of objects at key into out, which must
+// point to a slice, typically of structs or maps. Every element must be an
+// object; decoding follows encoding/json, numbers included.
+func (p _Parameter) GetObjectSlice(ctx context.Context, key string, out any) error {
+	items, err := p.array(ctx, key)
+	if err != nil {
+		return err
+	}
+	for i, item := range items {
+		if _, ok := item.(map[string]any); !ok {
+			return typeMismatch(fmt.Sprintf("%s[%d]", key, i), item, "an object")
+		}
+	}
+	b, err := json.Marshal(items)
+	if err != nil {
+		return fmt.Errorf("cafesdk: decode input %s: %w", key, err)
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		return fmt.Errorf("cafesdk: decode input %s: %w", key, err)
+	}
+	return nil
+}
+
+func (p _Parameter) array(ctx context.Context, key string) ([]any, error) {
+	v, err := p.lookup(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	items, ok := v.([]any)
+	if !ok {
+		return nil, typeMismatch(key, v, "an array")
+	}
+	return items, nil
+}
+
+// URLList returns the array of URL strings at key, such as a list of start
+// URLs, parsed into absolute URLs. Surrounding whitespace is trimmed. An
+// entry that is not a string or lacks a scheme or host fails the call with
+// an error wrapping ErrInvalidInput that names its index.
+func (p _Parameter) URLList(ctx context.Context, key string) ([]*url.URL, error) {
+	items, err := p.array(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]*url.URL, 0, len(items))
+	for i, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s[%d] is %s, not a URL string", ErrInvalidInput, key, i, jsonType(item))
+		}
+		u, err := url.Parse(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s[%d]: %v", ErrInvalidInput, key, i, err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("%w: %s[%d]: %q is not an absolute URL", ErrInvalidInput, key, i, s)
+		}
+		urls = append(urls, u)
+	}
+	return urls, nil
+}
+
+// MissingInputError lists every required input key that was absent or empty.
+type MissingInputError struct {
+	Keys []string
+}
+
+func (e *MissingInputError) Error() string {
+	return "cafesdk: missing required input: " + strings.Join(e.Keys, ", ")
+}
+
+func (e *MissingInputError) Unwrap() error {
+	return ErrKeyNotFound
+}
+
+// Require checks that every key (dotted paths allowed) is present and not
+// empty, reporting all offenders at once in a *MissingInputError.
+func (p _Parameter) Require(ctx context.Context, keys ...string) error {
+	var missing []string
+	for _, key := range keys {
+		v, err := p.lookup(ctx, key)
+		if errors.Is(err, ErrKeyNotFound) || (err == nil && isEmpty(v)) {
+			missing = append(missing, key)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if len(missing) > 0 {
+		return &MissingInputError{Keys: missing}
+	}
+	return nil
+}
+
+func isEmpty(v any) bool {
+	switch v := v.(type) {
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	}
+	return false
+}
+
+// input returns the raw and parsed input, fetching it on first use.
+func (p _Parameter) input(ctx context.Context) (string, map[string]any, error) {
+	p.c.input.mu.Lock()
+	defer p.c.input.mu.Unlock()
+
+	if p.c.input.loaded {
+		return p.c.input.raw, p.c.input.data, nil
+	}
+	return p.loadLocked(ctx)
+}
+
+func (p _Parameter) loadLocked(ctx context.Context) (string, map[string]any, error) {
+	inputJSON, err := p.GetInputJSONString(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var v any
+	if strings.TrimSpace(inputJSON) != "" {
+		dec := json.NewDecoder(strings.NewReader(inputJSON))
+		dec.UseNumber()
+		if err := dec.Decode(&v); err != nil {
+			return "", nil, fmt.Errorf("cafesdk: decode input %q: %w", snippet(inputJSON), err)
+		}
+	}
+	// Non-object input stays available to Unmarshal; the getters see no keys.
+	m, ok := v.(map[string]any)
+	if !ok {
+		m = map[string]any{}
+	}
+	p.c.input.loaded, p.c.input.raw, p.c.input.data = true, inputJSON, m
+	return inputJSON, m, nil
+}
+
+func (p _Parameter) lookup(ctx context.Context, key string) (any, error) {
+	_, m, err := p.input(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var v any = m
+	for _, part := range strings.Split(key, ".") {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, key)
+		}
+		if v, ok = obj[part]; !ok || v == nil {
+			return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, key)
+		}
+	}
+	return v, nil
+}
+
+func typeMismatch(key string, v any, want string) error {
+	return fmt.Errorf("cafesdk: input %s is %s, not %s", key, jsonType(v), want)
+}
+
+func jsonType(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "a string"
+	case json.Number:
+		return "a number"
+	case bool:
+		return "a boolean"
+	case []any:
+		return "an array"
+	case map[string]any:
+		return "an object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func decodeInput(inputJSON string, v any) error {
+	if strings.TrimSpace(inputJSON) == "" {
+		return ErrEmptyInput
+	}
+	if err := json.Unmarshal([]byte(inputJSON), v); err != nil {
+		return fmt.Errorf("cafesdk: decode input %q: %w", snippet(inputJSON), err)
+	}
+	return nil
+}
+
+func snippet(s string) string {
+	if len(s) <= maxSnippet {
+		return s
+	}
+	return s[:maxSnippet] + "..."
+}
+
+// localInput returns the local input JSON, reporting false when no local
+// source is given.
+func localInput() (string, bool, error) {
+	if path, ok := inputFlagValue(os.Args[1:]); ok {
+		return readInputFile(path)
+	}
+	if input, ok := os.LookupEnv(inputJSONEnv); ok {
+		return input, true, nil
+	}
+	if path := os.Getenv(inputFileEnv); path != "" {
+		return readInputFile(path)
+	}
+	return "", false, nil
+}
+
+// inputFlagValue finds "--input path" or "--input=path" in args without
+// claiming the program's own flag set.
+func inputFlagValue(args []string) (string, bool) {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if v, ok := strings.CutPrefix(arg, inputFlag+"="); ok {
+			return v, true
+		}
+		if arg == inputFlag && i+1 < len(args) {
+			return args[i+1], true
+		}
+	}
+	return "", false
+}
+
+func readInputFile(path string) (string, bool, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", true, fmt.Errorf("cafesdk: read input file: %w", err)
+	}
+	return string(b), true, nil
+}
@@ -0,0 +1,376 @@
+package cafesdk_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	cafesdk "test/GoSdk"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type actorInput struct {
+	URL   string `json:"url"`
+	Pages int    `json:"pages"`
+}
+
+func TestUnmarshalValidInput(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.SetInput(`{"url":"https://example.com","pages":3}`)
+
+	var in actorInput
+	if err := client.Parameter.Unmarshal(context.Background(), &in); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if in != (actorInput{URL: "https://example.com", Pages: 3}) {
+		t.Errorf("Unmarshal = %+v", in)
+	}
+}
+
+func TestGetInputUsesDefaultClient(t *testing.T) {
+	client, srv := newTestClient(t)
+	cafesdk.UseDefaultClient(t, client)
+	srv.SetInput(`{"url":"https://example.com"}`)
+
+	in, err := cafesdk.GetInput[actorInput](context.Background())
+	if err != nil || in.URL != "https://example.com" {
+		t.Fatalf("GetInput = %+v, %v", in, err)
+	}
+}
+
+func TestUnmarshalMalformedInput(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.SetInput(`{"url": "https://example.com",`)
+
+	var in actorInput
+	err := client.Parameter.Unmarshal(context.Background(), &in)
+	if err == nil {
+		t.Fatal("Unmarshal of malformed JSON succeeded")
+	}
+	if !strings.Contains(err.Error(), `https://example.com`) {
+		t.Errorf("error %q does not quote the offending JSON", err)
+	}
+}
+
+func TestUnmarshalEmptyInput(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.SetInput("  ")
+
+	var in actorInput
+	if err := client.Parameter.Unmarshal(context.Background(), &in); !errors.Is(err, cafesdk.ErrEmptyInput) {
+		t.Fatalf("Unmarshal of empty input = %v, want ErrEmptyInput", err)
+	}
+}
+
+func TestUnmarshalTypeErrorQuotesInput(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.SetInput(`{"pages":"three"}`)
+
+	var in actorInput
+	err := client.Parameter.Unmarshal(context.Background(), &in)
+	if err == nil || !strings.Contains(err.Error(), `three`) {
+		t.Fatalf("Unmarshal = %v, want a decode error quoting the input", err)
+	}
+}
+
+const getterInput = `{"name":"shop","limit":20,"ratio":0.5,"debug":true,"pagination":{"limit":50,"cursor":"abc"}}`
+
+func TestScalarGetters(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.SetInput(getterInput)
+	ctx := context.Background()
+
+	if s, err := client.Parameter.GetString(ctx, "name"); err != nil || s != "shop" {
+		t.Errorf("GetString(name) = %q, %v", s, err)
+	}
+	if n, err := client.Parameter.GetInt(ctx, "limit"); err != nil || n != 20 {
+		t.Errorf("GetInt(limit) = %d, %v", n, err)
+	}
+	if f, err := client.Parameter.GetFloat64(ctx, "ratio"); err != nil || f != 0.5 {
+		t.Errorf("GetFloat64(ratio) = %v, %v", f, err)
+	}
+	if b, err := client.Parameter.GetBool(ctx, "debug"); err != nil || !b {
+		t.Errorf("GetBool(debug) = %v, %v", b, err)
+	}
+}
+
+func TestGettersNestedPath(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.SetInput(getterInput)
+	ctx := context.Background()
+
+	if n, err := client.Parameter.GetInt(ctx, "pagination.limit"); err != nil || n != 50 {
+		t.Errorf("GetInt(pagination.limit) = %d, %v", n, err)
+	}
+	if s, err := client.Parameter.GetString(ctx, "pagination.cursor"); err != nil || s != "abc" {
+		t.Errorf("GetString(pagination.cursor) = %q, %v", s, err)
+	}
+	if _, err := client.Parameter.GetInt(ctx, "pagination.limit.deeper"); !errors.Is(err, cafesdk.ErrKeyNotFound) {
+		t.Errorf("GetInt through a number = %v, want ErrKeyNotFound", err)
+	}
+}
+
+func TestGettersMissingKey(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.SetInput(getterInput)
+	ctx := context.Background()
+
+	for _, key := range []string{"absent", "pagination.absent", "absent.limit"} {
+		if _, err := client.Parameter.GetString(ctx, key); !errors.Is(err, cafesdk.ErrKeyNotFound) {
+			t.Errorf("GetString(%s) = %v, want ErrKeyNotFound", key, err)
+		}
+	}
+}
+
+func TestGettersTypeMismatch(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.SetInput(getterInput)
+	ctx := context.Background()
+
+	checks := []struct {
+		name string
+		err  error
+	}{
+		{"GetString(limit)", second(client.Parameter.GetString(ctx, "limit"))},
+		{"GetInt(name)", second(client.Parameter.GetInt(ctx, "name"))},
+		{"GetInt(ratio)", second(client.Parameter.GetInt(ctx, "ratio"))},
+		{"GetFloat64(debug)", second(client.Parameter.GetFloat64(ctx, "debug"))},
+		{"GetBool(pagination)", second(client.Parameter.GetBool(ctx, "pagination"))},
+	}
+	for _, c := range checks {
+		if c.err == nil || errors.Is(c.err, cafesdk.ErrKeyNotFound) || !strings.Contains(c.err.Error(), ", not ") {
+			t.Errorf("%s = %v, want a type mismatch", c.name, c.err)
+		}
+	}
+}
+
+// second returns the error of a value, error pair.
+func second[T any](_ T, err error) error {
+	return err
+}
+
+func TestRequireReportsAllMissingKeys(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.SetInput(`{"url":"https://example.com","query":"  ","tags":[],"pagination":{}}`)
+
+	err := client.Parameter.Require(context.Background(), "url", "query", "tags", "pagination.limit", "token")
+	var missing *cafesdk.MissingInputError
+	if !errors.As(err, &missing) {
+		t.Fatalf("Require = %v, want *MissingInputError", err)
+	}
+	want := []string{"query", "tags", "pagination.limit", "token"}
+	if strings.Join(missing.Keys, ",") != strings.Join(want, ",") {
+		t.Errorf("missing keys = %q, want %q", missing.Keys, want)
+	}
+	if !errors.Is(err, cafesdk.ErrKeyNotFound) {
+		t.Error("MissingInputError does not wrap ErrKeyNotFound")
+	}
+}
+
+func TestRequireAllPresent(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.SetInput(`{"url":"https://example.com","pagination":{"limit":1}}`)
+
+	if err := client.Parameter.Require(context.Background(), "url", "pagination.limit"); err != nil {
+		t.Fatalf("Require = %v, want nil", err)
+	}
+}
+
+func TestInputFetchedOnce(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.SetInput(`{"url":"https://example.com","pages":2}`)
+	ctx := context.Background()
+
+	var in actorInput
+	if err := client.Parameter.Unmarshal(ctx, &in); err != nil {
+		t.Fatal(err)
+	}
+	client.Parameter.GetString(ctx, "url")
+	client.Parameter.GetInt(ctx, "pages")
+	client.Parameter.Require(ctx, "url")
+
+	if n := len(srv.CallsTo(cafesdk.MethodGetInputJSONString)); n != 1 {
+		t.Errorf("GetInputJSONString RPCs = %d, want 1", n)
+	}
+}
+
+func TestRefreshRefetchesInput(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.SetInput(`{"pages":1}`)
+	ctx := context.Background()
+
+	if n, _ := client.Parameter.GetInt(ctx, "pages"); n != 1 {
+		t.Fatalf("GetInt(pages) = %d, want 1", n)
+	}
+	srv.SetInput(`{"pages":2}`)
+	if n, _ := client.Parameter.GetInt(ctx, "pages"); n != 1 {
+		t.Errorf("GetInt(pages) before Refresh = %d, want the cached 1", n)
+	}
+	if err := client.Parameter.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if n, _ := client.Parameter.GetInt(ctx, "pages"); n != 2 {
+		t.Errorf("GetInt(pages) after Refresh = %d, want 2", n)
+	}
+	if n := len(srv.CallsTo(cafesdk.MethodGetInputJSONString)); n != 2 {
+		t.Errorf("GetInputJSONString RPCs = %d, want 2", n)
+	}
+}
+
+func TestGetInputJSONStringWithRetry(t *testing.T) {
+	clock := useFakeClock(t)
+	client, srv := newTestClient(t)
+	srv.SetInput(`{"url":"https://example.com"}`)
+	srv.FailFunc(cafesdk.MethodGetInputJSONString, func(call int) error {
+		if call <= 2 {
+			return status.Error(codes.Unavailable, "starting")
+		}
+		return nil
+	})
+
+	type result struct {
+		input string
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		input, err := client.Parameter.GetInputJSONStringWithRetry(context.Background(), 5)
+		done <- result{input, err}
+	}()
+	for range 2 {
+		clock.BlockUntil(1)
+		clock.Advance(cafesdk.DefaultRetryPolicy.MaxDelay)
+	}
+
+	r := <-done
+	if r.err != nil || r.input != `{"url":"https://example.com"}` {
+		t.Errorf("GetInputJSONStringWithRetry = %q, %v", r.input, r.err)
+	}
+	if n := len(srv.CallsTo(cafesdk.MethodGetInputJSONString)); n != 3 {
+		t.Errorf("made %d attempts, want 3", n)
+	}
+}
+
+func TestGetInputJSONStringWithRetryStopsOnPermanentError(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.Fail(cafesdk.MethodGetInputJSONString, status.Error(codes.PermissionDenied, "no"))
+
+	if _, err := client.Parameter.GetInputJSONStringWithRetry(context.Background(), 5); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("GetInputJSONStringWithRetry = %v, want PermissionDenied", err)
+	}
+	if n := len(srv.CallsTo(cafesdk.MethodGetInputJSONString)); n != 1 {
+		t.Errorf("made %d attempts, want 1", n)
+	}
+}
+
+func TestURLListValid(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.SetInput(`{"start":{"urls":["https://example.com/a?p=1", " http://example.org "]}}`)
+
+	urls, err := client.Parameter.URLList(context.Background(), "start.urls")
+	if err != nil {
+		t.Fatalf("URLList: %v", err)
+	}
+	var got []string
+	for _, u := range urls {
+		got = append(got, u.String())
+	}
+	if want := []string{"https://example.com/a?p=1", "http://example.org"}; !equalStrings(got, want) {
+		t.Errorf("URLList = %q, want %q", got, want)
+	}
+	if urls[0].Host != "example.com" || urls[0].Query().Get("p") != "1" {
+		t.Errorf("URLList[0] parsed as %+v", urls[0])
+	}
+}
+
+func TestURLListInvalidEntry(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.SetInput(`{"bad":["https://ok.example","example.com/no-scheme"],"ugly":["http://%zz"],"mixed":["https://ok.example",7]}`)
+	ctx := context.Background()
+
+	for key, index := range map[string]string{"bad": "bad[1]", "ugly": "ugly[0]", "mixed": "mixed[1]"} {
+		_, err := client.Parameter.URLList(ctx, key)
+		if !errors.Is(err, cafesdk.ErrInvalidInput) || !strings.Contains(err.Error(), index) {
+			t.Errorf("URLList(%s) = %v, want ErrInvalidInput naming %s", key, err, index)
+		}
+	}
+}
+
+func TestURLListMissingKey(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.SetInput(`{"urls":"https://example.com"}`)
+	ctx := context.Background()
+
+	if _, err := client.Parameter.URLList(ctx, "startUrls"); !errors.Is(err, cafesdk.ErrKeyNotFound) {
+		t.Errorf("URLList(startUrls) = %v, want ErrKeyNotFound", err)
+	}
+	if _, err := client.Parameter.URLList(ctx, "urls"); err == nil || !strings.Contains(err.Error(), "an array") {
+		t.Errorf("URLList(urls) on a string = %v, want a type mismatch", err)
+	}
+}
+
+const sliceInput = `{"keywords":["go","grpc"],"none":[],"mixed":["a",1],"seeds":[{"url":"https://a.example","depth":2},{"url":"https://b.example"}],"notObjects":[{"url":"x"},"y"],"name":"shop"}`
+
+func TestGetStringSlice(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.SetInput(sliceInput)
+	ctx := context.Background()
+
+	if got, err := client.Parameter.GetStringSlice(ctx, "keywords"); err != nil || !equalStrings(got, []string{"go", "grpc"}) {
+		t.Errorf("GetStringSlice(keywords) = %q, %v", got, err)
+	}
+	if got, err := client.Parameter.GetStringSlice(ctx, "none"); err != nil || got == nil || len(got) != 0 {
+		t.Errorf("GetStringSlice(none) = %#v, %v; want an empty, non-nil slice", got, err)
+	}
+
+	checks := map[string]string{"mixed": "mixed[1] is a number, not a string", "name": "name is a string, not an array"}
+	for key, want := range checks {
+		if _, err := client.Parameter.GetStringSlice(ctx, key); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("GetStringSlice(%s) = %v, want %q", key, err, want)
+		}
+	}
+	if _, err := client.Parameter.GetStringSlice(ctx, "absent"); !errors.Is(err, cafesdk.ErrKeyNotFound) {
+		t.Errorf("GetStringSlice(absent) = %v, want ErrKeyNotFound", err)
+	}
+}
+
+func TestGetObjectSlice(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.SetInput(sliceInput)
+	ctx := context.Background()
+
+	type seed struct {
+		URL   string `json:"url"`
+		Depth int    `json:"depth"`
+	}
+	var seeds []seed
+	if err := client.Parameter.GetObjectSlice(ctx, "seeds", &seeds); err != nil {
+		t.Fatalf("GetObjectSlice(seeds): %v", err)
+	}
+	if len(seeds) != 2 || seeds[0] != (seed{"https://a.example", 2}) || seeds[1] != (seed{"https://b.example", 0}) {
+		t.Errorf("GetObjectSlice(seeds) = %+v", seeds)
+	}
+
+	empty := []seed{{URL: "stale"}}
+	if err := client.Parameter.GetObjectSlice(ctx, "none", &empty); err != nil || len(empty) != 0 {
+		t.Errorf("GetObjectSlice(none) = %+v, %v; want an empty slice", empty, err)
+	}
+
+	var out []seed
+	checks := map[string]string{"notObjects": "notObjects[1] is a string, not an object", "keywords": "keywords[0] is a string, not an object", "name": "name is a string, not an array"}
+	for key, want := range checks {
+		if err := client.Parameter.GetObjectSlice(ctx, key, &out); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("GetObjectSlice(%s) = %v, want %q", key, err, want)
+		}
+	}
+	var wrongField []struct{ Depth string }
+	if err := client.Parameter.GetObjectSlice(ctx, "seeds", &wrongField); err == nil || !strings.Contains(err.Error(), "decode input seeds") {
+		t.Errorf("GetObjectSlice into mismatched structs = %v, want a decode error", err)
+	}
+	if n := len(srv.CallsTo(cafesdk.MethodGetInputJSONString)); n != 1 {
+		t.Errorf("input fetched %d times, want once from the cache", n)
+	}
+}
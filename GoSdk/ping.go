@@ -0,0 +1,39 @@
+package cafesdk
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// Ping checks that the default Client's platform is reachable. See
+// Client.Ping.
+func Ping(ctx context.Context) error {
+	return defaultClient.Ping(ctx)
+}
+
+// Ping connects to the platform if needed and makes a standard gRPC health
+// check, so an actor can fail fast at startup instead of on its first real
+// call. A platform without the health service still counts as reachable,
+// since it answered. Ping makes no call in dry-run mode.
+func (c *Client) Ping(ctx context.Context) error {
+	if dryRun.Load() {
+		return nil
+	}
+
+	res, err := invoke(ctx, c, MethodHealthCheck, func(ctx context.Context) (*healthpb.HealthCheckResponse, error) {
+		return healthpb.NewHealthClient(c.conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	})
+	switch {
+	case status.Code(err) == codes.Unimplemented:
+		return nil
+	case err != nil:
+		return fmt.Errorf("cafesdk: ping platform: %w", err)
+	case res.GetStatus() != healthpb.HealthCheckResponse_SERVING:
+		return fmt.Errorf("%w: platform health is %s", ErrUnavailable, res.GetStatus())
+	}
+	return nil
+}
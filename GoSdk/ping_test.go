@@ -0,0 +1,39 @@
+package cafesdk_test
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	cafesdk "test/GoSdk"
+)
+
+func TestPingReachable(t *testing.T) {
+	// The test server has no health service, which still counts as an
+	// answer.
+	client, _ := newTestClient(t)
+
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+}
+
+func TestPingUnreachable(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+	client := cafesdk.New(cafesdk.WithAddress(addr))
+	t.Cleanup(func() { client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	err = client.Ping(ctx)
+	if err == nil || !strings.Contains(err.Error(), "ping platform") {
+		t.Errorf("Ping = %v, want a ping platform error", err)
+	}
+}
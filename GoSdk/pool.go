@@ -0,0 +1,96 @@
+package cafesdk
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// WorkerPool runs tasks with bounded parallelism.
+type WorkerPool struct {
+	sem           chan struct{}
+	ctx           context.Context
+	cancel        context.CancelFunc
+	cancelOnError bool
+
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	errs   []error
+	waited bool
+}
+
+// NewWorkerPool returns a pool running at most concurrency tasks at once.
+// A concurrency below 1 is treated as 1.
+func NewWorkerPool(concurrency int) *WorkerPool {
+	p := &WorkerPool{sem: make(chan struct{}, max(concurrency, 1))}
+	return p.WithContext(context.Background())
+}
+
+// WithContext makes tasks receive a context derived from ctx, releasing the
+// one set before. Call it before the first Submit.
+func (p *WorkerPool) WithContext(ctx context.Context) *WorkerPool {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.ctx, p.cancel = context.WithCancel(ctx)
+	return p
+}
+
+// CancelOnError cancels the tasks' context on the first failure, so running
+// tasks can stop early and queued ones are skipped. Call it before the first
+// Submit.
+func (p *WorkerPool) CancelOnError() *WorkerPool {
+	p.cancelOnError = true
+	return p
+}
+
+// Submit runs task once a worker is free, blocking until then. Tasks
+// submitted after the pool's context is cancelled are not run. A pool cannot
+// be reused: Submit panics once Wait has returned.
+func (p *WorkerPool) Submit(task func(ctx context.Context) error) {
+	p.mu.Lock()
+	waited := p.waited
+	p.mu.Unlock()
+	if waited {
+		panic("cafesdk: WorkerPool.Submit called after Wait")
+	}
+
+	select {
+	case p.sem <- struct{}{}:
+	case <-p.ctx.Done():
+		return
+	}
+	if p.ctx.Err() != nil {
+		<-p.sem
+		return
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer func() {
+			<-p.sem
+			p.wg.Done()
+		}()
+
+		if err := task(p.ctx); err != nil {
+			p.mu.Lock()
+			p.errs = append(p.errs, err)
+			p.mu.Unlock()
+			if p.cancelOnError {
+				p.cancel()
+			}
+		}
+	}()
+}
+
+// Wait blocks until every submitted task has finished and returns their
+// errors joined in the order they occurred, or nil.
+func (p *WorkerPool) Wait() error {
+	p.wg.Wait()
+	p.cancel()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.waited = true
+	return errors.Join(p.errs...)
+}
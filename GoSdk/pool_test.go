@@ -0,0 +1,98 @@
+package cafesdk_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cafesdk "test/GoSdk"
+)
+
+func TestWorkerPoolRunsAll(t *testing.T) {
+	pool := cafesdk.NewWorkerPool(4)
+	var ran atomic.Int64
+	for range 100 {
+		pool.Submit(func(context.Context) error {
+			ran.Add(1)
+			return nil
+		})
+	}
+	if err := pool.Wait(); err != nil {
+		t.Fatalf("Wait = %v", err)
+	}
+	if n := ran.Load(); n != 100 {
+		t.Errorf("%d tasks ran, want 100", n)
+	}
+}
+
+func TestWorkerPoolAggregatesErrors(t *testing.T) {
+	pool := cafesdk.NewWorkerPool(2)
+	errA, errB := errors.New("a failed"), errors.New("b failed")
+	for _, err := range []error{errA, nil, errB, nil} {
+		pool.Submit(func(context.Context) error { return err })
+	}
+
+	err := pool.Wait()
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Errorf("Wait = %v, want both errors", err)
+	}
+}
+
+func TestWorkerPoolCancelOnError(t *testing.T) {
+	pool := cafesdk.NewWorkerPool(1).CancelOnError()
+	errBoom := errors.New("boom")
+	var ran atomic.Int64
+	pool.Submit(func(context.Context) error { return errBoom })
+	for range 10 {
+		pool.Submit(func(context.Context) error {
+			ran.Add(1)
+			return nil
+		})
+	}
+
+	if err := pool.Wait(); !errors.Is(err, errBoom) {
+		t.Fatalf("Wait = %v, want boom", err)
+	}
+	if n := ran.Load(); n != 0 {
+		t.Errorf("%d tasks ran after the failure, want 0", n)
+	}
+}
+
+func TestWorkerPoolConcurrencyLimit(t *testing.T) {
+	const limit = 3
+	pool := cafesdk.NewWorkerPool(limit)
+	var running, peak atomic.Int64
+	for range 30 {
+		pool.Submit(func(context.Context) error {
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(2 * time.Millisecond)
+			running.Add(-1)
+			return nil
+		})
+	}
+	pool.Wait()
+
+	if p := peak.Load(); p > limit {
+		t.Errorf("%d tasks ran at once, want at most %d", p, limit)
+	}
+}
+
+func TestWorkerPoolSubmitAfterWaitPanics(t *testing.T) {
+	pool := cafesdk.NewWorkerPool(1)
+	pool.Wait()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Submit after Wait did not panic")
+		}
+	}()
+	pool.Submit(func(context.Context) error { return nil })
+}
@@ -0,0 +1,63 @@
+package cafesdk
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// ProgressLogPrefix starts the Info log lines that carry progress updates.
+// The platform has no progress RPC, so progress is reported, regardless of
+// SetLogLevel, as
+// "[cafesdk:progress] {"fraction":0.25,"done":25,"total":100}".
+const ProgressLogPrefix = "[cafesdk:progress] "
+
+// progressInterval is the minimum gap between progress updates sent.
+const progressInterval = time.Second
+
+var (
+	progressMu   sync.Mutex
+	progressSent time.Time
+)
+
+type progressUpdate struct {
+	Fraction float64 `json:"fraction"`
+	Done     int     `json:"done,omitempty"`
+	Total    int     `json:"total,omitempty"`
+}
+
+// SetProgress reports that done of total items are finished.
+func (r _Result) SetProgress(ctx context.Context, done, total int) (*Response, error) {
+	var fraction float64
+	if total > 0 {
+		fraction = float64(done) / float64(total)
+	}
+	return r.sendProgress(ctx, progressUpdate{Fraction: fraction, Done: done, Total: total})
+}
+
+// ReportProgress reports completion as a fraction, clamped to [0, 1].
+func (r _Result) ReportProgress(ctx context.Context, fraction float64) (*Response, error) {
+	return r.sendProgress(ctx, progressUpdate{Fraction: fraction})
+}
+
+// sendProgress sends at most one update per progressInterval; updates in
+// between return an empty Response. Completion is always sent.
+func (r _Result) sendProgress(ctx context.Context, p progressUpdate) (*Response, error) {
+	p.Fraction = min(max(p.Fraction, 0), 1)
+
+	progressMu.Lock()
+	now := clockNow()
+	if p.Fraction < 1 && now.Sub(progressSent) < progressInterval {
+		progressMu.Unlock()
+		return &Response{}, nil
+	}
+	progressSent = now
+	progressMu.Unlock()
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return r.c.Log.emit(ctx, LevelInfo, ProgressLogPrefix+string(b))
+}
@@ -0,0 +1,72 @@
+package cafesdk_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	cafesdk "test/GoSdk"
+	"test/GoSdk/cafesdktest"
+)
+
+// progressFractions returns the fractions of the progress lines received.
+func progressFractions(t *testing.T, srv *cafesdktest.Server) []float64 {
+	t.Helper()
+	var out []float64
+	for _, line := range srv.Logs() {
+		body, ok := strings.CutPrefix(line.Text, cafesdk.ProgressLogPrefix)
+		if !ok {
+			continue
+		}
+		var p struct{ Fraction float64 }
+		if err := json.Unmarshal([]byte(body), &p); err != nil {
+			t.Fatalf("progress line %q: %v", line.Text, err)
+		}
+		out = append(out, p.Fraction)
+	}
+	return out
+}
+
+func TestProgressThrottled(t *testing.T) {
+	clock := useFakeClock(t)
+	cafesdk.ResetProgress(t)
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+
+	client.Result.ReportProgress(ctx, 0.1)
+	client.Result.ReportProgress(ctx, 0.2)
+	clock.Advance(999 * time.Millisecond)
+	client.Result.SetProgress(ctx, 3, 10)
+	clock.Advance(time.Millisecond)
+	client.Result.SetProgress(ctx, 4, 10)
+	client.Result.SetProgress(ctx, 10, 10)
+
+	want := []float64{0.1, 0.4, 1}
+	got := progressFractions(t, srv)
+	if len(got) != len(want) {
+		t.Fatalf("progress sent = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("progress sent = %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestProgressClamped(t *testing.T) {
+	clock := useFakeClock(t)
+	cafesdk.ResetProgress(t)
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+
+	client.Result.ReportProgress(ctx, -0.5)
+	clock.Advance(time.Second)
+	client.Result.ReportProgress(ctx, 1.7)
+
+	if got := progressFractions(t, srv); len(got) != 2 || got[0] != 0 || got[1] != 1 {
+		t.Errorf("progress sent = %v, want [0 1]", got)
+	}
+}
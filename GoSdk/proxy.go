@@ -0,0 +1,107 @@
+package cafesdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const defaultProxyCooldown = 30 * time.Second
+
+// proxyPool hands out proxies in rotation, skipping ones that recently
+// failed to connect.
+type proxyPool struct {
+	proxies  []*url.URL
+	random   bool
+	cooldown time.Duration
+
+	mu        sync.Mutex
+	next      int
+	deadUntil []time.Time
+}
+
+func newProxyPool(proxies []string, random bool, cooldown time.Duration) (*proxyPool, error) {
+	if cooldown <= 0 {
+		cooldown = defaultProxyCooldown
+	}
+	p := &proxyPool{random: random, cooldown: cooldown, deadUntil: make([]time.Time, len(proxies))}
+	for _, raw := range proxies {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("cafesdk: parse proxy URL %q: %w", raw, err)
+		}
+		p.proxies = append(p.proxies, u)
+	}
+	return p, nil
+}
+
+// pick returns the index of the next live proxy. When every proxy is cooling
+// down it falls back to plain rotation rather than failing the request.
+func (p *proxyPool) pick() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n := len(p.proxies)
+	start := p.next
+	if p.random {
+		start = rand.IntN(n)
+	}
+	p.next = (start + 1) % n
+
+	now := clockNow()
+	for i := 0; i < n; i++ {
+		idx := (start + i) % n
+		if now.After(p.deadUntil[idx]) {
+			if !p.random {
+				p.next = (idx + 1) % n
+			}
+			return idx
+		}
+	}
+	return start
+}
+
+func (p *proxyPool) markDead(idx int) {
+	p.mu.Lock()
+	p.deadUntil[idx] = clockNow().Add(p.cooldown)
+	p.mu.Unlock()
+}
+
+type proxyKey struct{}
+
+// rotatingTransport picks a proxy per request and hands it to the wrapped
+// transport's Proxy hook through the request context.
+type rotatingTransport struct {
+	pool *proxyPool
+	base http.RoundTripper
+}
+
+func newRotatingTransport(pool *proxyPool, base *http.Transport) *rotatingTransport {
+	base.Proxy = func(req *http.Request) (*url.URL, error) {
+		u, _ := req.Context().Value(proxyKey{}).(*url.URL)
+		return u, nil
+	}
+	return &rotatingTransport{pool: pool, base: base}
+}
+
+func (t *rotatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	idx := t.pool.pick()
+	req = req.WithContext(context.WithValue(req.Context(), proxyKey{}, t.pool.proxies[idx]))
+
+	resp, err := t.base.RoundTrip(req)
+	if isProxyConnectError(err) {
+		t.pool.markDead(idx)
+	}
+	return resp, err
+}
+
+func isProxyConnectError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "proxyconnect"
+}
@@ -0,0 +1,118 @@
+package cafesdk
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDialer stands in for the network: it records the address of every
+// dial and answers each request with 200, except on the addresses in fail,
+// whose dials are refused.
+type fakeDialer struct {
+	mu    sync.Mutex
+	dials []string
+	fail  map[string]bool
+}
+
+func (d *fakeDialer) DialContext(_ context.Context, _, addr string) (net.Conn, error) {
+	d.mu.Lock()
+	d.dials = append(d.dials, addr)
+	fail := d.fail[addr]
+	d.mu.Unlock()
+	if fail {
+		return nil, errors.New("connection refused")
+	}
+
+	client, server := net.Pipe()
+	go func() {
+		defer server.Close()
+		if _, err := http.ReadRequest(bufio.NewReader(server)); err == nil {
+			server.Write([]byte("HTTP/1.1 200 OK\r\nContent-Length: 0\r\nConnection: close\r\n\r\n"))
+		}
+	}()
+	return client, nil
+}
+
+func (d *fakeDialer) dialed() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.dials...)
+}
+
+func newFakeRotatingClient(t *testing.T, d *fakeDialer, cooldown time.Duration, proxies ...string) *http.Client {
+	t.Helper()
+	pool, err := newProxyPool(proxies, false, cooldown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := &http.Transport{DialContext: d.DialContext, DisableKeepAlives: true}
+	return &http.Client{Transport: newRotatingTransport(pool, base)}
+}
+
+func get(client *http.Client) error {
+	resp, err := client.Get("http://example.com/")
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func TestProxyRotationOrder(t *testing.T) {
+	d := &fakeDialer{}
+	client := newFakeRotatingClient(t, d, time.Minute, "http://a:1", "http://b:2", "http://c:3")
+
+	for range 6 {
+		if err := get(client); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{"a:1", "b:2", "c:3", "a:1", "b:2", "c:3"}
+	if got := d.dialed(); !equalStrings(got, want) {
+		t.Errorf("dials = %q, want %q", got, want)
+	}
+}
+
+func TestProxyRotationSkipsFailedProxy(t *testing.T) {
+	d := &fakeDialer{fail: map[string]bool{"b:2": true}}
+	client := newFakeRotatingClient(t, d, 50*time.Millisecond, "http://a:1", "http://b:2", "http://c:3")
+
+	var errs int
+	for range 5 {
+		if get(client) != nil {
+			errs++
+		}
+	}
+	if errs != 1 {
+		t.Errorf("%d requests failed, want only the one through b", errs)
+	}
+	want := []string{"a:1", "b:2", "c:3", "a:1", "c:3"}
+	if got := d.dialed(); !equalStrings(got, want) {
+		t.Errorf("dials = %q, want %q", got, want)
+	}
+
+	// Once the cooldown is over, b is tried again.
+	time.Sleep(60 * time.Millisecond)
+	get(client)
+	get(client)
+	if got := d.dialed()[5:]; !equalStrings(got, []string{"a:1", "b:2"}) {
+		t.Errorf("dials after cooldown = %q, want a then b", got)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+package cafesdk
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket limiter keyed by host. Each host may send
+// perSecond requests per second on average, with bursts of up to burst.
+type RateLimiter struct {
+	perSecond float64
+	burst     float64
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a RateLimiter allowing perSecond requests per
+// second to each host. A burst below 1 is treated as 1. A perSecond of zero
+// or less leaves requests unlimited.
+func NewRateLimiter(perSecond float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		perSecond: perSecond,
+		burst:     float64(max(burst, 1)),
+		buckets:   map[string]*tokenBucket{},
+	}
+}
+
+// Wait blocks until host may send another request or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context, host string) error {
+	if l.perSecond <= 0 {
+		return ctx.Err()
+	}
+	l.mu.Lock()
+	now := clockNow()
+	b, ok := l.buckets[host]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[host] = b
+	}
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.perSecond)
+	b.last = now
+	b.tokens--
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / l.perSecond * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if wait == 0 {
+		return nil
+	}
+	if err := Sleep(ctx, wait); err != nil {
+		l.mu.Lock()
+		b.tokens++
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Transport returns a RoundTripper that waits on the limiter for each
+// request's host before handing it to base.
+func (l *RateLimiter) Transport(base http.RoundTripper) http.RoundTripper {
+	return &rateLimitedTransport{limiter: l, base: base}
+}
+
+type rateLimitedTransport struct {
+	limiter *RateLimiter
+	base    http.RoundTripper
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context(), req.URL.Host); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,62 @@
+package cafesdk_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	cafesdk "test/GoSdk"
+)
+
+func TestRateLimiterPacesRequests(t *testing.T) {
+	clock := useFakeClock(t)
+	limiter := cafesdk.NewRateLimiter(2, 1)
+	start := clock.Now()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var mu sync.Mutex
+	var passed []time.Time
+	go func() {
+		// The eleventh Wait keeps a timer pending, so BlockUntil below
+		// never waits on a finished goroutine; cancel ends it.
+		for range 11 {
+			if limiter.Wait(ctx, "example.com") != nil {
+				return
+			}
+			mu.Lock()
+			passed = append(passed, clock.Now())
+			mu.Unlock()
+		}
+	}()
+
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(passed)
+	}
+	for count() < 10 {
+		clock.BlockUntil(1)
+		clock.Advance(100 * time.Millisecond)
+	}
+
+	mu.Lock()
+	elapsed := passed[9].Sub(start)
+	mu.Unlock()
+	if elapsed < 4*time.Second || elapsed > 5*time.Second {
+		t.Errorf("10 requests at 2/s took %v, want about 4.5s", elapsed)
+	}
+}
+
+func TestRateLimiterZeroIsUnlimited(t *testing.T) {
+	limiter := cafesdk.NewRateLimiter(0, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	for range 100 {
+		if err := limiter.Wait(ctx, "example.com"); err != nil {
+			t.Fatalf("Wait = %v, want no limit", err)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package cafesdk
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"unicode/utf8"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Metadata keys sent by PushRaw.
+const (
+	// ContentTypeHeader carries the content type given to PushRaw.
+	ContentTypeHeader = "x-content-type"
+	// ContentEncodingHeader is "base64" when PushRaw had to encode the
+	// payload.
+	ContentEncodingHeader = "x-content-encoding"
+)
+
+const defaultRawContentType = "application/octet-stream"
+
+// PushRaw pushes an already serialized record, such as an encoded protobuf
+// message, tagged with contentType (application/octet-stream if empty), so
+// a platform that understands other formats can decode it. The Data message
+// only has a string field, so data is sent in it as is when it is valid
+// UTF-8 and base64-encoded otherwise, with ContentEncodingHeader set to
+// "base64". A platform that ignores these headers stores the payload as
+// text.
+//
+// PushRaw skips the checks and rewriting PushData applies to JSON:
+// SetJSONValidation, SetSourceField and SetHeaderValidation tracking.
+func (r _Result) PushRaw(ctx context.Context, data []byte, contentType string) (*Response, error) {
+	if contentType == "" {
+		contentType = defaultRawContentType
+	}
+	payload := string(data)
+	md := []string{ContentTypeHeader, contentType}
+	if !utf8.Valid(data) {
+		payload = base64.StdEncoding.EncodeToString(data)
+		md = append(md, ContentEncodingHeader, "base64")
+	}
+	ctx = metadata.AppendToOutgoingContext(ctx, md...)
+
+	res, err := withRetry(ctx, r.c, currentRetryPolicy(), func(ctx context.Context) (*Response, error) {
+		if dryRunCall(MethodPushData, fmt.Sprintf("(%s, %d bytes)", contentType, len(data))) {
+			return dryRunResponse(), nil
+		}
+		return invoke(ctx, r.c, MethodPushData, func(ctx context.Context) (*Response, error) {
+			return r.c.resultClient.PushData(ctx, &Data{JsonString: payload})
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.c.pushed.Add(1)
+	return res, nil
+}
@@ -0,0 +1,67 @@
+package cafesdk_test
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	cafesdk "test/GoSdk"
+)
+
+func TestPushRawSendsContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        []byte
+		contentType string
+		wantType    string
+		wantData    string
+		wantEnc     []string
+	}{
+		{"text", []byte("id,name\n1,a\n"), "text/csv", "text/csv", "id,name\n1,a\n", nil},
+		{"binary", []byte{0x0a, 0x03, 0xff, 0xfe, 0x00}, "application/x-protobuf", "application/x-protobuf",
+			base64.StdEncoding.EncodeToString([]byte{0x0a, 0x03, 0xff, 0xfe, 0x00}), []string{"base64"}},
+		{"default type", []byte("blob"), "", "application/octet-stream", "blob", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, srv := newTestClient(t)
+			if _, err := client.Result.PushRaw(context.Background(), tt.data, tt.contentType); err != nil {
+				t.Fatalf("PushRaw: %v", err)
+			}
+
+			if got := srv.Data(); len(got) != 1 || got[0] != tt.wantData {
+				t.Errorf("Data() = %q, want [%q]", got, tt.wantData)
+			}
+			calls := srv.CallsTo(cafesdk.MethodPushData)
+			if len(calls) != 1 {
+				t.Fatalf("CallsTo(PushData) = %d calls, want 1", len(calls))
+			}
+			md := calls[0].Metadata
+			if got := md.Get(cafesdk.ContentTypeHeader); len(got) != 1 || got[0] != tt.wantType {
+				t.Errorf("%s = %q, want [%s]", cafesdk.ContentTypeHeader, got, tt.wantType)
+			}
+			if got := md.Get(cafesdk.ContentEncodingHeader); !equalStrings(got, tt.wantEnc) {
+				t.Errorf("%s = %q, want %q", cafesdk.ContentEncodingHeader, got, tt.wantEnc)
+			}
+		})
+	}
+}
+
+func TestPushRawLeavesJSONPathUntagged(t *testing.T) {
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+	if _, err := client.Result.PushRaw(ctx, []byte("raw"), "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Result.PushData(ctx, `{"n":1}`); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := srv.CallsTo(cafesdk.MethodPushData)
+	if len(calls) != 2 {
+		t.Fatalf("CallsTo(PushData) = %d calls, want 2", len(calls))
+	}
+	if got := calls[1].Metadata.Get(cafesdk.ContentTypeHeader); len(got) != 0 {
+		t.Errorf("PushData sent %s = %q, want none", cafesdk.ContentTypeHeader, got)
+	}
+}
@@ -0,0 +1,96 @@
+package cafesdk
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	grpc "google.golang.org/grpc"
+)
+
+// defaultReconnectAfter is how many consecutive ErrUnavailable calls make
+// the Client replace its connection, unless WithReconnect says otherwise.
+const defaultReconnectAfter = 3
+
+// swapConn is the connection the service clients are bound to when the
+// Client dials itself. It forwards to the current *grpc.ClientConn, which
+// reconnect replaces without rebinding them.
+type swapConn struct {
+	cur atomic.Pointer[grpc.ClientConn]
+}
+
+func newSwapConn(conn *grpc.ClientConn) *swapConn {
+	s := &swapConn{}
+	s.cur.Store(conn)
+	return s
+}
+
+func (s *swapConn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	return s.cur.Load().Invoke(ctx, method, args, reply, opts...)
+}
+
+func (s *swapConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return s.cur.Load().NewStream(ctx, desc, method, opts...)
+}
+
+// reconnectState counts consecutive unavailable calls.
+type reconnectState struct {
+	failures atomic.Int32
+	count    atomic.Int64
+}
+
+// Reconnects returns how many times the Client has replaced its connection
+// after repeated failures (see WithReconnect).
+func (c *Client) Reconnects() int64 {
+	return c.reconnect.count.Load()
+}
+
+// noteCall feeds the outcome of a call to the reconnection supervisor,
+// which re-dials once enough calls in a row found the platform unavailable.
+func (c *Client) noteCall(err error) {
+	if !errors.Is(err, ErrUnavailable) {
+		if err == nil {
+			c.reconnect.failures.Store(0)
+		}
+		return
+	}
+	c.mu.Lock()
+	after := c.config.reconnectAfter
+	c.mu.Unlock()
+	if after <= 0 || int(c.reconnect.failures.Add(1)) < after {
+		return
+	}
+	c.reconnect.failures.Store(0)
+	c.redial()
+}
+
+// redial dials a fresh connection with the Client's configuration and swaps
+// it in, so calls made from then on, retries included, use it. The old
+// connection is closed, failing calls still in flight on it. Injected
+// connections are never replaced.
+func (c *Client) redial() {
+	c.dialMu.Lock()
+	defer c.dialMu.Unlock()
+
+	c.mu.Lock()
+	sc, ok := c.conn.(*swapConn)
+	if c.closed || !ok {
+		c.mu.Unlock()
+		return
+	}
+	conn, err := c.dial()
+	if err != nil {
+		c.mu.Unlock()
+		return
+	}
+	old := sc.cur.Swap(conn)
+	c.grpcConn = conn
+	c.ready = false
+	c.stopWatch()
+	c.watch.cancel = nil
+	c.startWatch()
+	c.mu.Unlock()
+
+	c.reconnect.count.Add(1)
+	old.Close()
+}
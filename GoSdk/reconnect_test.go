@@ -0,0 +1,94 @@
+package cafesdk_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cafesdk "test/GoSdk"
+)
+
+// pushWhileDown pushes to a stopped server, which must fail as unavailable.
+func pushWhileDown(t *testing.T, client *cafesdk.Client) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := client.Result.PushData(ctx, `{"lost":true}`); !errors.Is(err, cafesdk.ErrUnavailable) {
+		t.Fatalf("PushData while the server is down = %v, want ErrUnavailable", err)
+	}
+}
+
+func TestReconnectAfterServerRestart(t *testing.T) {
+	client, srv := newTestClient(t, cafesdk.WithReconnect(2))
+	ctx := context.Background()
+
+	if _, err := client.Result.PushData(ctx, `{"n":1}`); err != nil {
+		t.Fatalf("PushData before the restart: %v", err)
+	}
+	srv.Stop()
+	pushWhileDown(t, client)
+	if n := client.Reconnects(); n != 0 {
+		t.Errorf("Reconnects() after one failure = %d, want 0", n)
+	}
+	pushWhileDown(t, client)
+	if n := client.Reconnects(); n != 1 {
+		t.Errorf("Reconnects() after two failures = %d, want 1", n)
+	}
+
+	if err := srv.Restart(); err != nil {
+		t.Fatalf("Restart: %v", err)
+	}
+	for i := 2; i <= 3; i++ {
+		if _, err := client.Result.PushData(ctx, `{"n":2}`); err != nil {
+			t.Fatalf("PushData %d after the restart: %v", i, err)
+		}
+	}
+	if got := len(srv.Data()); got != 3 {
+		t.Errorf("server got %d records, want 3", got)
+	}
+	if n := client.Reconnects(); n != 1 {
+		t.Errorf("Reconnects() after recovering = %d, want 1", n)
+	}
+}
+
+func TestReconnectCountResetsOnSuccess(t *testing.T) {
+	client, srv := newTestClient(t, cafesdk.WithReconnect(2))
+	ctx := context.Background()
+
+	client.Result.PushData(ctx, `{}`)
+	srv.Stop()
+	pushWhileDown(t, client)
+	if err := srv.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := client.Result.PushData(ctx, `{}`); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("PushData did not recover after the restart")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	srv.Stop()
+	pushWhileDown(t, client)
+	if n := client.Reconnects(); n != 0 {
+		t.Errorf("Reconnects() = %d, want 0: the failures were not consecutive", n)
+	}
+}
+
+func TestReconnectDisabled(t *testing.T) {
+	client, srv := newTestClient(t, cafesdk.WithReconnect(0))
+
+	client.Result.PushData(context.Background(), `{}`)
+	srv.Stop()
+	for range 4 {
+		pushWhileDown(t, client)
+	}
+	if n := client.Reconnects(); n != 0 {
+		t.Errorf("Reconnects() with reconnection off = %d, want 0", n)
+	}
+}
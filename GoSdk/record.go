@@ -0,0 +1,98 @@
+package cafesdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Record is a table row whose fields keep the order they were first set
+// in. Build one with NewRecord and push it with PushRecord, which checks
+// its keys against the table header.
+type Record struct {
+	keys   []string
+	values map[string]any
+}
+
+// NewRecord returns an empty Record.
+func NewRecord() *Record {
+	return &Record{values: map[string]any{}}
+}
+
+// Set sets the field key to value and returns r for chaining. Setting a key
+// again replaces its value but keeps its position.
+func (r *Record) Set(key string, value any) *Record {
+	if _, ok := r.values[key]; !ok {
+		r.keys = append(r.keys, key)
+	}
+	r.values[key] = value
+	return r
+}
+
+// Keys returns the record's keys in the order they were first set.
+func (r *Record) Keys() []string {
+	return append([]string(nil), r.keys...)
+}
+
+// MarshalJSON encodes r as a JSON object with its fields in order.
+func (r *Record) MarshalJSON() ([]byte, error) {
+	return r.encode(r.keys, EncoderOptions{})
+}
+
+// encode writes the fields named by keys that r has, in that order.
+func (r *Record) encode(keys []string, opts EncoderOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+	for _, key := range keys {
+		value, ok := r.values[key]
+		if !ok {
+			continue
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := opts.marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", key, err)
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return opts.indent(buf.Bytes()), nil
+}
+
+// PushRecord pushes rec after checking that each of its keys is a column of
+// the last header set with SetTableHeader, so a misspelt field fails with
+// an ErrInvalidInput error instead of landing in no column. Fields are sent
+// in header order. It returns ErrNoHeader before a header is set.
+func (r _Result) PushRecord(ctx context.Context, rec *Record) (*Response, error) {
+	keys := r.c.header.headerKeys()
+	if keys == nil {
+		return nil, ErrNoHeader
+	}
+
+	columns := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		columns[key] = true
+	}
+	for _, key := range rec.keys {
+		if !columns[key] {
+			return nil, fmt.Errorf("%w: record key %q is not a table header column", ErrInvalidInput, key)
+		}
+	}
+
+	b, err := rec.encode(keys, r.encoderOptions())
+	if err != nil {
+		return nil, fmt.Errorf("cafesdk: marshal record: %w", err)
+	}
+	return r.PushData(ctx, string(b))
+}
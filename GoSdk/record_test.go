@@ -0,0 +1,81 @@
+package cafesdk_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	cafesdk "test/GoSdk"
+)
+
+// useHeader sets a header with the given text columns.
+func useHeader(t *testing.T, client *cafesdk.Client, keys ...string) {
+	t.Helper()
+	header := make([]*cafesdk.TableHeaderItem, len(keys))
+	for i, key := range keys {
+		header[i] = &cafesdk.TableHeaderItem{Key: key, Label: key, Format: cafesdk.FormatText}
+	}
+	if _, err := client.Result.SetTableHeader(context.Background(), header); err != nil {
+		t.Fatalf("SetTableHeader: %v", err)
+	}
+}
+
+func TestPushRecordHeaderOrder(t *testing.T) {
+	client, srv := newTestClient(t)
+	useHeader(t, client, "title", "price", "url")
+
+	rec := cafesdk.NewRecord().Set("url", "https://example.com").Set("title", "Widget").Set("price", 9.5)
+	if _, err := client.Result.PushRecord(context.Background(), rec); err != nil {
+		t.Fatalf("PushRecord: %v", err)
+	}
+	want := `{"title":"Widget","price":9.5,"url":"https://example.com"}`
+	if got := srv.Data(); !equalStrings(got, []string{want}) {
+		t.Errorf("Data() = %q, want %q", got, want)
+	}
+}
+
+func TestPushRecordMissingFieldsOmitted(t *testing.T) {
+	client, srv := newTestClient(t)
+	useHeader(t, client, "title", "price")
+
+	if _, err := client.Result.PushRecord(context.Background(), cafesdk.NewRecord().Set("price", 1)); err != nil {
+		t.Fatalf("PushRecord: %v", err)
+	}
+	if got := srv.Data(); !equalStrings(got, []string{`{"price":1}`}) {
+		t.Errorf("Data() = %q", got)
+	}
+}
+
+func TestPushRecordUnknownKey(t *testing.T) {
+	client, srv := newTestClient(t)
+	useHeader(t, client, "title", "price")
+
+	rec := cafesdk.NewRecord().Set("title", "Widget").Set("pirce", 9.5)
+	if _, err := client.Result.PushRecord(context.Background(), rec); !errors.Is(err, cafesdk.ErrInvalidInput) {
+		t.Errorf("PushRecord = %v, want ErrInvalidInput for the misspelt key", err)
+	}
+	if n := len(srv.CallsTo(cafesdk.MethodPushData)); n != 0 {
+		t.Errorf("%d pushes, want none", n)
+	}
+}
+
+func TestPushRecordWithoutHeader(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	if _, err := client.Result.PushRecord(context.Background(), cafesdk.NewRecord().Set("a", 1)); !errors.Is(err, cafesdk.ErrNoHeader) {
+		t.Errorf("PushRecord = %v, want ErrNoHeader", err)
+	}
+}
+
+func TestRecordSetKeepsFirstPosition(t *testing.T) {
+	rec := cafesdk.NewRecord().Set("b", 1).Set("a", 2).Set("b", 3)
+
+	if got := rec.Keys(); !equalStrings(got, []string{"b", "a"}) {
+		t.Errorf("Keys() = %q, want [b a]", got)
+	}
+	b, err := json.Marshal(rec)
+	if err != nil || string(b) != `{"b":3,"a":2}` {
+		t.Errorf("json.Marshal = %s, %v", b, err)
+	}
+}
@@ -0,0 +1,75 @@
+package cafesdk
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync/atomic"
+)
+
+// PanicMode selects what Recover does after reporting a panic.
+type PanicMode int32
+
+const (
+	// PanicExit exits with status 2 through the exit function (see
+	// SetExitFunc).
+	PanicExit PanicMode = iota
+	// PanicRepanic resumes panicking with the original value.
+	PanicRepanic
+)
+
+var panicMode atomic.Int32
+
+// SetPanicMode sets the action Recover takes after reporting; the default is
+// PanicExit.
+func SetPanicMode(mode PanicMode) {
+	panicMode.Store(int32(mode))
+}
+
+// Recover reports a panic to the platform. Defer it at the top of run:
+//
+//	defer cafesdk.Recover(ctx)
+//
+// On panic it logs an Error with the value and the stack of the panicking
+// goroutine, reports a failure with code FailureCodePanic, drains writers
+// and buffered logs, closes the connection, then exits or re-panics
+// according to SetPanicMode.
+func Recover(ctx context.Context) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	Log.Errorf(ctx, "panic: %v\n\n%s", r, panicStack())
+	ReportFailure(ctx, FailureCodePanic, fmt.Sprint(r), nil)
+	Close()
+
+	if PanicMode(panicMode.Load()) == PanicRepanic {
+		panic(r)
+	}
+	exit(2)
+}
+
+// panicStack returns the stack of the panicking goroutine from the frame
+// that panicked on. Deferred calls run on the panicking stack, so the frames
+// of the recovering code, of panic and of runtime helpers such as sigpanic
+// are cut; a stack of unexpected shape is returned whole.
+func panicStack() []byte {
+	stack := debug.Stack()
+	lines := bytes.Split(bytes.TrimSuffix(stack, []byte("\n")), []byte("\n"))
+	// lines[0] is the goroutine header; each frame is a function line and
+	// a file line.
+	for i := 1; i+1 < len(lines); i += 2 {
+		if !bytes.HasPrefix(lines[i], []byte("panic(")) {
+			continue
+		}
+		j := i + 2
+		for j+1 < len(lines) && bytes.HasPrefix(lines[j], []byte("runtime.")) {
+			j += 2
+		}
+		trimmed := append([][]byte{lines[0]}, lines[j:]...)
+		return append(bytes.Join(trimmed, []byte("\n")), '\n')
+	}
+	return stack
+}
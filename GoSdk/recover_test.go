@@ -0,0 +1,105 @@
+package cafesdk_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	cafesdk "test/GoSdk"
+	"test/GoSdk/cafesdktest"
+)
+
+// useExitRecorder replaces the exit function until the test ends and
+// returns the status codes it was called with.
+func useExitRecorder(t *testing.T) *[]int {
+	t.Helper()
+	var codes []int
+	cafesdk.SetExitFunc(func(code int) { codes = append(codes, code) })
+	t.Cleanup(func() { cafesdk.SetExitFunc(os.Exit) })
+	return &codes
+}
+
+func explode() {
+	panic("boom")
+}
+
+func explodeNil() {
+	var m map[string]*int
+	_ = *m["x"]
+}
+
+func runRecovered(f func()) {
+	defer cafesdk.Recover(context.Background())
+	f()
+}
+
+// panicLog returns the text of the Error line reporting the panic.
+func panicLog(t *testing.T, srv *cafesdktest.Server) string {
+	t.Helper()
+	for _, line := range srv.Logs() {
+		if line.Level == cafesdk.LevelError && strings.HasPrefix(line.Text, "panic: ") {
+			return line.Text
+		}
+	}
+	t.Fatalf("no panic Error line in %+v", srv.Logs())
+	return ""
+}
+
+// firstFrame returns the function of the first frame of the stack in a
+// panic log.
+func firstFrame(text string) string {
+	_, stack, _ := strings.Cut(text, "\n\n")
+	lines := strings.Split(stack, "\n")
+	if len(lines) < 2 {
+		return ""
+	}
+	return lines[1]
+}
+
+func TestRecoverLogsPanicSite(t *testing.T) {
+	exits := useExitRecorder(t)
+	client, srv := newTestClient(t)
+	cafesdk.UseDefaultClient(t, client)
+
+	runRecovered(explode)
+
+	text := panicLog(t, srv)
+	if !strings.HasPrefix(text, "panic: boom\n\n") {
+		t.Errorf("log = %q, want it to start with the panic value", text)
+	}
+	if frame := firstFrame(text); !strings.HasPrefix(frame, "test/GoSdk_test.explode(") {
+		t.Errorf("stack starts at %q, want the explode frame\n%s", frame, text)
+	}
+	if len(*exits) != 1 || (*exits)[0] != 2 {
+		t.Errorf("exit calls = %v, want [2]", *exits)
+	}
+}
+
+func TestRecoverRuntimeErrorSkipsRuntimeFrames(t *testing.T) {
+	useExitRecorder(t)
+	client, srv := newTestClient(t)
+	cafesdk.UseDefaultClient(t, client)
+
+	runRecovered(explodeNil)
+
+	if frame := firstFrame(panicLog(t, srv)); !strings.HasPrefix(frame, "test/GoSdk_test.explodeNil(") {
+		t.Errorf("stack starts at %q, want the explodeNil frame", frame)
+	}
+}
+
+func TestRecoverRepanics(t *testing.T) {
+	useExitRecorder(t)
+	cafesdk.SetPanicMode(cafesdk.PanicRepanic)
+	t.Cleanup(func() { cafesdk.SetPanicMode(cafesdk.PanicExit) })
+	client, _ := newTestClient(t)
+	cafesdk.UseDefaultClient(t, client)
+
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("recovered %v, want the original panic value", r)
+		}
+	}()
+	runRecovered(explode)
+	t.Error("Recover did not re-panic")
+}
@@ -0,0 +1,13 @@
+package cafesdk
+
+// OK reports whether the platform accepted the call: the code is either
+// unset, as in the responses the SDK returns without an RPC (dry run,
+// skipped duplicates, filtered logs), or an HTTP-style 2xx status. Use the
+// generated GetCode and GetMessage for the details; like them, OK is safe
+// to call on a nil Response, which reports false.
+func (x *Response) OK() bool {
+	if x == nil {
+		return false
+	}
+	return x.Code == 0 || (x.Code >= 200 && x.Code < 300)
+}
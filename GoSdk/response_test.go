@@ -0,0 +1,34 @@
+package cafesdk_test
+
+import (
+	"testing"
+
+	cafesdk "test/GoSdk"
+)
+
+func TestResponseOK(t *testing.T) {
+	tests := []struct {
+		res  *cafesdk.Response
+		want bool
+	}{
+		{nil, false},
+		{&cafesdk.Response{}, true},
+		{&cafesdk.Response{Code: 200, Message: "ok"}, true},
+		{&cafesdk.Response{Code: 204}, true},
+		{&cafesdk.Response{Code: 400, Message: "bad record"}, false},
+		{&cafesdk.Response{Code: 500, Message: "internal"}, false},
+		{&cafesdk.Response{Code: 302}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.res.OK(); got != tt.want {
+			t.Errorf("%+v.OK() = %v, want %v", tt.res, got, tt.want)
+		}
+	}
+	var nilRes *cafesdk.Response
+	if nilRes.GetCode() != 0 || nilRes.GetMessage() != "" {
+		t.Error("getters on a nil Response are not zero")
+	}
+	if res := (&cafesdk.Response{Code: 400, Message: "bad record"}); res.GetMessage() != "bad record" || res.GetCode() != 400 {
+		t.Errorf("getters = %d %q", res.GetCode(), res.GetMessage())
+	}
+}
@@ -0,0 +1,155 @@
+package cafesdk
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// IdempotencyKeyHeader is the gRPC metadata key carrying the key sent by
+// PushDataIdempotent.
+const IdempotencyKeyHeader = "x-idempotency-key"
+
+// ErrNoHeader is returned by PushRow before a table header has been set.
+var ErrNoHeader = errors.New("cafesdk: no table header set")
+
+// JSONValidation selects how PushData checks records before sending them.
+type JSONValidation int32
+
+const (
+	// ValidateNone sends records unchecked; it is the default.
+	ValidateNone JSONValidation = iota
+	// ValidateJSON rejects records that are not valid JSON.
+	ValidateJSON
+	// ValidateObject rejects records that are not a JSON object.
+	ValidateObject
+)
+
+var jsonValidation atomic.Int32
+
+// SetJSONValidation makes PushData, and the helpers built on it, check each
+// record locally and fail with an ErrInvalidInput error instead of making
+// the RPC. It is off by default because records built with json.Marshal
+// would be parsed twice.
+func SetJSONValidation(v JSONValidation) {
+	jsonValidation.Store(int32(v))
+}
+
+func validateRecord(jsonString string) error {
+	mode := JSONValidation(jsonValidation.Load())
+	if mode == ValidateNone && strictMode.Load() {
+		mode = ValidateJSON
+	}
+	if mode == ValidateNone {
+		return nil
+	}
+	if !json.Valid([]byte(jsonString)) {
+		return fmt.Errorf("%w: record is not valid JSON: %q", ErrInvalidInput, snippet(jsonString))
+	}
+	if mode == ValidateObject && !strings.HasPrefix(strings.TrimSpace(jsonString), "{") {
+		return fmt.Errorf("%w: record is not a JSON object: %q", ErrInvalidInput, snippet(jsonString))
+	}
+	return nil
+}
+
+// PushedCount returns how many records the Client has pushed successfully,
+// whether through PushData, PushBatch or the helpers built on them. The
+// count starts at zero and is never reset.
+func (r _Result) PushedCount() int64 {
+	return r.c.pushed.Load()
+}
+
+// BatchError reports a PushBatch that stopped part way. Records before
+// Accepted were delivered; the record at index Accepted failed with Err.
+type BatchError struct {
+	Accepted int
+	Err      error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("cafesdk: batch push stopped after %d records: %v", e.Accepted, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// PushBatch pushes records in order and returns the response for the last
+// one. The platform accepts a single record per PushData RPC, so records are
+// sent one after another; on failure the returned *BatchError tells how many
+// were accepted. An empty batch makes no RPC and returns a nil response.
+func (r _Result) PushBatch(ctx context.Context, items []string) (*Response, error) {
+	return r.pushBatch(ctx, items, true)
+}
+
+// pushBatch is PushBatch, retrying each record under the SetRetryPolicy
+// policy only when retry is true.
+func (r _Result) pushBatch(ctx context.Context, items []string, retry bool) (*Response, error) {
+	var res *Response
+	for i, item := range items {
+		var err error
+		if res, err = r.push(ctx, "", item, retry); err != nil {
+			return nil, &BatchError{Accepted: i, Err: err}
+		}
+	}
+	return res, nil
+}
+
+// Push marshals v to JSON and pushes it as one record. Marshal errors are
+// returned without making an RPC. See SetKeyNormalizer for renaming map keys
+// and SetEncoderOptions for encoding.
+func (r _Result) Push(ctx context.Context, v any) (*Response, error) {
+	v, err := r.normalizeKeys(v)
+	if err != nil {
+		return nil, err
+	}
+	return r.pushValue(ctx, v)
+}
+
+func (r _Result) pushValue(ctx context.Context, v any) (*Response, error) {
+	opts := r.encoderOptions()
+	b, err := opts.marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("cafesdk: marshal record: %w", err)
+	}
+	return r.PushData(ctx, string(opts.indent(b)))
+}
+
+// PushRow pushes values as one record whose keys are the columns of the last
+// header set with SetTableHeader, in order. The number of values must match
+// the number of columns.
+func (r _Result) PushRow(ctx context.Context, values []string) (*Response, error) {
+	keys := r.c.header.headerKeys()
+	if keys == nil {
+		return nil, ErrNoHeader
+	}
+	if len(values) != len(keys) {
+		return nil, fmt.Errorf("cafesdk: row has %d values but the table header has %d columns", len(values), len(keys))
+	}
+
+	record := make(map[string]string, len(keys))
+	for i, key := range keys {
+		record[key] = values[i]
+	}
+	return r.pushValue(ctx, record)
+}
+
+// PushDataIdempotent pushes jsonString with key sent as gRPC metadata, so a
+// platform that deduplicates on it stores the record once even when a retry
+// (see SetRetryPolicy) resends a push whose acknowledgement was lost. Every
+// attempt carries the same key. An empty key defaults to the SHA-256 of
+// jsonString, which makes identical records share a key.
+func (r _Result) PushDataIdempotent(ctx context.Context, key, jsonString string) (*Response, error) {
+	if key == "" {
+		sum := sha256.Sum256([]byte(jsonString))
+		key = hex.EncodeToString(sum[:])
+	}
+	return r.PushData(metadata.AppendToOutgoingContext(ctx, IdempotencyKeyHeader, key), jsonString)
+}
@@ -0,0 +1,310 @@
+package cafesdk_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+
+	cafesdk "test/GoSdk"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func records(n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = fmt.Sprintf(`{"n":%d}`, i)
+	}
+	return out
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPushBatchEmpty(t *testing.T) {
+	client, srv := newTestClient(t)
+
+	res, err := client.Result.PushBatch(context.Background(), nil)
+	if err != nil || res != nil {
+		t.Fatalf("PushBatch(nil) = %v, %v; want nil, nil", res, err)
+	}
+	if n := len(srv.Calls()); n != 0 {
+		t.Errorf("empty batch made %d RPCs", n)
+	}
+}
+
+func TestPushBatchSingle(t *testing.T) {
+	client, srv := newTestClient(t)
+
+	res, err := client.Result.PushBatch(context.Background(), []string{`{"n":0}`})
+	if err != nil || !res.OK() {
+		t.Fatalf("PushBatch = %v, %v", res, err)
+	}
+	if got := srv.Data(); !equalStrings(got, []string{`{"n":0}`}) {
+		t.Errorf("server data = %q", got)
+	}
+}
+
+func TestPushBatchLarge(t *testing.T) {
+	client, srv := newTestClient(t)
+	batch := records(250) // more than a PushChunks chunk
+
+	if _, err := client.Result.PushBatch(context.Background(), batch); err != nil {
+		t.Fatalf("PushBatch: %v", err)
+	}
+	if got := srv.Data(); !equalStrings(got, batch) {
+		t.Errorf("server got %d records, want all 250 in order", len(got))
+	}
+	if n := client.Result.PushedCount(); n != 250 {
+		t.Errorf("PushedCount = %d, want 250", n)
+	}
+}
+
+func TestPushBatchReportsAccepted(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.FailFunc(cafesdk.MethodPushData, func(call int) error {
+		if call == 4 {
+			return status.Error(codes.InvalidArgument, "bad record")
+		}
+		return nil
+	})
+
+	_, err := client.Result.PushBatch(context.Background(), records(10))
+	var batchErr *cafesdk.BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("PushBatch = %v, want *BatchError", err)
+	}
+	if batchErr.Accepted != 3 {
+		t.Errorf("Accepted = %d, want 3", batchErr.Accepted)
+	}
+	if status.Code(batchErr.Err) != codes.InvalidArgument {
+		t.Errorf("Err = %v, want the InvalidArgument status", batchErr.Err)
+	}
+	if n := len(srv.Data()); n != 3 {
+		t.Errorf("server stored %d records, want 3", n)
+	}
+}
+
+func TestPushStruct(t *testing.T) {
+	client, srv := newTestClient(t)
+	type product struct {
+		Name  string  `json:"name"`
+		Price float64 `json:"price"`
+	}
+
+	if _, err := client.Result.Push(context.Background(), product{Name: "pen", Price: 1.5}); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	if got := srv.Data(); !equalStrings(got, []string{`{"name":"pen","price":1.5}`}) {
+		t.Errorf("server data = %q", got)
+	}
+}
+
+func TestPushMap(t *testing.T) {
+	client, srv := newTestClient(t)
+
+	if _, err := client.Result.Push(context.Background(), map[string]any{"b": 2, "a": "x"}); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	if got := srv.Data(); !equalStrings(got, []string{`{"a":"x","b":2}`}) {
+		t.Errorf("server data = %q", got)
+	}
+}
+
+func TestPushMarshalError(t *testing.T) {
+	client, srv := newTestClient(t)
+	v := struct{ C chan int }{C: make(chan int)}
+
+	_, err := client.Result.Push(context.Background(), v)
+	var typeErr *json.UnsupportedTypeError
+	if !errors.As(err, &typeErr) {
+		t.Fatalf("Push = %v, want a *json.UnsupportedTypeError", err)
+	}
+	if n := len(srv.Calls()); n != 0 {
+		t.Errorf("failed marshal made %d RPCs", n)
+	}
+}
+
+func TestPushedCountConcurrent(t *testing.T) {
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+	srv.FailFunc(cafesdk.MethodPushData, func(call int) error {
+		if call%10 == 0 {
+			return status.Error(codes.InvalidArgument, "rejected")
+		}
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 25 {
+				if i%5 == 0 {
+					client.Result.PushBatch(ctx, []string{`{"g":` + strconv.Itoa(g) + `}`, `{}`})
+				} else {
+					client.Result.PushData(ctx, `{}`)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n, delivered := client.Result.PushedCount(), int64(len(srv.Data())); n != delivered {
+		t.Errorf("PushedCount = %d, want the %d records the server accepted", n, delivered)
+	}
+}
+
+// Run with -race: 50 goroutines push 100 records each through PushData,
+// PushBatch and a shared Writer.
+func TestResultConcurrentPushes(t *testing.T) {
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+	w := client.Result.NewWriter(ctx, cafesdk.WriterOptions{BatchSize: 64})
+
+	var wg sync.WaitGroup
+	for g := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := func(i int) string { return fmt.Sprintf(`{"g":%d,"i":%d}`, g, i) }
+			for i := 0; i < 100; i += 2 {
+				var err error
+				switch g % 3 {
+				case 0:
+					if _, err = client.Result.PushData(ctx, rec(i)); err == nil {
+						_, err = client.Result.PushData(ctx, rec(i+1))
+					}
+				case 1:
+					_, err = client.Result.PushBatch(ctx, []string{rec(i), rec(i + 1)})
+				default:
+					if err = w.Write(rec(i)); err == nil {
+						err = w.Write(rec(i + 1))
+					}
+				}
+				if err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err := w.Close(); err != nil {
+		t.Fatalf("Writer.Close: %v", err)
+	}
+
+	got := srv.Data()
+	seen := make(map[string]bool, len(got))
+	for _, record := range got {
+		seen[record] = true
+	}
+	if len(got) != 5000 || len(seen) != 5000 {
+		t.Errorf("server received %d records, %d distinct; want 5000", len(got), len(seen))
+	}
+	if n := client.Result.PushedCount(); n != 5000 {
+		t.Errorf("PushedCount = %d, want 5000", n)
+	}
+}
+
+func TestPushRow(t *testing.T) {
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+	header, err := client.Result.NewHeaderBuilder().Text("title", "Title").Link("url", "URL").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Result.SetTableHeader(ctx, header); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Result.PushRow(ctx, []string{"Pen", "https://example.com/pen"}); err != nil {
+		t.Fatalf("PushRow: %v", err)
+	}
+	if got := srv.Data(); !equalStrings(got, []string{`{"title":"Pen","url":"https://example.com/pen"}`}) {
+		t.Errorf("server data = %q", got)
+	}
+}
+
+func TestPushRowCountMismatch(t *testing.T) {
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+	header := []*cafesdk.TableHeaderItem{{Key: "title", Label: "Title", Format: cafesdk.FormatText}}
+	if _, err := client.Result.SetTableHeader(ctx, header); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, values := range [][]string{{}, {"a", "b"}} {
+		if _, err := client.Result.PushRow(ctx, values); err == nil {
+			t.Errorf("PushRow(%q) succeeded against a one-column header", values)
+		}
+	}
+	if n := len(srv.Data()); n != 0 {
+		t.Errorf("mismatched rows pushed %d records", n)
+	}
+}
+
+func TestPushRowWithoutHeader(t *testing.T) {
+	client, srv := newTestClient(t)
+
+	if _, err := client.Result.PushRow(context.Background(), []string{"a"}); !errors.Is(err, cafesdk.ErrNoHeader) {
+		t.Errorf("PushRow = %v, want ErrNoHeader", err)
+	}
+	if n := len(srv.Calls()); n != 0 {
+		t.Errorf("PushRow without a header made %d RPCs", n)
+	}
+}
+
+func TestJSONValidationModes(t *testing.T) {
+	records := []struct {
+		name, json string
+	}{
+		{"object", `{"a":1}`},
+		{"array", `[1,2]`},
+		{"invalid", `{"a":`},
+	}
+	tests := []struct {
+		mode cafesdk.JSONValidation
+		ok   []bool // per record above
+	}{
+		{cafesdk.ValidateNone, []bool{true, true, true}},
+		{cafesdk.ValidateJSON, []bool{true, true, false}},
+		{cafesdk.ValidateObject, []bool{true, false, false}},
+	}
+	t.Cleanup(func() { cafesdk.SetJSONValidation(cafesdk.ValidateNone) })
+
+	for _, tt := range tests {
+		cafesdk.SetJSONValidation(tt.mode)
+		for i, r := range records {
+			client, srv := newTestClient(t)
+			_, err := client.Result.PushData(context.Background(), r.json)
+			if tt.ok[i] {
+				if err != nil {
+					t.Errorf("mode %d, %s: PushData = %v, want it sent", tt.mode, r.name, err)
+				}
+				continue
+			}
+			if !errors.Is(err, cafesdk.ErrInvalidInput) {
+				t.Errorf("mode %d, %s: PushData = %v, want ErrInvalidInput", tt.mode, r.name, err)
+			}
+			if n := len(srv.Calls()); n != 0 {
+				t.Errorf("mode %d, %s: rejected record made %d RPCs", tt.mode, r.name, n)
+			}
+		}
+	}
+}
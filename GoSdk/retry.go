@@ -0,0 +1,152 @@
+package cafesdk
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+// RetryPolicy controls how Result calls, and functions passed to Retry, are
+// retried on transient errors.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	// Values below 2 disable retries.
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry; it grows by Multiplier
+	// on each subsequent retry up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Multiplier is the growth factor between waits; values of 1 or less
+	// select 2.
+	Multiplier float64
+	// Jitter randomizes each wait by up to this fraction (0 to 1) of it.
+	Jitter float64
+	// Retryable reports whether an error is worth retrying. Nil selects
+	// IsTransient, which accepts Unavailable and DeadlineExceeded.
+	Retryable func(error) bool
+}
+
+// DefaultRetryPolicy is a reasonable policy for SetRetryPolicy.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   200 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+	Jitter:      0.2,
+}
+
+var retryPolicy atomic.Pointer[RetryPolicy]
+
+// SetRetryPolicy sets the policy every Result call honors. Retries are off
+// until a policy is set. Unless the policy sets Retryable, only Unavailable
+// and DeadlineExceeded are retried; other errors, such as InvalidArgument,
+// are returned immediately.
+func SetRetryPolicy(p RetryPolicy) {
+	retryPolicy.Store(&p)
+}
+
+func currentRetryPolicy() RetryPolicy {
+	if p := retryPolicy.Load(); p != nil {
+		return *p
+	}
+	return RetryPolicy{}
+}
+
+// isZero reports whether p is the zero RetryPolicy, which some helpers
+// replace with DefaultRetryPolicy.
+func (p RetryPolicy) isZero() bool {
+	return p.MaxAttempts == 0 && p.BaseDelay == 0 && p.MaxDelay == 0 &&
+		p.Multiplier == 0 && p.Jitter == 0 && p.Retryable == nil
+}
+
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	m := p.Multiplier
+	if m <= 1 {
+		m = 2
+	}
+	d := p.BaseDelay
+	for i := 1; i < retry && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+		d = time.Duration(float64(d) * m)
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(d))
+	}
+	return d
+}
+
+type attemptKey struct{}
+
+// AttemptFromContext returns the attempt number, starting at 1, of the SDK
+// retry loop that made ctx, or 0 when ctx was not made by one. Interceptors
+// installed with WithUnaryInterceptor see it on every RPC the SDK retries,
+// and so does a transport on each request sent by DoWithRetry.
+func AttemptFromContext(ctx context.Context) int {
+	n, _ := ctx.Value(attemptKey{}).(int)
+	return n
+}
+
+func withAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+// Retry calls fn until it succeeds, returns an error p.Retryable rejects,
+// or p.MaxAttempts attempts have been made, waiting with exponential
+// backoff between attempts. It returns nil or fn's last error. When ctx is
+// done before the next attempt, that error is joined with ctx.Err(), so
+// errors.Is reports both. The SDK retries its own calls with it.
+func Retry(ctx context.Context, p RetryPolicy, fn func() error) error {
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsTransient
+	}
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		if ctx.Err() == nil && Sleep(ctx, p.backoff(attempt)) == nil {
+			continue
+		}
+		if errors.Is(err, ctx.Err()) {
+			return err
+		}
+		return errors.Join(ctx.Err(), err)
+	}
+}
+
+// withRetry runs call under Retry, giving each attempt a context that
+// carries its number and ends when c shuts down.
+func withRetry[T any](ctx context.Context, c *Client, p RetryPolicy, call func(context.Context) (T, error)) (T, error) {
+	ctx, cancel := c.derive(ctx)
+	defer cancel()
+
+	var res T
+	attempt := 0
+	err := Retry(ctx, p, func() error {
+		attempt++
+		var err error
+		res, err = call(withAttempt(ctx, attempt))
+		return err
+	})
+	return res, err
+}
+
+// Sleep waits for d, returning early with ctx.Err() if ctx is done first.
+// Unlike time.Sleep it lets a cancelled run stop waiting; the SDK uses it
+// for every retry backoff. It waits on the Clock set with SetClock.
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := currentClock().NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C():
+		return nil
+	}
+}
@@ -0,0 +1,271 @@
+package cafesdk_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	cafesdk "test/GoSdk"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// useRetryPolicy sets p as the retry policy until the test ends.
+func useRetryPolicy(t *testing.T, p cafesdk.RetryPolicy) {
+	t.Helper()
+	cafesdk.SetRetryPolicy(p)
+	t.Cleanup(func() { cafesdk.SetRetryPolicy(cafesdk.RetryPolicy{}) })
+}
+
+func TestPushDataRetriesTransientErrors(t *testing.T) {
+	useRetryPolicy(t, cafesdk.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+	client, srv := newTestClient(t)
+	srv.FailFunc(cafesdk.MethodPushData, func(call int) error {
+		if call <= 2 {
+			return status.Error(codes.Unavailable, "restarting")
+		}
+		return nil
+	})
+
+	if _, err := client.Result.PushData(context.Background(), `{"n":1}`); err != nil {
+		t.Fatalf("PushData: %v", err)
+	}
+	if n := len(srv.CallsTo(cafesdk.MethodPushData)); n != 3 {
+		t.Errorf("PushData made %d attempts, want 3", n)
+	}
+	if got := srv.Data(); !equalStrings(got, []string{`{"n":1}`}) {
+		t.Errorf("server data = %q, want the record once", got)
+	}
+}
+
+func TestPushDataDoesNotRetryInvalidArgument(t *testing.T) {
+	useRetryPolicy(t, cafesdk.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+	client, srv := newTestClient(t)
+	srv.Fail(cafesdk.MethodPushData, status.Error(codes.InvalidArgument, "bad record"))
+
+	_, err := client.Result.PushData(context.Background(), `{"n":1}`)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("PushData = %v, want InvalidArgument", err)
+	}
+	if n := len(srv.CallsTo(cafesdk.MethodPushData)); n != 1 {
+		t.Errorf("PushData made %d attempts, want 1", n)
+	}
+}
+
+func TestIdempotencyKeyReusedAcrossRetries(t *testing.T) {
+	useRetryPolicy(t, cafesdk.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+	client, srv := newTestClient(t)
+	srv.FailFunc(cafesdk.MethodPushData, func(call int) error {
+		if call <= 2 {
+			return status.Error(codes.Unavailable, "ack lost")
+		}
+		return nil
+	})
+	ctx := context.Background()
+
+	if _, err := client.Result.PushDataIdempotent(ctx, "order-42", `{"n":1}`); err != nil {
+		t.Fatalf("PushDataIdempotent: %v", err)
+	}
+	calls := srv.CallsTo(cafesdk.MethodPushData)
+	if len(calls) != 3 {
+		t.Fatalf("made %d attempts, want 3", len(calls))
+	}
+	for i, c := range calls {
+		if got := c.Metadata.Get(cafesdk.IdempotencyKeyHeader); len(got) != 1 || got[0] != "order-42" {
+			t.Errorf("attempt %d key = %q, want [order-42]", i+1, got)
+		}
+	}
+}
+
+func TestIdempotencyKeyDefaultsToContentHash(t *testing.T) {
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+
+	for _, record := range []string{`{"n":1}`, `{"n":1}`, `{"n":2}`} {
+		if _, err := client.Result.PushDataIdempotent(ctx, "", record); err != nil {
+			t.Fatalf("PushDataIdempotent(%s): %v", record, err)
+		}
+	}
+	var keys []string
+	for _, c := range srv.CallsTo(cafesdk.MethodPushData) {
+		keys = append(keys, c.Metadata.Get(cafesdk.IdempotencyKeyHeader)...)
+	}
+	if len(keys) != 3 || keys[0] == "" || keys[0] != keys[1] || keys[1] == keys[2] {
+		t.Errorf("keys = %q, want identical records to share a non-empty key", keys)
+	}
+}
+
+func TestSleepReturnsPromptlyOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := cafesdk.Sleep(ctx, time.Hour)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Sleep = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Sleep returned after %v, want soon after the cancel", elapsed)
+	}
+}
+
+func TestSleepWaitsOutDuration(t *testing.T) {
+	start := time.Now()
+	if err := cafesdk.Sleep(context.Background(), 20*time.Millisecond); err != nil {
+		t.Errorf("Sleep = %v, want nil", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("Sleep returned after %v, want at least 20ms", elapsed)
+	}
+}
+
+func TestAttemptNumberSeenByLogHook(t *testing.T) {
+	useRetryPolicy(t, cafesdk.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+	cafesdk.SetLogAttempt(true)
+	t.Cleanup(func() { cafesdk.SetLogAttempt(false) })
+
+	// The hook logs every push attempt with the context of the attempt.
+	var client *cafesdk.Client
+	var seen []int
+	hook := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if method == "/cafesdk.Result/PushData" {
+			seen = append(seen, cafesdk.AttemptFromContext(ctx))
+			client.Log.InfoKV(ctx, "pushing", map[string]any{"method": method})
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	client, srv := newTestClient(t, cafesdk.WithUnaryInterceptor(hook))
+	srv.FailFunc(cafesdk.MethodPushData, func(call int) error {
+		if call <= 2 {
+			return status.Error(codes.Unavailable, "restarting")
+		}
+		return nil
+	})
+
+	if _, err := client.Result.PushData(context.Background(), `{"n":1}`); err != nil {
+		t.Fatalf("PushData: %v", err)
+	}
+	if want := []int{1, 2, 3}; !slices.Equal(seen, want) {
+		t.Errorf("hook saw attempts %v, want %v", seen, want)
+	}
+	logs := srv.Logs()
+	if len(logs) != 3 {
+		t.Fatalf("Logs() = %+v, want a line per attempt", logs)
+	}
+	for i, l := range logs {
+		_, fields := decodeLog(t, l.Text)
+		if got := fields["attempt"]; got != float64(i+1) {
+			t.Errorf("log %d attempt = %v, want %d", i, got, i+1)
+		}
+	}
+}
+
+func TestLogAttemptOff(t *testing.T) {
+	var client *cafesdk.Client
+	hook := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if method == "/cafesdk.Result/PushData" {
+			client.Log.InfoKV(ctx, "pushing", nil)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	client, srv := newTestClient(t, cafesdk.WithUnaryInterceptor(hook))
+
+	if _, err := client.Result.PushData(context.Background(), `{}`); err != nil {
+		t.Fatalf("PushData: %v", err)
+	}
+	logs := srv.Logs()
+	if len(logs) != 1 {
+		t.Fatalf("Logs() = %+v, want 1 line", logs)
+	}
+	if _, fields := decodeLog(t, logs[0].Text); fields != nil {
+		t.Errorf("fields = %v with SetLogAttempt off, want none", fields)
+	}
+}
+
+var errFlaky = status.Error(codes.Unavailable, "flaky")
+
+func TestRetrySucceedsAfterFailures(t *testing.T) {
+	calls := 0
+	err := cafesdk.Retry(context.Background(), cafesdk.RetryPolicy{MaxAttempts: 5, BaseDelay: time.Millisecond}, func() error {
+		calls++
+		if calls < 3 {
+			return errFlaky
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("Retry = %v after %d calls, want nil after 3", err, calls)
+	}
+}
+
+func TestRetryExhausted(t *testing.T) {
+	calls := 0
+	err := cafesdk.Retry(context.Background(), cafesdk.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}, func() error {
+		calls++
+		return fmt.Errorf("attempt %d: %w", calls, errFlaky)
+	})
+	if calls != 3 || err == nil || err.Error() != "attempt 3: "+errFlaky.Error() {
+		t.Errorf("Retry = %v after %d calls, want the third attempt's error", err, calls)
+	}
+}
+
+func TestRetryStopsOnNonRetryable(t *testing.T) {
+	permanent := errors.New("permanent")
+	calls := 0
+	policy := cafesdk.RetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   time.Millisecond,
+		Retryable:   func(err error) bool { return !errors.Is(err, permanent) },
+	}
+	err := cafesdk.Retry(context.Background(), policy, func() error {
+		calls++
+		if calls == 2 {
+			return permanent
+		}
+		return errFlaky
+	})
+	if !errors.Is(err, permanent) || calls != 2 {
+		t.Errorf("Retry = %v after %d calls, want permanent after 2", err, calls)
+	}
+
+	calls = 0
+	err = cafesdk.Retry(context.Background(), cafesdk.RetryPolicy{MaxAttempts: 5}, func() error {
+		calls++
+		return status.Error(codes.InvalidArgument, "bad")
+	})
+	if status.Code(err) != codes.InvalidArgument || calls != 1 {
+		t.Errorf("default Retryable: Retry = %v after %d calls, want InvalidArgument after 1", err, calls)
+	}
+}
+
+func TestRetryCancelledDuringBackoff(t *testing.T) {
+	clock := useFakeClock(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	done := make(chan error, 1)
+	go func() {
+		done <- cafesdk.Retry(ctx, cafesdk.RetryPolicy{MaxAttempts: 5, BaseDelay: time.Hour}, func() error {
+			calls++
+			return errFlaky
+		})
+	}()
+	clock.BlockUntil(1)
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) || !errors.Is(err, errFlaky) {
+			t.Errorf("Retry = %v, want both context.Canceled and the last error", err)
+		}
+		if calls != 1 {
+			t.Errorf("%d calls, want 1", calls)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Retry did not return after the context was cancelled")
+	}
+}
@@ -0,0 +1,169 @@
+package cafesdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// RedactedValue replaces the value of a redacted field in RPC debug output.
+const RedactedValue = "***"
+
+const defaultRPCDebugMaxBytes = 1024
+
+// DefaultRedactFields are the field names redacted when
+// RPCDebugOptions.Redact is nil.
+var DefaultRedactFields = []string{"password", "passwd", "secret", "token", "apiKey", "authorization", "cookie", "proxyAuth"}
+
+// RPCDebugOptions configure SetRPCDebug.
+type RPCDebugOptions struct {
+	// Writer receives one line per RPC; os.Stderr if nil. The lines do not
+	// go through Log, which would log its own RPCs.
+	Writer io.Writer
+	// Redact lists the field names whose values are shown as RedactedValue,
+	// at any depth, including inside records and log messages that are
+	// themselves JSON. Names match ignoring case, "_" and "-", so "apiKey"
+	// also covers "api_key". Nil means DefaultRedactFields; pass an empty,
+	// non-nil slice to redact nothing.
+	Redact []string
+	// MaxBytes truncates each request and response body past this many
+	// bytes; 1024 if zero or less.
+	MaxBytes int
+}
+
+type rpcDebug struct {
+	mu       sync.Mutex // serializes writes
+	w        io.Writer
+	redact   map[string]bool
+	maxBytes int
+}
+
+var activeRPCDebug atomic.Pointer[rpcDebug]
+
+// SetRPCDebug writes the method, latency, error and the request and response
+// of every RPC, as JSON with sensitive fields redacted and long bodies
+// truncated, for debugging the platform integration. It applies to every
+// Client and may be called at any time; nil, the default, turns it off. It
+// is meant for development: even redacted, payloads can hold personal data.
+func SetRPCDebug(opts *RPCDebugOptions) {
+	if opts == nil {
+		activeRPCDebug.Store(nil)
+		return
+	}
+	d := &rpcDebug{w: opts.Writer, maxBytes: opts.MaxBytes, redact: map[string]bool{}}
+	if d.w == nil {
+		d.w = os.Stderr
+	}
+	if d.maxBytes <= 0 {
+		d.maxBytes = defaultRPCDebugMaxBytes
+	}
+	names := opts.Redact
+	if names == nil {
+		names = DefaultRedactFields
+	}
+	for _, name := range names {
+		d.redact[redactKey(name)] = true
+	}
+	activeRPCDebug.Store(d)
+}
+
+func rpcDebugInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	d := activeRPCDebug.Load()
+	if d == nil {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	start := clockNow()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	elapsed := clockSince(start)
+
+	line := fmt.Sprintf("rpc %s %s req=%s", methodName(method), elapsed, d.body(req))
+	if err != nil {
+		line += " err=" + err.Error()
+	} else {
+		line += " resp=" + d.body(reply)
+	}
+	d.mu.Lock()
+	fmt.Fprintln(d.w, line)
+	d.mu.Unlock()
+	return err
+}
+
+// body renders msg as redacted, truncated JSON.
+func (d *rpcDebug) body(msg any) string {
+	m, ok := msg.(proto.Message)
+	if !ok {
+		return fmt.Sprintf("<%T>", msg)
+	}
+	b, err := protojson.Marshal(m)
+	if err != nil {
+		return fmt.Sprintf("<%T: %v>", msg, err)
+	}
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return fmt.Sprintf("<%T: %v>", msg, err)
+	}
+	if b, err = json.Marshal(d.redactValue(v)); err != nil {
+		return fmt.Sprintf("<%T: %v>", msg, err)
+	}
+	return d.truncate(string(b))
+}
+
+// redactValue replaces the redacted fields of v. Strings holding a JSON
+// object or array, such as pushed records, are redacted inside as well.
+func (d *rpcDebug) redactValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if d.redact[redactKey(key)] {
+				v[key] = RedactedValue
+			} else {
+				v[key] = d.redactValue(value)
+			}
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = d.redactValue(value)
+		}
+	case string:
+		s := strings.TrimSpace(v)
+		if !strings.HasPrefix(s, "{") && !strings.HasPrefix(s, "[") {
+			return v
+		}
+		var nested any
+		if json.Unmarshal([]byte(s), &nested) != nil {
+			return v
+		}
+		b, err := json.Marshal(d.redactValue(nested))
+		if err != nil {
+			return RedactedValue
+		}
+		return string(b)
+	}
+	return v
+}
+
+func (d *rpcDebug) truncate(s string) string {
+	if len(s) <= d.maxBytes {
+		return s
+	}
+	cut := d.maxBytes
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s...(%d bytes)", s[:cut], len(s))
+}
+
+func redactKey(name string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(name))
+}
@@ -0,0 +1,118 @@
+package cafesdk_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	cafesdk "test/GoSdk"
+)
+
+// syncBuffer is a bytes.Buffer safe for the debug writer and the test to
+// share.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func useRPCDebug(t *testing.T, opts cafesdk.RPCDebugOptions) *syncBuffer {
+	t.Helper()
+	out := &syncBuffer{}
+	opts.Writer = out
+	cafesdk.SetRPCDebug(&opts)
+	t.Cleanup(func() { cafesdk.SetRPCDebug(nil) })
+	return out
+}
+
+func TestRPCDebugRedactsFields(t *testing.T) {
+	out := useRPCDebug(t, cafesdk.RPCDebugOptions{})
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+
+	record := `{"user":"ann","password":"hunter2","auth":{"api_key":"k-123"},"items":[{"Token":"t-456"}]}`
+	if _, err := client.Result.PushData(ctx, record); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Log.Info(ctx, `{"secret":"s-789","step":1}`); err != nil {
+		t.Fatal(err)
+	}
+
+	got := out.String()
+	for _, leaked := range []string{"hunter2", "k-123", "t-456", "s-789"} {
+		if strings.Contains(got, leaked) {
+			t.Errorf("debug output leaks %q:\n%s", leaked, got)
+		}
+	}
+	for _, want := range []string{`\"password\":\"***\"`, `\"api_key\":\"***\"`, `\"Token\":\"***\"`, `\"secret\":\"***\"`, `\"user\":\"ann\"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("debug output lacks %s:\n%s", want, got)
+		}
+	}
+	if !regexp.MustCompile(`(?m)^rpc \S*PushData \S+ req=.* resp=`).MatchString(got) {
+		t.Errorf("debug output lacks the PushData line with latency and response:\n%s", got)
+	}
+}
+
+func TestRPCDebugCustomRedactList(t *testing.T) {
+	out := useRPCDebug(t, cafesdk.RPCDebugOptions{Redact: []string{"email"}})
+	client, _ := newTestClient(t)
+
+	if _, err := client.Result.PushData(context.Background(), `{"e-mail":"a@b.example","password":"visible"}`); err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+	if strings.Contains(got, "a@b.example") || !strings.Contains(got, "visible") {
+		t.Errorf("Redact [email]: output %s, want only the e-mail hidden", got)
+	}
+}
+
+func TestRPCDebugTruncatesLongBodies(t *testing.T) {
+	out := useRPCDebug(t, cafesdk.RPCDebugOptions{MaxBytes: 64})
+	client, _ := newTestClient(t)
+
+	record := fmt.Sprintf(`{"body":%q}`, strings.Repeat("x", 500))
+	if _, err := client.Result.PushData(context.Background(), record); err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+	m := regexp.MustCompile(`req=(.*?)\.\.\.\((\d+) bytes\)`).FindStringSubmatch(got)
+	if m == nil {
+		t.Fatalf("debug output not truncated:\n%s", got)
+	}
+	if len(m[1]) != 64 {
+		t.Errorf("kept %d bytes of the request, want 64", len(m[1]))
+	}
+	full, _ := json.Marshal(map[string]string{"jsonString": record})
+	if m[2] != strconv.Itoa(len(full)) {
+		t.Errorf("reported %s bytes, want the full request size %d", m[2], len(full))
+	}
+}
+
+func TestRPCDebugOff(t *testing.T) {
+	out := useRPCDebug(t, cafesdk.RPCDebugOptions{})
+	cafesdk.SetRPCDebug(nil)
+	client, _ := newTestClient(t)
+
+	client.Result.PushData(context.Background(), `{}`)
+	if got := out.String(); got != "" {
+		t.Errorf("debug output after SetRPCDebug(nil) = %q", got)
+	}
+}
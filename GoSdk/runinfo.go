@@ -0,0 +1,89 @@
+package cafesdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Environment variables the platform sets for each run.
+const (
+	runIDEnv        = "CAFE_RUN_ID"
+	actorIDEnv      = "CAFE_ACTOR_ID"
+	runStartedAtEnv = "CAFE_RUN_STARTED_AT"
+	runDeadlineEnv  = "CAFE_RUN_DEADLINE"
+)
+
+// ErrNoRunInfo is returned by RunInfo outside a platform run.
+var ErrNoRunInfo = errors.New("cafesdk: run metadata not available (" + runIDEnv + " is not set)")
+
+// RunMetadata identifies the current platform run.
+type RunMetadata struct {
+	RunID   string
+	ActorID string
+	// StartedAt is zero when the platform did not report it.
+	StartedAt time.Time
+}
+
+var (
+	runInfoMu     sync.Mutex
+	runInfo       RunMetadata
+	runInfoLoaded bool
+)
+
+// RunInfo returns the metadata of the current run, read from the
+// CAFE_RUN_ID, CAFE_ACTOR_ID and CAFE_RUN_STARTED_AT (RFC 3339) environment
+// variables and cached after the first successful read.
+func RunInfo(ctx context.Context) (RunMetadata, error) {
+	runInfoMu.Lock()
+	defer runInfoMu.Unlock()
+
+	if runInfoLoaded {
+		return runInfo, nil
+	}
+
+	md := RunMetadata{RunID: os.Getenv(runIDEnv), ActorID: os.Getenv(actorIDEnv)}
+	if md.RunID == "" {
+		return RunMetadata{}, ErrNoRunInfo
+	}
+	if v := os.Getenv(runStartedAtEnv); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return RunMetadata{}, fmt.Errorf("cafesdk: parse %s: %w", runStartedAtEnv, err)
+		}
+		md.StartedAt = t
+	}
+
+	runInfo, runInfoLoaded = md, true
+	return md, nil
+}
+
+// RunDeadline returns when the platform will stop the run, read from the
+// CAFE_RUN_DEADLINE environment variable (RFC 3339). It reports false when
+// the run has no deadline or the value cannot be parsed.
+func RunDeadline(ctx context.Context) (time.Time, bool) {
+	v := os.Getenv(runDeadlineEnv)
+	if v == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// RunContext returns a context that is cancelled at the run deadline, so an
+// actor can stop scraping and push what it has before the platform stops
+// it. Without a deadline the context only ends when cancel is called, which
+// releases its resources either way.
+func RunContext() (context.Context, context.CancelFunc) {
+	ctx := context.Background()
+	if deadline, ok := RunDeadline(ctx); ok {
+		return context.WithDeadline(ctx, deadline)
+	}
+	return context.WithCancel(ctx)
+}
@@ -0,0 +1,93 @@
+package cafesdk_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cafesdk "test/GoSdk"
+)
+
+func TestRunInfoFromEnv(t *testing.T) {
+	cafesdk.ResetRunInfo(t)
+	t.Setenv("CAFE_RUN_ID", "run-1")
+	t.Setenv("CAFE_ACTOR_ID", "actor-7")
+	t.Setenv("CAFE_RUN_STARTED_AT", "2024-05-01T12:00:00Z")
+
+	md, err := cafesdk.RunInfo(context.Background())
+	if err != nil {
+		t.Fatalf("RunInfo: %v", err)
+	}
+	want := cafesdk.RunMetadata{RunID: "run-1", ActorID: "actor-7", StartedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	if md != want {
+		t.Errorf("RunInfo = %+v, want %+v", md, want)
+	}
+
+	t.Setenv("CAFE_RUN_ID", "run-2")
+	if md, _ := cafesdk.RunInfo(context.Background()); md.RunID != "run-1" {
+		t.Errorf("RunInfo after the env changed = %q, want the cached run-1", md.RunID)
+	}
+}
+
+func TestRunInfoAbsent(t *testing.T) {
+	cafesdk.ResetRunInfo(t)
+	t.Setenv("CAFE_RUN_ID", "")
+
+	if _, err := cafesdk.RunInfo(context.Background()); !errors.Is(err, cafesdk.ErrNoRunInfo) {
+		t.Errorf("RunInfo = %v, want ErrNoRunInfo", err)
+	}
+}
+
+func TestRunInfoBadStartTime(t *testing.T) {
+	cafesdk.ResetRunInfo(t)
+	t.Setenv("CAFE_RUN_ID", "run-1")
+	t.Setenv("CAFE_RUN_STARTED_AT", "yesterday")
+
+	if _, err := cafesdk.RunInfo(context.Background()); err == nil {
+		t.Error("RunInfo accepted an unparsable start time")
+	}
+}
+
+func TestRunDeadlinePresent(t *testing.T) {
+	deadline := time.Now().Add(time.Hour).Truncate(time.Second)
+	t.Setenv("CAFE_RUN_DEADLINE", deadline.Format(time.RFC3339))
+
+	got, ok := cafesdk.RunDeadline(context.Background())
+	if !ok || !got.Equal(deadline) {
+		t.Fatalf("RunDeadline = %v, %v, want %v", got, ok, deadline)
+	}
+	ctx, cancel := cafesdk.RunContext()
+	defer cancel()
+	if d, ok := ctx.Deadline(); !ok || !d.Equal(deadline) {
+		t.Errorf("RunContext deadline = %v, %v, want %v", d, ok, deadline)
+	}
+}
+
+func TestRunContextEndsAtPastDeadline(t *testing.T) {
+	t.Setenv("CAFE_RUN_DEADLINE", time.Now().Add(-time.Minute).Format(time.RFC3339))
+
+	ctx, cancel := cafesdk.RunContext()
+	defer cancel()
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		t.Errorf("RunContext past its deadline: Err = %v, want DeadlineExceeded", ctx.Err())
+	}
+}
+
+func TestRunDeadlineAbsent(t *testing.T) {
+	for _, v := range []string{"", "tomorrow"} {
+		t.Setenv("CAFE_RUN_DEADLINE", v)
+
+		if d, ok := cafesdk.RunDeadline(context.Background()); ok {
+			t.Errorf("RunDeadline with %q = %v, want none", v, d)
+		}
+		ctx, cancel := cafesdk.RunContext()
+		if _, ok := ctx.Deadline(); ok || ctx.Err() != nil {
+			t.Errorf("RunContext with %q has a deadline or ended", v)
+		}
+		cancel()
+		if !errors.Is(ctx.Err(), context.Canceled) {
+			t.Errorf("RunContext after cancel: Err = %v, want Canceled", ctx.Err())
+		}
+	}
+}
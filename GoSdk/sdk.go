@@ -1,72 +1,607 @@
+// Package cafesdk is the Go SDK for CafeScraper actors. It talks to the
+// platform over gRPC to read input parameters, push results and write logs.
+//
+// All Parameter, Result and Log methods, and the Writer, Stream and
+// DedupWriter helpers, are safe for concurrent use by multiple goroutines.
 package cafesdk
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	grpc "google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 const (
-	address = "127.0.0.1:20086"
+	defaultAddress = "127.0.0.1:20086"
+	addressEnv     = "CAFE_GRPC_ADDRESS"
+
+	dialAttempts  = 5
+	dialBaseDelay = time.Second
+	dialMaxDelay  = 8 * time.Second
 )
 
-type _Parameter struct{}
-type _Result struct{}
-type _Log struct{}
+type _Parameter struct{ c *Client }
+type _Result struct{ c *Client }
+type _Log struct{ c *Client }
 
-var Parameter _Parameter
-var Result _Result
-var Log _Log
+// The package-level Parameter, Result and Log belong to the default Client,
+// which SetAddress, SetTLSConfig, Configure and Close act on.
+var (
+	defaultClient = newClient()
 
-var _parameterClient ParameterClient
-var _resultClient ResultClient
-var _logClient LogClient
+	Parameter = defaultClient.Parameter
+	Result    = defaultClient.Result
+	Log       = defaultClient.Log
+)
 
-var grpcConn *grpc.ClientConn
+// ErrClosed is returned by SDK calls made after Close.
+var ErrClosed = errors.New("cafesdk: connection is closed")
 
-func init() {
+// Client is an SDK instance with its own platform connection, input cache
+// and result bookkeeping, for programs that talk to more than one platform
+// endpoint or inject a connection in tests. Settings made through the
+// package-level Set functions, such as SetRetryPolicy, SetLogLevel and
+// SetLogBuffering, apply to every Client.
+type Client struct {
+	Parameter _Parameter
+	Result    _Result
+	Log       _Log
+
+	// root ends on Shutdown; background work, retries and calls derive
+	// their contexts from it.
+	root       context.Context
+	rootCancel context.CancelFunc
+
+	mu     sync.Mutex
+	config dialConfig
+	conn   grpc.ClientConnInterface
+	// grpcConn is the connection the Client dialed itself and closes; it is
+	// nil for a connection injected with WithConn.
+	grpcConn *grpc.ClientConn
+	ready    bool
+	closed   bool
+
+	// dialMu serializes dialing so concurrent first calls share one attempt.
+	dialMu sync.Mutex
+	// closeOnce makes Close flush and release the connection only once.
+	closeOnce sync.Once
+
+	parameterClient ParameterClient
+	resultClient    ResultClient
+	logClient       LogClient
+
+	input      inputCache
+	pushed     atomic.Int64
+	normalizer atomic.Pointer[func(string) string]
+	encoder    atomic.Pointer[EncoderOptions]
+	captured   capturedRecords
+	header     headerTracker
+	sent       sentHeaders
+	pending    pendingHeader
+	writers    writerSet
+	failure    failureState
+	breaker    circuitBreaker
+	watch      connWatch
+	reconnect  reconnectState
+	warnings   queuedWarnings
+}
+
+// New returns a Client configured by opts. It connects lazily, on its first
+// call, to the address given by WithAddress, or else CAFE_GRPC_ADDRESS or
+// 127.0.0.1:20086.
+func New(opts ...Option) *Client {
+	c := newClient()
+	for _, opt := range opts {
+		opt(&c.config)
+	}
+	return c
+}
+
+func newClient() *Client {
+	c := &Client{config: dialConfig{address: defaultAddress, reconnectAfter: defaultReconnectAfter}}
+	c.root, c.rootCancel = context.WithCancel(context.Background())
+	if addr := os.Getenv(addressEnv); addr != "" {
+		c.config.address = addr
+	}
+	c.Parameter = _Parameter{c}
+	c.Result = _Result{c}
+	c.Log = _Log{c}
+	return c
+}
+
+// SetAddress overrides the platform gRPC address. The address is resolved
+// with the precedence SetAddress > CAFE_GRPC_ADDRESS > 127.0.0.1:20086, and
+// must be set before the first SDK call establishes the connection.
+func SetAddress(addr string) error {
+	return defaultClient.configure("SetAddress", WithAddress(addr))
+}
+
+func (c *Client) configure(caller string, opts ...Option) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+	if c.conn != nil {
+		return fmt.Errorf("cafesdk: %s called after the connection was established", caller)
+	}
+	for _, opt := range opts {
+		opt(&c.config)
+	}
+	return nil
+}
+
+// ensureConn creates the connection and clients on first use and waits for
+// the platform to become reachable, retrying within a bounded number of
+// attempts with exponentially growing wait windows.
+func (c *Client) ensureConn(ctx context.Context) error {
+	c.mu.Lock()
+	ready, isClosed := c.ready, c.closed
+	c.mu.Unlock()
+	if isClosed {
+		return ErrClosed
+	}
+	if ready {
+		return nil
+	}
+
+	c.dialMu.Lock()
+	defer c.dialMu.Unlock()
+
+	conn, ready, err := c.currentConn()
+	if err != nil || ready {
+		return err
+	}
+
+	if err := waitReady(ctx, conn); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.ready = true
+	c.mu.Unlock()
+	return nil
+}
+
+// currentConn returns the dialed connection, creating it if needed. An
+// injected connection is reported ready as is.
+func (c *Client) currentConn() (*grpc.ClientConn, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, false, ErrClosed
+	}
+	if c.conn != nil {
+		return c.grpcConn, c.ready, nil
+	}
+
+	if c.config.conn != nil {
+		c.bind(c.config.conn)
+		c.ready = true
+		return nil, true, nil
+	}
+
+	conn, err := c.dial()
+	if err != nil {
+		return nil, false, err
+	}
+
+	c.grpcConn = conn
+	c.bind(newSwapConn(conn))
+	return conn, false, nil
+}
+
+// dial creates a connection from the Client's configuration. c.mu must be
+// held.
+func (c *Client) dial() (*grpc.ClientConn, error) {
+	creds, err := transportCredentials(c.config.tls)
+	if err != nil {
+		return nil, err
+	}
+	dialOpts := append([]grpc.DialOption{grpc.WithTransportCredentials(creds)}, c.config.dialOptions()...)
+	return grpc.NewClient(c.config.address, dialOpts...)
+}
+
+// Conn returns the default Client's connection. See Client.Conn.
+func Conn() (*grpc.ClientConn, error) {
+	return defaultClient.Conn()
+}
+
+// Conn returns the Client's gRPC connection, creating it if needed, for
+// calling platform services this SDK does not wrap. It is meant for
+// advanced use: the connection stays owned by the Client, so do not close
+// it, and it may not be ready yet. Conn fails with ErrClosed after Close,
+// and when the Client was given a connection other than a
+// *grpc.ClientConn through WithConn.
+func (c *Client) Conn() (*grpc.ClientConn, error) {
+	if _, _, err := c.currentConn(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, ErrClosed
+	}
+	if conn := c.clientConn(); conn != nil {
+		return conn, nil
+	}
+	return nil, errors.New("cafesdk: the injected connection is not a *grpc.ClientConn")
+}
+
+// clientConn returns the current *grpc.ClientConn, or nil when there is
+// none yet or the injected connection is of another type. c.mu must be
+// held.
+func (c *Client) clientConn() *grpc.ClientConn {
+	switch conn := c.conn.(type) {
+	case *swapConn:
+		return conn.cur.Load()
+	case *grpc.ClientConn:
+		return conn
+	}
+	return nil
+}
+
+func (c *Client) bind(conn grpc.ClientConnInterface) {
+	c.conn = conn
+	c.parameterClient = NewParameterClient(conn)
+	c.resultClient = NewResultClient(conn)
+	c.logClient = NewLogClient(conn)
+	c.startWatch()
+}
+
+// waitReady connects conn and waits for it to be ready, up to dialAttempts
+// times. The waiting happens inside each attempt, for dialBaseDelay at
+// first and twice as long each time up to dialMaxDelay, so the attempts
+// follow one another without a pause.
+func waitReady(ctx context.Context, conn *grpc.ClientConn) error {
+	policy := RetryPolicy{
+		MaxAttempts: dialAttempts,
+		Retryable:   func(err error) bool { return errors.Is(err, ErrUnavailable) },
+	}
+	wait, attempt := dialBaseDelay, 0
+	return Retry(ctx, policy, func() error {
+		attempt++
+		if attempt > 1 {
+			conn.ResetConnectBackoff()
+			wait = min(wait*2, dialMaxDelay)
+		}
+		conn.Connect()
+
+		attemptCtx, cancel := context.WithTimeout(ctx, wait)
+		defer cancel()
+		state := conn.GetState()
+		for state != connectivity.Ready && state != connectivity.Shutdown && conn.WaitForStateChange(attemptCtx, state) {
+			state = conn.GetState()
+		}
+
+		switch {
+		case state == connectivity.Ready:
+			return nil
+		case state == connectivity.Shutdown:
+			return ErrClosed
+		case ctx.Err() != nil:
+			return ctx.Err()
+		}
+		return fmt.Errorf("%w: %s not reachable after %d attempts", ErrUnavailable, conn.Target(), attempt)
+	})
+}
+
+// Close drains open result Writers, sends a pending header, flushes
+// buffered logs and releases the default Client's connection. Subsequent
+// SDK calls return ErrClosed; calling Close again is a no-op. To stop
+// background work such as heartbeats while keeping the connection, use
+// Shutdown.
+func Close() error {
+	var errs []error
+	if b := activeLogBuffer.Swap(nil); b != nil {
+		errs = append(errs, b.close(context.Background()))
+	}
+	return errors.Join(append(errs, defaultClient.Close())...)
+}
+
+// Close drains the Client's open result Writers, sends a pending header
+// (see FlushHeader), flushes buffered logs and releases its connection; a
+// connection injected with WithConn is left open. Subsequent calls on the
+// Client return ErrClosed. Only the first Close does this: later and
+// concurrent calls wait for it to finish and return nil.
+func (c *Client) Close() error {
 	var err error
-	grpcConn, err = grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	c.closeOnce.Do(func() { err = c.close() })
+	return err
+}
+
+func (c *Client) close() error {
+	errs := []error{c.writers.closeAll()}
+	if _, err := c.Result.FlushHeader(context.Background()); err != nil {
+		errs = append(errs, err)
+	}
+	if b := activeLogBuffer.Load(); b != nil {
+		errs = append(errs, b.flush(context.Background()))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	c.stopWatch()
+
+	if c.grpcConn != nil {
+		errs = append(errs, c.grpcConn.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// DefaultTimeout is the deadline applied to SDK calls whose context has none.
+const DefaultTimeout = 30 * time.Second
+
+var defaultTimeout atomic.Int64
+
+func init() {
+	defaultTimeout.Store(int64(DefaultTimeout))
+}
+
+// SetDefaultTimeout changes the deadline applied to SDK calls whose context
+// carries none. A non-positive duration disables the automatic deadline.
+func SetDefaultTimeout(d time.Duration) {
+	defaultTimeout.Store(int64(d))
+}
+
+var (
+	timeoutMu      sync.RWMutex
+	methodTimeouts = map[string]time.Duration{}
+)
+
+// SetTimeout overrides the default timeout for one method, named by the
+// Method constants, such as a longer one for MethodPushData when records are
+// large. Like the default, it only applies when the caller's context has no
+// deadline. A non-positive duration removes the override. Any other method
+// name is rejected, since no call would ever use its timeout.
+func SetTimeout(method string, d time.Duration) error {
+	if _, ok := knownMethods[method]; !ok {
+		return fmt.Errorf("cafesdk: SetTimeout: unknown method %q (use a Method constant)", method)
+	}
+
+	timeoutMu.Lock()
+	defer timeoutMu.Unlock()
+
+	if d <= 0 {
+		delete(methodTimeouts, method)
+		return nil
+	}
+	methodTimeouts[method] = d
+	return nil
+}
+
+func withDefaultTimeout(ctx context.Context, method string) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	timeoutMu.RLock()
+	d, ok := methodTimeouts[method]
+	timeoutMu.RUnlock()
+	if !ok {
+		d = time.Duration(defaultTimeout.Load())
+	}
+	if d <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+var callSlots atomic.Pointer[chan struct{}]
+
+// SetMaxConcurrentCalls bounds how many Parameter, Result and Log RPCs are
+// in flight at once across every Client; further calls block until a slot
+// frees or their context ends. A non-positive n, the default, removes the
+// bound. Calls already waiting keep the bound they started with.
+func SetMaxConcurrentCalls(n int) {
+	if n <= 0 {
+		callSlots.Store(nil)
+		return
+	}
+	slots := make(chan struct{}, n)
+	callSlots.Store(&slots)
+}
+
+// acquireSlot waits for an RPC slot and returns the function releasing it.
+func acquireSlot(ctx context.Context) (func(), error) {
+	slots := callSlots.Load()
+	if slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case *slots <- struct{}{}:
+		return func() { <-*slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// invoke runs an RPC for method on c's connection under its timeout.
+// Errors are classified (see ErrUnavailable and friends), and when the call
+// fails because the context ended, the context error is wrapped so
+// errors.Is(err, context.DeadlineExceeded) holds. While c's circuit
+// breaker is open it fails with ErrCircuitOpen instead.
+func invoke[T any](ctx context.Context, c *Client, method string, call func(ctx context.Context) (T, error)) (T, error) {
+	if !c.breaker.allow() {
+		var zero T
+		return zero, ErrCircuitOpen
+	}
+	res, err := invokeConn(ctx, c, method, call)
+	c.breaker.record(ctx, err)
+	c.noteCall(err)
+	return res, err
+}
+
+func invokeConn[T any](ctx context.Context, c *Client, method string, call func(ctx context.Context) (T, error)) (T, error) {
+	ctx, cancel := withDefaultTimeout(ctx, method)
+	defer cancel()
+
+	var zero T
+	if c.root.Err() != nil {
+		return zero, ErrShutdown
+	}
+	ctx, stop := c.derive(ctx)
+	defer stop()
+	if err := c.ensureConn(ctx); err != nil {
+		return zero, classifyError(err)
+	}
+	c.sendWarnings()
+	release, err := acquireSlot(ctx)
 	if err != nil {
-		log.Fatalf("init grpc client failed: %v", err)
+		return zero, classifyError(err)
 	}
+	defer release()
 
-	_parameterClient = NewParameterClient(grpcConn)
-	_resultClient = NewResultClient(grpcConn)
-	_logClient = NewLogClient(grpcConn)
+	res, err := call(ctx)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil && !errors.Is(err, ctxErr) {
+			err = fmt.Errorf("%w: %w", ctxErr, err)
+		}
+		return zero, classifyError(err)
+	}
+	return res, nil
 }
 
-func (_Parameter) GetInputJSONString(ctx context.Context) (string, error) {
-	res, err := _parameterClient.GetInputJSONString(ctx, &emptypb.Empty{})
+// GetInputJSONString returns the actor's input JSON. For running outside
+// the platform, a local input is used instead of the RPC when one is given,
+// in order of precedence: the file named by an --input command-line flag,
+// the CAFE_INPUT_JSON environment variable, or the file named by
+// CAFE_INPUT_FILE.
+func (p _Parameter) GetInputJSONString(ctx context.Context) (string, error) {
+	if input, ok, err := localInput(); ok || err != nil {
+		return input, err
+	}
+	if dryRun.Load() {
+		return "", nil
+	}
+	res, err := invoke(ctx, p.c, MethodGetInputJSONString, func(ctx context.Context) (*InputJSONStringResponse, error) {
+		return p.c.parameterClient.GetInputJSONString(ctx, &emptypb.Empty{})
+	})
 	if err != nil {
 		return "", err
 	}
 	return res.JsonString, nil
 }
 
-func (_Result) SetTableHeader(ctx context.Context, headers []*TableHeaderItem) (*Response, error) {
-	return _resultClient.SetTableHeader(ctx, &TableHeader{Headers: headers})
+// SetTableHeader sets the result table columns. Headers with a format other
+// than the Format constants (or one passed through FormatRaw) are rejected
+// before any RPC is made. A header identical to the last one sent is not
+// sent again: SetTableHeader returns an empty Response without an RPC. Use
+// ForceSetTableHeader to resend it anyway.
+func (r _Result) SetTableHeader(ctx context.Context, headers []*TableHeaderItem) (*Response, error) {
+	return r.setTableHeader(ctx, "", headers)
 }
 
-func (_Result) PushData(ctx context.Context, jsonString string) (*Response, error) {
-	return _resultClient.PushData(ctx, &Data{JsonString: jsonString})
+// ForceSetTableHeader sends headers even when they match the last header
+// sent, bypassing SetHeaderCoalescing too.
+func (r _Result) ForceSetTableHeader(ctx context.Context, headers []*TableHeaderItem) (*Response, error) {
+	return r.sendTableHeader(ctx, "", headers, "", true)
 }
 
-func (_Log) Debug(ctx context.Context, text string) (*Response, error) {
-	return _logClient.Debug(ctx, &LogBody{Log: text})
+// setTableHeader sets the header of dataset, "" being the default one,
+// whose header alone is coalesced and tracked for PushRow and VerifyHeader.
+func (r _Result) setTableHeader(ctx context.Context, dataset string, headers []*TableHeaderItem) (*Response, error) {
+	if dataset == "" && headerCoalescing.Load() {
+		if _, err := prepareHeader(headers); err != nil {
+			return nil, err
+		}
+		r.c.pending.coalesce(headers)
+		r.c.header.trackHeader(headers)
+		return &Response{}, nil
+	}
+	return r.sendTableHeader(ctx, dataset, headers, "", false)
 }
 
-func (_Log) Info(ctx context.Context, text string) (*Response, error) {
-	return _logClient.Info(ctx, &LogBody{Log: text})
+// sendTableHeader makes the SetTableHeader RPC, with hints in
+// ColumnHintsHeader unless empty, unless headers and hints encode the same
+// as the last header sent for dataset and force is false.
+func (r _Result) sendTableHeader(ctx context.Context, dataset string, headers []*TableHeaderItem, hints string, force bool) (*Response, error) {
+	headers, err := prepareHeader(headers)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := proto.MarshalOptions{Deterministic: true}.Marshal(&TableHeader{Headers: headers})
+	if err != nil {
+		return nil, fmt.Errorf("cafesdk: encode table header: %w", err)
+	}
+	encoded = append(encoded, hints...)
+	if !force && r.c.sent.unchanged(dataset, encoded) {
+		return &Response{}, nil
+	}
+	ctx = withDataset(ctx, dataset)
+	if hints != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, ColumnHintsHeader, hints)
+	}
+	res, err := withRetry(ctx, r.c, currentRetryPolicy(), func(ctx context.Context) (*Response, error) {
+		if dryRunHeader(headers) {
+			return dryRunResponse(), nil
+		}
+		return invoke(ctx, r.c, MethodSetTableHeader, func(ctx context.Context) (*Response, error) {
+			return r.c.resultClient.SetTableHeader(ctx, &TableHeader{Headers: headers})
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.c.sent.record(dataset, encoded)
+	if dataset == "" {
+		r.c.header.trackHeader(headers)
+	}
+	return res, nil
 }
 
-func (_Log) Warn(ctx context.Context, text string) (*Response, error) {
-	return _logClient.Warn(ctx, &LogBody{Log: text})
+// PushData pushes one JSON record. See SetJSONValidation for checking
+// records before the RPC.
+func (r _Result) PushData(ctx context.Context, jsonString string) (*Response, error) {
+	return r.push(ctx, "", jsonString, true)
 }
 
-func (_Log) Error(ctx context.Context, text string) (*Response, error) {
-	return _logClient.Error(ctx, &LogBody{Log: text})
+// push pushes one record to dataset, under the SetRetryPolicy policy when
+// retry is true and in a single attempt otherwise, for callers that retry
+// themselves.
+func (r _Result) push(ctx context.Context, dataset, jsonString string, retry bool) (*Response, error) {
+	if err := validateRecord(jsonString); err != nil {
+		return nil, err
+	}
+	jsonString = tagSource(ctx, jsonString)
+	ctx = withDataset(ctx, dataset)
+	call := func(ctx context.Context) (*Response, error) {
+		if dryRunCall(MethodPushData, jsonString) {
+			return dryRunResponse(), nil
+		}
+		return invoke(ctx, r.c, MethodPushData, func(ctx context.Context) (*Response, error) {
+			return r.c.resultClient.PushData(ctx, &Data{JsonString: jsonString})
+		})
+	}
+	var res *Response
+	var err error
+	if retry {
+		res, err = withRetry(ctx, r.c, currentRetryPolicy(), call)
+	} else {
+		res, err = call(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.c.pushed.Add(1)
+	if dataset == "" {
+		r.c.header.trackRecord(jsonString)
+		r.c.captured.add(jsonString)
+	}
+	return res, nil
 }
@@ -0,0 +1,484 @@
+package cafesdk_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cafesdk "test/GoSdk"
+	"test/GoSdk/cafesdktest"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+)
+
+func TestNewWithAddressReachesServer(t *testing.T) {
+	client, srv := newTestClient(t)
+
+	if _, err := client.Result.PushData(context.Background(), `{"ok":true}`); err != nil {
+		t.Fatalf("PushData: %v", err)
+	}
+	if got := srv.Data(); len(got) != 1 || got[0] != `{"ok":true}` {
+		t.Errorf("server data = %q", got)
+	}
+}
+
+func TestAddressFromEnvironment(t *testing.T) {
+	srv := cafesdktest.Start(t)
+	t.Setenv("CAFE_GRPC_ADDRESS", srv.Addr)
+	client := cafesdk.New()
+	t.Cleanup(func() { client.Close() })
+
+	if _, err := client.Result.PushData(context.Background(), `{}`); err != nil {
+		t.Fatalf("PushData with CAFE_GRPC_ADDRESS: %v", err)
+	}
+	if len(srv.Data()) != 1 {
+		t.Errorf("server got %d records, want 1", len(srv.Data()))
+	}
+}
+
+func TestWithAddressOverridesEnvironment(t *testing.T) {
+	srv := cafesdktest.Start(t)
+	t.Setenv("CAFE_GRPC_ADDRESS", "127.0.0.1:1")
+	client := cafesdk.New(cafesdk.WithAddress(srv.Addr))
+	t.Cleanup(func() { client.Close() })
+
+	if _, err := client.Result.PushData(context.Background(), `{}`); err != nil {
+		t.Fatalf("PushData: %v", err)
+	}
+}
+
+func TestSetAddressAfterConnectFails(t *testing.T) {
+	client, srv := newTestClient(t)
+	cafesdk.UseDefaultClient(t, client)
+
+	if _, err := cafesdk.Result.PushData(context.Background(), `{}`); err != nil {
+		t.Fatalf("PushData: %v", err)
+	}
+	if err := cafesdk.SetAddress("127.0.0.1:1"); err == nil {
+		t.Fatal("SetAddress after the connection was established succeeded")
+	}
+	if err := cafesdk.Configure(cafesdk.WithAddress("127.0.0.1:1")); err == nil {
+		t.Fatal("Configure after the connection was established succeeded")
+	}
+
+	// The ignored options leave the connection in place.
+	if _, err := cafesdk.Result.PushData(context.Background(), `{}`); err != nil {
+		t.Fatalf("PushData after rejected SetAddress: %v", err)
+	}
+	if len(srv.Data()) != 2 {
+		t.Errorf("server got %d records, want 2", len(srv.Data()))
+	}
+}
+
+func TestCloseTearsDownConnection(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+
+	if _, err := client.Result.PushData(ctx, `{}`); err != nil {
+		t.Fatalf("PushData: %v", err)
+	}
+	conn, err := client.Conn()
+	if err != nil {
+		t.Fatalf("Conn: %v", err)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if state := conn.GetState(); state != connectivity.Shutdown {
+		t.Errorf("connection state after Close = %v, want Shutdown", state)
+	}
+	if _, err := client.Result.PushData(ctx, `{}`); !errors.Is(err, cafesdk.ErrClosed) {
+		t.Errorf("PushData after Close = %v, want ErrClosed", err)
+	}
+	if _, err := client.Conn(); !errors.Is(err, cafesdk.ErrClosed) {
+		t.Errorf("Conn after Close = %v, want ErrClosed", err)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Errorf("second Close = %v, want nil", err)
+	}
+}
+
+// connCounter counts the connections a server sees open and close.
+type connCounter struct {
+	begun, ended atomic.Int32
+}
+
+func (c *connCounter) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (c *connCounter) HandleRPC(context.Context, stats.RPCStats) {}
+
+func (c *connCounter) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (c *connCounter) HandleConn(_ context.Context, s stats.ConnStats) {
+	switch s.(type) {
+	case *stats.ConnBegin:
+		c.begun.Add(1)
+	case *stats.ConnEnd:
+		c.ended.Add(1)
+	}
+}
+
+func TestConcurrentCloseClosesOnce(t *testing.T) {
+	counter := &connCounter{}
+	srv := cafesdktest.Start(t, grpc.StatsHandler(counter))
+	client := cafesdk.New(cafesdk.WithAddress(srv.Addr))
+	if _, err := client.Result.PushData(context.Background(), `{}`); err != nil {
+		t.Fatalf("PushData: %v", err)
+	}
+	conn, err := client.Conn()
+	if err != nil {
+		t.Fatalf("Conn: %v", err)
+	}
+
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	errs := make([]error, 50)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs[i] = client.Close()
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	// A second close of the gRPC connection would fail, so every Close
+	// returning cleanly shows it was released once.
+	for i, err := range errs {
+		if err != nil && !errors.Is(err, cafesdk.ErrClosed) {
+			t.Errorf("Close #%d = %v, want nil or ErrClosed", i, err)
+		}
+	}
+	if state := conn.GetState(); state != connectivity.Shutdown {
+		t.Errorf("connection state after Close = %v, want Shutdown", state)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for counter.ended.Load() < counter.begun.Load() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if begun, ended := counter.begun.Load(), counter.ended.Load(); begun != 1 || ended != 1 {
+		t.Errorf("server saw %d connections open and %d close, want 1 and 1", begun, ended)
+	}
+}
+
+func TestFirstCallWaitsForLateServer(t *testing.T) {
+	srv := cafesdktest.Start(t)
+	srv.Stop()
+	go func() {
+		time.Sleep(3 * time.Second)
+		if err := srv.Restart(); err != nil {
+			t.Errorf("Restart: %v", err)
+		}
+	}()
+
+	client := cafesdk.New(cafesdk.WithAddress(srv.Addr))
+	t.Cleanup(func() { client.Close() })
+	if _, err := client.Result.PushData(context.Background(), `{"late":true}`); err != nil {
+		t.Fatalf("PushData against a server 3s late: %v", err)
+	}
+	if len(srv.Data()) != 1 {
+		t.Errorf("server got %d records, want 1", len(srv.Data()))
+	}
+}
+
+func TestDefaultTimeoutAppliesToSlowServer(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.Delay(cafesdk.MethodPushData, 2*time.Second)
+	cafesdk.SetDefaultTimeout(200 * time.Millisecond)
+	t.Cleanup(func() { cafesdk.SetDefaultTimeout(cafesdk.DefaultTimeout) })
+
+	if _, err := client.Log.Info(context.Background(), "connect first"); err != nil {
+		t.Fatalf("Info: %v", err)
+	}
+	start := time.Now()
+	_, err := client.Result.PushData(context.Background(), `{}`)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("PushData = %v, want context.DeadlineExceeded", err)
+	}
+	if !errors.Is(err, cafesdk.ErrTimeout) {
+		t.Errorf("PushData = %v, want it classified as ErrTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("PushData took %v, want about the 200ms default timeout", elapsed)
+	}
+}
+
+func TestCallerDeadlineIsKept(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.Delay(cafesdk.MethodPushData, 300*time.Millisecond)
+	cafesdk.SetDefaultTimeout(50 * time.Millisecond)
+	t.Cleanup(func() { cafesdk.SetDefaultTimeout(cafesdk.DefaultTimeout) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := client.Result.PushData(ctx, `{}`); err != nil {
+		t.Fatalf("PushData with its own deadline: %v", err)
+	}
+}
+
+func TestKeepaliveClientReachesServer(t *testing.T) {
+	srv := cafesdktest.Start(t, grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+		MinTime:             time.Second,
+		PermitWithoutStream: true,
+	}))
+	client := cafesdk.New(cafesdk.WithAddress(srv.Addr), cafesdk.WithKeepalive(keepalive.ClientParameters{}))
+	t.Cleanup(func() { client.Close() })
+
+	if _, err := client.Result.PushData(context.Background(), `{}`); err != nil {
+		t.Fatalf("PushData with keepalive: %v", err)
+	}
+}
+
+func TestMaxRecvMsgSize(t *testing.T) {
+	srv := cafesdktest.Start(t)
+	input := `{"html":"` + strings.Repeat("x", 4<<20) + `"}`
+	srv.SetInput(input)
+	ctx := context.Background()
+
+	small := cafesdk.New(cafesdk.WithAddress(srv.Addr))
+	t.Cleanup(func() { small.Close() })
+	if _, err := small.Parameter.GetInputJSONString(ctx); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("input over 4 MB without the option = %v, want ResourceExhausted", err)
+	}
+
+	large := cafesdk.New(cafesdk.WithAddress(srv.Addr), cafesdk.WithMaxRecvMsgSize(8<<20))
+	t.Cleanup(func() { large.Close() })
+	got, err := large.Parameter.GetInputJSONString(ctx)
+	if err != nil {
+		t.Fatalf("input over 4 MB with WithMaxRecvMsgSize: %v", err)
+	}
+	if got != input {
+		t.Errorf("input is %d bytes, want %d", len(got), len(input))
+	}
+}
+
+func TestMaxSendMsgSize(t *testing.T) {
+	srv := cafesdktest.Start(t, grpc.MaxRecvMsgSize(16<<20))
+	record := `{"html":"` + strings.Repeat("x", 1<<20) + `"}`
+	ctx := context.Background()
+
+	client := cafesdk.New(cafesdk.WithAddress(srv.Addr), cafesdk.WithMaxSendMsgSize(1<<20))
+	t.Cleanup(func() { client.Close() })
+	if _, err := client.Result.PushData(ctx, record); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("PushData over the cap = %v, want ResourceExhausted", err)
+	}
+	if n := len(srv.Calls()); n != 0 {
+		t.Errorf("oversized push reached the server %d times", n)
+	}
+	if _, err := client.Result.PushData(ctx, `{"small":true}`); err != nil {
+		t.Errorf("PushData under the cap: %v", err)
+	}
+}
+
+func TestClientsAreIsolated(t *testing.T) {
+	clientA, srvA := newTestClient(t)
+	clientB, srvB := newTestClient(t)
+	srvA.SetInput(`{"name":"a"}`)
+	srvB.SetInput(`{"name":"b"}`)
+	ctx := context.Background()
+
+	for _, c := range []struct {
+		client *cafesdk.Client
+		want   string
+	}{{clientA, "a"}, {clientB, "b"}} {
+		name, err := c.client.Parameter.GetString(ctx, "name")
+		if err != nil || name != c.want {
+			t.Fatalf("GetString(name) = %q, %v; want %q", name, err, c.want)
+		}
+		c.client.Result.PushData(ctx, `{"from":"`+c.want+`"}`)
+		c.client.Log.Info(ctx, "log "+c.want)
+	}
+	if err := clientA.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := clientB.Result.PushData(ctx, `{"from":"b","after":"close"}`); err != nil {
+		t.Errorf("closing A broke B: %v", err)
+	}
+
+	if got := srvA.Data(); len(got) != 1 || got[0] != `{"from":"a"}` {
+		t.Errorf("server A data = %q", got)
+	}
+	if got := srvB.Data(); len(got) != 2 || got[0] != `{"from":"b"}` {
+		t.Errorf("server B data = %q", got)
+	}
+	if logs := srvA.Logs(); len(logs) != 1 || logs[0].Text != "log a" {
+		t.Errorf("server A logs = %+v", logs)
+	}
+	if logs := srvB.Logs(); len(logs) != 1 || logs[0].Text != "log b" {
+		t.Errorf("server B logs = %+v", logs)
+	}
+	if n := clientA.Result.PushedCount(); n != 1 {
+		t.Errorf("client A PushedCount = %d, want 1", n)
+	}
+}
+
+func TestMaxConcurrentCallsBoundsInFlight(t *testing.T) {
+	const limit = 3
+	cafesdk.SetMaxConcurrentCalls(limit)
+	t.Cleanup(func() { cafesdk.SetMaxConcurrentCalls(0) })
+
+	var inFlight, peak atomic.Int32
+	count := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(5 * time.Millisecond)
+		return handler(ctx, req)
+	}
+	srv := cafesdktest.Start(t, grpc.ChainUnaryInterceptor(count))
+	client := cafesdk.New(cafesdk.WithAddress(srv.Addr))
+	t.Cleanup(func() { client.Close() })
+
+	var wg sync.WaitGroup
+	for range 30 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Result.PushData(context.Background(), `{}`); err != nil {
+				t.Errorf("PushData: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if p := peak.Load(); p > limit {
+		t.Errorf("peak in-flight = %d, want at most %d", p, limit)
+	} else if p < limit {
+		t.Errorf("peak in-flight = %d; the pushes never filled the %d slots", p, limit)
+	}
+}
+
+// encodingRecorder records the grpc-encoding of requests reaching a server.
+type encodingRecorder struct {
+	mu        sync.Mutex
+	encodings []string
+}
+
+func (r *encodingRecorder) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (r *encodingRecorder) HandleRPC(_ context.Context, s stats.RPCStats) {
+	if h, ok := s.(*stats.InHeader); ok {
+		r.mu.Lock()
+		r.encodings = append(r.encodings, h.Compression)
+		r.mu.Unlock()
+	}
+}
+
+func (r *encodingRecorder) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (r *encodingRecorder) HandleConn(context.Context, stats.ConnStats) {}
+
+func TestCompressionRoundTrip(t *testing.T) {
+	rec := &encodingRecorder{}
+	srv := cafesdktest.Start(t, grpc.StatsHandler(rec))
+	client := cafesdk.New(cafesdk.WithAddress(srv.Addr), cafesdk.WithCompression(true))
+	t.Cleanup(func() { client.Close() })
+
+	record := `{"html":"` + strings.Repeat("<p>compressible</p>", 1000) + `"}`
+	if _, err := client.Result.PushData(context.Background(), record); err != nil {
+		t.Fatalf("PushData: %v", err)
+	}
+	if got := srv.Data(); len(got) != 1 || got[0] != record {
+		t.Errorf("server decoded %d records, want the record intact", len(got))
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if !equalStrings(rec.encodings, []string{"gzip"}) {
+		t.Errorf("request encodings = %q, want [gzip]", rec.encodings)
+	}
+}
+
+func TestConnBuildsGeneratedClient(t *testing.T) {
+	client, srv := newTestClient(t)
+	conn, err := client.Conn()
+	if err != nil {
+		t.Fatalf("Conn: %v", err)
+	}
+
+	res, err := cafesdk.NewResultClient(conn).PushData(context.Background(), &cafesdk.Data{JsonString: `{"raw":true}`})
+	if err != nil {
+		t.Fatalf("PushData through Conn: %v", err)
+	}
+	if !res.OK() || !equalStrings(srv.Data(), []string{`{"raw":true}`}) {
+		t.Errorf("PushData = %+v, Data() = %q", res, srv.Data())
+	}
+}
+
+func TestConnAfterClose(t *testing.T) {
+	client, _ := newTestClient(t)
+	client.Close()
+
+	if conn, err := client.Conn(); conn != nil || !errors.Is(err, cafesdk.ErrClosed) {
+		t.Errorf("Conn after Close = %v, %v, want ErrClosed", conn, err)
+	}
+}
+
+// useTimeout sets a timeout override for method until the test ends.
+func useTimeout(t *testing.T, method string, d time.Duration) {
+	t.Helper()
+	if err := cafesdk.SetTimeout(method, d); err != nil {
+		t.Fatalf("SetTimeout: %v", err)
+	}
+	t.Cleanup(func() { cafesdk.SetTimeout(method, 0) })
+}
+
+func TestSetTimeoutOverridesOneMethod(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.Delay(cafesdk.MethodPushData, 2*time.Second)
+	srv.Delay(cafesdk.MethodLogInfo, 300*time.Millisecond)
+	useTimeout(t, cafesdk.MethodPushData, 100*time.Millisecond)
+	ctx := context.Background()
+
+	start := time.Now()
+	if _, err := client.Result.PushData(ctx, `{}`); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("PushData = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("PushData took %v, want about the 100ms override", elapsed)
+	}
+	if _, err := client.Log.Info(ctx, "slow but within the default"); err != nil {
+		t.Errorf("Info under the default timeout: %v", err)
+	}
+}
+
+func TestCallerDeadlineBeatsSetTimeout(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.Delay(cafesdk.MethodPushData, 300*time.Millisecond)
+	useTimeout(t, cafesdk.MethodPushData, 50*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := client.Result.PushData(ctx, `{}`); err != nil {
+		t.Fatalf("PushData with its own deadline: %v", err)
+	}
+}
+
+func TestSetTimeoutUnknownMethod(t *testing.T) {
+	for _, method := range []string{"PushData", "Result.Push", ""} {
+		if err := cafesdk.SetTimeout(method, time.Second); err == nil || !strings.Contains(err.Error(), "unknown method") {
+			t.Errorf("SetTimeout(%q) = %v, want an unknown method error", method, err)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package cafesdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	secretEnvPrefix   = "CAFE_SECRET_"
+	secretsDirEnv     = "CAFE_SECRETS_DIR"
+	defaultSecretsDir = "/run/secrets"
+)
+
+// ErrSecretNotFound is returned by GetSecret when no source has the secret.
+var ErrSecretNotFound = errors.New("cafesdk: secret not found")
+
+// GetSecret returns the named secret, such as a site login. The platform has
+// no secrets RPC, so it is read from the CAFE_SECRET_<NAME> environment
+// variable (the name upper-cased, with characters other than letters and
+// digits replaced by underscores), or else from the file <name> in the
+// directory named by CAFE_SECRETS_DIR, /run/secrets by default, without its
+// trailing newline. The SDK never logs or traces secret values, and errors
+// name only the secret.
+func GetSecret(ctx context.Context, name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return "", fmt.Errorf("cafesdk: invalid secret name %q", name)
+	}
+
+	if v, ok := os.LookupEnv(secretEnvPrefix + secretEnvName(name)); ok {
+		return v, nil
+	}
+
+	dir := os.Getenv(secretsDirEnv)
+	if dir == "" {
+		dir = defaultSecretsDir
+	}
+	b, err := os.ReadFile(filepath.Join(dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("cafesdk: read secret %s: %w", name, err)
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+func secretEnvName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,97 @@
+package cafesdk_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cafesdk "test/GoSdk"
+)
+
+// useSecretsDir points CAFE_SECRETS_DIR at a new directory holding files.
+func useSecretsDir(t *testing.T, files map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("CAFE_SECRETS_DIR", dir)
+}
+
+func TestGetSecretSources(t *testing.T) {
+	useSecretsDir(t, map[string]string{"site-login": "from-file\n", "api.token": "file-token"})
+	t.Setenv("CAFE_SECRET_API_TOKEN", "env-token")
+	unsetenv(t, "CAFE_SECRET_SITE_LOGIN")
+	ctx := context.Background()
+
+	for name, want := range map[string]string{
+		"site-login": "from-file", // trailing newline trimmed
+		"api.token":  "env-token", // the environment wins over the file
+	} {
+		if got, err := cafesdk.GetSecret(ctx, name); err != nil || got != want {
+			t.Errorf("GetSecret(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+}
+
+func TestGetSecretNotFound(t *testing.T) {
+	useSecretsDir(t, nil)
+
+	_, err := cafesdk.GetSecret(context.Background(), "missing")
+	if !errors.Is(err, cafesdk.ErrSecretNotFound) || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("GetSecret = %v, want ErrSecretNotFound naming the secret", err)
+	}
+}
+
+func TestGetSecretInvalidName(t *testing.T) {
+	for _, name := range []string{"", ".", "..", "../etc/passwd", `a\b`} {
+		if _, err := cafesdk.GetSecret(context.Background(), name); err == nil || errors.Is(err, cafesdk.ErrSecretNotFound) {
+			t.Errorf("GetSecret(%q) = %v, want an invalid name error", name, err)
+		}
+	}
+}
+
+func TestGetSecretNeverLogged(t *testing.T) {
+	const value = "hunter2-s3cr3t"
+	useSecretsDir(t, map[string]string{"login": value})
+	var mirror, debug bytes.Buffer
+	cafesdk.SetLocalMirror(&mirror)
+	cafesdk.SetRPCDebug(&cafesdk.RPCDebugOptions{Writer: &debug})
+	t.Cleanup(func() {
+		cafesdk.SetLocalMirror(nil)
+		cafesdk.SetRPCDebug(nil)
+	})
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+
+	secret, err := cafesdk.GetSecret(ctx, "login")
+	if err != nil || secret != value {
+		t.Fatalf("GetSecret = %q, %v", secret, err)
+	}
+	// An actor logging in with it: the record carries the secret as a
+	// redacted field.
+	if _, err := client.Result.PushData(ctx, `{"user":"me","password":"`+secret+`"}`); err != nil {
+		t.Fatalf("PushData: %v", err)
+	}
+	if _, err := client.Log.Info(ctx, "logged in"); err != nil {
+		t.Fatalf("Info: %v", err)
+	}
+
+	for _, l := range srv.Logs() {
+		if strings.Contains(l.Text, value) {
+			t.Errorf("server log %q contains the secret", l.Text)
+		}
+	}
+	if strings.Contains(mirror.String(), value) {
+		t.Errorf("mirror contains the secret:\n%s", mirror.String())
+	}
+	if out := debug.String(); strings.Contains(out, value) || !strings.Contains(out, cafesdk.MethodPushData) {
+		t.Errorf("RPC debug output leaks the secret or lacks the push:\n%s", out)
+	}
+}
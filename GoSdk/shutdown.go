@@ -0,0 +1,132 @@
+package cafesdk
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// DefaultShutdownGrace bounds the flush and close that follow a signal.
+const DefaultShutdownGrace = 10 * time.Second
+
+// OnShutdown handles SIGTERM and SIGINT for the run. On a signal it cancels
+// the returned context, logs the signal as the final run status, drains
+// result writers and buffered logs and closes the connection, giving up on
+// the flush after grace (DefaultShutdownGrace when non-positive). The
+// returned channel is closed once that sequence is over, or once ctx ends
+// without a signal.
+func OnShutdown(ctx context.Context, grace time.Duration) (context.Context, <-chan struct{}) {
+	if grace <= 0 {
+		grace = DefaultShutdownGrace
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer signal.Stop(signals)
+
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-signals:
+			cancel()
+			shutdown(sig, grace)
+		}
+	}()
+	return ctx, done
+}
+
+func shutdown(sig os.Signal, grace time.Duration) {
+	graceCtx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		Log.Warnf(graceCtx, "run stopped by signal %v", sig)
+		Close()
+	}()
+
+	select {
+	case <-closed:
+	case <-graceCtx.Done():
+	}
+}
+
+// Shutdown stops all SDK activity of the default Client: it drains result
+// Writers, sends a pending header and flushes buffered logs, then cancels
+// the context every background goroutine (heartbeats, writers, the log
+// buffer, connection watchers, retry backoffs) derives from. Whatever has
+// not been flushed when ctx ends is abandoned, and the returned error then
+// wraps ctx's. Afterwards SDK calls return ErrShutdown.
+//
+// Unlike Close, Shutdown leaves the connection open, so an actor can stop
+// its own work and still inspect state; call Close to release it.
+func Shutdown(ctx context.Context) error {
+	return defaultClient.shutdown(ctx, func(ctx context.Context) error {
+		if b := activeLogBuffer.Swap(nil); b != nil {
+			return b.close(ctx)
+		}
+		return nil
+	})
+}
+
+// Shutdown is the per-Client form of the package-level Shutdown. Buffered
+// logs are flushed but buffering stays on, as it is shared by all Clients.
+func (c *Client) Shutdown(ctx context.Context) error {
+	return c.shutdown(ctx, func(ctx context.Context) error {
+		if b := activeLogBuffer.Load(); b != nil {
+			return b.flush(ctx)
+		}
+		return nil
+	})
+}
+
+// shutdown flushes c and runs flushLogs until they finish or ctx ends, then
+// cancels c's root context and waits for the flush to give up. When ctx
+// ended first, its error is joined with whatever the flush reported.
+func (c *Client) shutdown(ctx context.Context, flushLogs func(context.Context) error) error {
+	if c.root.Err() != nil {
+		return nil
+	}
+
+	var err error
+	flushed := make(chan struct{})
+	go func() {
+		defer close(flushed)
+		errs := []error{c.writers.closeAll()}
+		if _, err := c.Result.FlushHeader(ctx); err != nil {
+			errs = append(errs, err)
+		}
+		errs = append(errs, flushLogs(ctx))
+		err = errors.Join(errs...)
+	}()
+
+	select {
+	case <-flushed:
+	case <-ctx.Done():
+	}
+	c.rootCancel()
+	<-flushed
+	if ctxErr := ctx.Err(); ctxErr != nil && !errors.Is(err, ctxErr) {
+		err = errors.Join(ctxErr, err)
+	}
+	return err
+}
+
+// derive returns ctx, additionally cancelled when c shuts down.
+func (c *Client) derive(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(c.root, func() { cancel(ErrShutdown) })
+	return ctx, func() {
+		stop()
+		cancel(context.Canceled)
+	}
+}
@@ -0,0 +1,165 @@
+package cafesdk_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	cafesdk "test/GoSdk"
+)
+
+func TestOnShutdownFlushesBeforeDone(t *testing.T) {
+	useLogBuffering(t)
+	client, srv := newTestClient(t)
+	cafesdk.UseDefaultClient(t, client)
+
+	runCtx, done := cafesdk.OnShutdown(context.Background(), 5*time.Second)
+	w := client.Result.NewWriter(runCtx, cafesdk.WriterOptions{BatchSize: 100, FlushInterval: time.Hour})
+	if err := w.Write(`{"n":1}`); err != nil {
+		t.Fatal(err)
+	}
+	client.Log.Info(runCtx, "working")
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("done not closed after SIGTERM")
+	}
+
+	if runCtx.Err() == nil {
+		t.Error("run context not cancelled by the signal")
+	}
+	if got := srv.Data(); !equalStrings(got, []string{`{"n":1}`}) {
+		t.Errorf("server data = %q, want the buffered record", got)
+	}
+	var texts []string
+	for _, line := range srv.Logs() {
+		texts = append(texts, line.Text)
+	}
+	if len(texts) != 2 || texts[0] != "working" || !strings.Contains(texts[1], "signal terminated") {
+		t.Errorf("log lines = %q, want the buffered line then the signal status", texts)
+	}
+	if _, err := client.Result.PushData(context.Background(), `{}`); !errors.Is(err, cafesdk.ErrClosed) {
+		t.Errorf("PushData after shutdown = %v, want ErrClosed", err)
+	}
+}
+
+func TestOnShutdownDoneWithoutSignal(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	_, done := cafesdk.OnShutdown(ctx, time.Second)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("done not closed after the parent context ended")
+	}
+}
+
+// exits reports whether stop returns within a second.
+func exits(stop func()) bool {
+	stopped := make(chan struct{})
+	go func() {
+		stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return true
+	case <-time.After(time.Second):
+		return false
+	}
+}
+
+func TestShutdownStopsGoroutinesAndFlushes(t *testing.T) {
+	clock := useFakeClock(t)
+	useLogBuffering(t)
+	client, srv := newTestClient(t)
+	cafesdk.UseDefaultClient(t, client)
+	ctx := context.Background()
+
+	// One ticker is the log buffer's, the other the heartbeat's.
+	h := cafesdk.StartHeartbeat(ctx, time.Second)
+	clock.BlockUntil(2)
+	clock.Advance(time.Second)
+	waitForBeats(t, srv, 1)
+
+	w := client.Result.NewWriter(ctx, cafesdk.WriterOptions{BatchSize: 100, FlushInterval: time.Hour})
+	for _, record := range []string{`{"n":1}`, `{"n":2}`} {
+		if err := w.Write(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	client.Log.Info(ctx, "buffered")
+
+	if err := cafesdk.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	if got := srv.Data(); !equalStrings(got, []string{`{"n":1}`, `{"n":2}`}) {
+		t.Errorf("server data = %q, want both buffered records", got)
+	}
+	if !slices.Contains(logTexts(srv), "buffered") {
+		t.Errorf("logs = %q, want the buffered line flushed", logTexts(srv))
+	}
+	if !exits(h.Stop) {
+		t.Error("heartbeat goroutine still running after Shutdown")
+	}
+	if !exits(func() { w.Close() }) {
+		t.Error("writer goroutine still running after Shutdown")
+	}
+	if err := w.Write(`{"n":3}`); !errors.Is(err, cafesdk.ErrWriterClosed) {
+		t.Errorf("Write after Shutdown = %v, want ErrWriterClosed", err)
+	}
+
+	n := len(beats(srv))
+	clock.Advance(10 * time.Second)
+	time.Sleep(20 * time.Millisecond)
+	if got := len(beats(srv)); got != n {
+		t.Errorf("got %d heartbeats after Shutdown, want none", got-n)
+	}
+	if _, err := client.Result.PushData(ctx, `{}`); !errors.Is(err, cafesdk.ErrShutdown) {
+		t.Errorf("PushData after Shutdown = %v, want ErrShutdown", err)
+	}
+}
+
+func TestShutdownAbandonsFlushAtDeadline(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.Delay(cafesdk.MethodPushData, 2*time.Second)
+
+	w := client.Result.NewWriter(context.Background(), cafesdk.WriterOptions{BatchSize: 100, FlushInterval: time.Hour})
+	w.Write(`{"n":1}`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := client.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "1 records not delivered") {
+		t.Errorf("Shutdown = %v, want DeadlineExceeded and the undelivered record", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Shutdown took %v, want it to give up at the deadline", elapsed)
+	}
+}
+
+func TestShutdownLeavesOtherClientsRunning(t *testing.T) {
+	client, _ := newTestClient(t)
+	other, otherSrv := newTestClient(t)
+
+	if err := client.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Result.PushData(context.Background(), `{}`); err != nil {
+		t.Errorf("PushData on another client = %v", err)
+	}
+	if got := len(otherSrv.Data()); got != 1 {
+		t.Errorf("other server got %d records, want 1", got)
+	}
+}
@@ -0,0 +1,64 @@
+package cafesdk
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+)
+
+type sourceKey struct{}
+
+var sourceField atomic.Pointer[string]
+
+// WithSource returns a copy of ctx that tags the records pushed with it as
+// coming from source, such as the input URL that produced them. Tags are
+// only written once SetSourceField names the field to put them in.
+func WithSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, sourceKey{}, source)
+}
+
+// SourceFromContext returns the source set with WithSource, or "".
+func SourceFromContext(ctx context.Context) string {
+	s, _ := ctx.Value(sourceKey{}).(string)
+	return s
+}
+
+// SetSourceField makes PushData, and everything built on it, add the source
+// carried by the context (see WithSource) to each JSON object record as the
+// field name, usually "_source". Records pushed without a source, records
+// that are not objects and records that already have the field are sent
+// unchanged. An empty name, the default, turns tagging off.
+func SetSourceField(name string) {
+	sourceField.Store(&name)
+}
+
+// tagSource adds the context's source to jsonString when tagging is on.
+func tagSource(ctx context.Context, jsonString string) string {
+	p := sourceField.Load()
+	if p == nil || *p == "" {
+		return jsonString
+	}
+	source := SourceFromContext(ctx)
+	if source == "" {
+		return jsonString
+	}
+
+	var fields map[string]json.RawMessage
+	if json.Unmarshal([]byte(jsonString), &fields) != nil || fields == nil {
+		return jsonString
+	}
+	if _, ok := fields[*p]; ok {
+		return jsonString
+	}
+
+	// Marshalling a string cannot fail.
+	name, _ := json.Marshal(*p)
+	value, _ := json.Marshal(source)
+	tag := string(name) + ":" + string(value)
+	rest := strings.TrimSpace(strings.TrimSpace(jsonString)[1:])
+	if rest == "}" {
+		return "{" + tag + "}"
+	}
+	return "{" + tag + "," + rest
+}
@@ -0,0 +1,75 @@
+package cafesdk_test
+
+import (
+	"context"
+	"testing"
+
+	cafesdk "test/GoSdk"
+)
+
+func useSourceField(t *testing.T, name string) {
+	t.Helper()
+	cafesdk.SetSourceField(name)
+	t.Cleanup(func() { cafesdk.SetSourceField("") })
+}
+
+func TestSourceTagAdded(t *testing.T) {
+	useSourceField(t, "_source")
+	client, srv := newTestClient(t)
+	ctx := cafesdk.WithSource(context.Background(), "https://example.com/list?page=2")
+
+	for _, record := range []string{`{"n":1}`, ` { } `} {
+		if _, err := client.Result.PushData(ctx, record); err != nil {
+			t.Fatalf("PushData(%s): %v", record, err)
+		}
+	}
+	useHeader(t, client, "n")
+	if _, err := client.Result.PushRecord(ctx, cafesdk.NewRecord().Set("n", 3)); err != nil {
+		t.Fatalf("PushRecord: %v", err)
+	}
+
+	want := []string{
+		`{"_source":"https://example.com/list?page=2","n":1}`,
+		`{"_source":"https://example.com/list?page=2"}`,
+		`{"_source":"https://example.com/list?page=2","n":3}`,
+	}
+	if got := srv.Data(); !equalStrings(got, want) {
+		t.Errorf("Data() = %q, want %q", got, want)
+	}
+}
+
+func TestSourceTagOmitted(t *testing.T) {
+	ctx := cafesdk.WithSource(context.Background(), "https://example.com")
+	tests := []struct {
+		name, field string
+		ctx         context.Context
+		record      string
+	}{
+		{"tagging off", "", ctx, `{"n":1}`},
+		{"no source", "_source", context.Background(), `{"n":1}`},
+		{"field already set", "_source", ctx, `{"_source":"mine"}`},
+		{"not an object", "_source", ctx, `[1,2]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useSourceField(t, tt.field)
+			client, srv := newTestClient(t)
+
+			if _, err := client.Result.PushData(tt.ctx, tt.record); err != nil {
+				t.Fatalf("PushData: %v", err)
+			}
+			if got := srv.Data(); !equalStrings(got, []string{tt.record}) {
+				t.Errorf("Data() = %q, want the record unchanged", got)
+			}
+		})
+	}
+}
+
+func TestSourceFromContext(t *testing.T) {
+	if got := cafesdk.SourceFromContext(context.Background()); got != "" {
+		t.Errorf("SourceFromContext(Background) = %q", got)
+	}
+	if got := cafesdk.SourceFromContext(cafesdk.WithSource(context.Background(), "s")); got != "s" {
+		t.Errorf("SourceFromContext = %q, want s", got)
+	}
+}
@@ -0,0 +1,76 @@
+package cafesdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrClearUnsupported is returned by Result.Clear. It wraps
+// errors.ErrUnsupported.
+var ErrClearUnsupported = fmt.Errorf("cafesdk: the platform cannot discard pushed records: %w", errors.ErrUnsupported)
+
+// Clear would discard the records pushed so far in this run, but the
+// platform has no RPC for it, so Clear always fails with
+// ErrClearUnsupported and makes no call. Actors that need to replace
+// preliminary rows should hold them in a Staging and commit only the final
+// set.
+func (_Result) Clear(ctx context.Context) (*Response, error) {
+	return nil, ErrClearUnsupported
+}
+
+// Staging holds records locally until Commit pushes them, so a preliminary
+// set can be discarded with Clear and rebuilt before anything reaches the
+// platform. It is safe for concurrent use.
+type Staging struct {
+	result _Result
+
+	mu      sync.Mutex
+	records []string
+}
+
+// NewStaging returns an empty Staging that commits through r.
+func (r _Result) NewStaging() *Staging {
+	return &Staging{result: r}
+}
+
+// Add stages a JSON record.
+func (s *Staging) Add(jsonString string) {
+	s.mu.Lock()
+	s.records = append(s.records, jsonString)
+	s.mu.Unlock()
+}
+
+// Len returns the number of staged records.
+func (s *Staging) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.records)
+}
+
+// Clear discards every staged record. Records already committed are not
+// affected.
+func (s *Staging) Clear() {
+	s.mu.Lock()
+	s.records = nil
+	s.mu.Unlock()
+}
+
+// Commit pushes the staged records in order with PushBatch and removes the
+// ones delivered; on failure the rest stay staged for another Commit.
+func (s *Staging) Commit(ctx context.Context) (*Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, err := s.result.PushBatch(ctx, s.records)
+	if err != nil {
+		var batchErr *BatchError
+		if errors.As(err, &batchErr) {
+			s.records = s.records[batchErr.Accepted:]
+		}
+		return nil, err
+	}
+	s.records = nil
+	return res, nil
+}
@@ -0,0 +1,44 @@
+package cafesdk_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	cafesdk "test/GoSdk"
+)
+
+func TestClearUnsupported(t *testing.T) {
+	client, srv := newTestClient(t)
+
+	res, err := client.Result.Clear(context.Background())
+	if res != nil || !errors.Is(err, cafesdk.ErrClearUnsupported) || !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Clear = %v, %v, want ErrClearUnsupported", res, err)
+	}
+	if n := len(srv.Calls()); n != 0 {
+		t.Errorf("Clear made %d RPCs, want 0", n)
+	}
+}
+
+func TestStagingClearAndCommit(t *testing.T) {
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+	s := client.Result.NewStaging()
+
+	s.Add(`{"draft":1}`)
+	s.Clear()
+	s.Add(`{"n":1}`)
+	s.Add(`{"n":2}`)
+	if n := len(srv.Calls()); n != 0 {
+		t.Errorf("staging made %d RPCs before Commit", n)
+	}
+	if _, err := s.Commit(ctx); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if got := srv.Data(); !equalStrings(got, []string{`{"n":1}`, `{"n":2}`}) {
+		t.Errorf("Data() = %q, want the records staged after Clear", got)
+	}
+	if s.Len() != 0 {
+		t.Errorf("Len = %d after Commit, want 0", s.Len())
+	}
+}
@@ -0,0 +1,29 @@
+package cafesdk
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+var strictMode atomic.Bool
+
+// SetStrictMode makes PushData, and the helpers built on it, reject any
+// record that is not valid JSON with an ErrInvalidInput error, even when
+// SetJSONValidation is ValidateNone. It catches the empty or partial
+// records that result from ignoring a json.Marshal error, such as
+// b, _ := json.Marshal(v) followed by PushData(ctx, string(b)). A stricter
+// SetJSONValidation mode still applies.
+func SetStrictMode(enabled bool) {
+	strictMode.Store(enabled)
+}
+
+// MustPush is like Push but panics if v cannot be marshalled or the push
+// fails, for callers that prefer to treat a lost record as fatal. Recover
+// turns the panic into a logged failure.
+func (r _Result) MustPush(ctx context.Context, v any) *Response {
+	res, err := r.Push(ctx, v)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
@@ -0,0 +1,91 @@
+package cafesdk_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	cafesdk "test/GoSdk"
+)
+
+func useStrictMode(t *testing.T) {
+	t.Helper()
+	cafesdk.SetStrictMode(true)
+	t.Cleanup(func() { cafesdk.SetStrictMode(false) })
+}
+
+func TestPushMarshalFailureSurfaces(t *testing.T) {
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+
+	for name, v := range map[string]any{
+		"channel": map[string]any{"c": make(chan int)},
+		"NaN":     map[string]float64{"ratio": math.NaN()},
+		"func":    struct{ F func() }{func() {}},
+	} {
+		res, err := client.Result.Push(ctx, v)
+		if err == nil || res != nil || !strings.Contains(err.Error(), "marshal record") {
+			t.Errorf("Push(%s) = %v, %v; want a marshal error", name, res, err)
+		}
+	}
+	if n := len(srv.CallsTo(cafesdk.MethodPushData)); n != 0 {
+		t.Errorf("unmarshalable records made %d pushes, want none", n)
+	}
+}
+
+func TestMustPushPanicsOnMarshalFailure(t *testing.T) {
+	client, srv := newTestClient(t)
+
+	var recovered any
+	func() {
+		defer func() { recovered = recover() }()
+		client.Result.MustPush(context.Background(), map[string]any{"c": make(chan int)})
+	}()
+	err, ok := recovered.(error)
+	if !ok || !strings.Contains(err.Error(), "marshal record") {
+		t.Fatalf("MustPush panicked with %v, want the marshal error", recovered)
+	}
+	if n := len(srv.Data()); n != 0 {
+		t.Errorf("pushed %d records, want none", n)
+	}
+
+	if res := client.Result.MustPush(context.Background(), map[string]int{"n": 1}); res == nil {
+		t.Error("MustPush of a valid record returned nil")
+	}
+	if got := srv.Data(); !equalStrings(got, []string{`{"n":1}`}) {
+		t.Errorf("server data = %q, want the valid record", got)
+	}
+}
+
+func TestStrictModeRejectsEmptyAndPartialJSON(t *testing.T) {
+	useStrictMode(t)
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+
+	// What PushData receives after b, _ := json.Marshal(v) fails, or after
+	// a hand-built record is cut short.
+	for _, record := range []string{"", `{"id":1,"name":`, `{"id":1`} {
+		if _, err := client.Result.PushData(ctx, record); !errors.Is(err, cafesdk.ErrInvalidInput) {
+			t.Errorf("PushData(%q) = %v, want ErrInvalidInput", record, err)
+		}
+	}
+	if n := len(srv.CallsTo(cafesdk.MethodPushData)); n != 0 {
+		t.Fatalf("strict mode let %d invalid records through", n)
+	}
+
+	if _, err := client.Result.PushData(ctx, `{"id":1}`); err != nil {
+		t.Errorf("PushData of a valid record: %v", err)
+	}
+}
+
+func TestStrictModeOffPushesUnchecked(t *testing.T) {
+	client, srv := newTestClient(t)
+	if _, err := client.Result.PushData(context.Background(), `{"id":1`); err != nil {
+		t.Fatalf("PushData without strict mode: %v", err)
+	}
+	if got := srv.Data(); !equalStrings(got, []string{`{"id":1`}) {
+		t.Errorf("server data = %q, want the record sent as is", got)
+	}
+}
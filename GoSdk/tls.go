@@ -0,0 +1,61 @@
+package cafesdk
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// TLSConfig configures a TLS (or mTLS) connection to the platform.
+type TLSConfig struct {
+	// CAFile is a PEM bundle used to verify the server. The system roots are
+	// used when empty.
+	CAFile string
+	// CertFile and KeyFile hold the client certificate for mTLS. Both must be
+	// set together.
+	CertFile string
+	KeyFile  string
+	// ServerName overrides the name checked against the server certificate.
+	ServerName string
+}
+
+// SetTLSConfig enables TLS for the platform connection. Passing nil restores
+// the default insecure transport. Like SetAddress, it must be called before
+// the first SDK call establishes the connection.
+func SetTLSConfig(cfg *TLSConfig) error {
+	return defaultClient.configure("SetTLSConfig", WithTLS(cfg))
+}
+
+func transportCredentials(cfg *TLSConfig) (credentials.TransportCredentials, error) {
+	if cfg == nil {
+		return insecure.NewCredentials(), nil
+	}
+
+	tc := &tls.Config{ServerName: cfg.ServerName}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("cafesdk: read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("cafesdk: no certificates found in %s", cfg.CAFile)
+		}
+		tc.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cafesdk: load client certificate: %w", err)
+		}
+		tc.Certificates = []tls.Certificate{cert}
+	}
+
+	return credentials.NewTLS(tc), nil
+}
@@ -0,0 +1,169 @@
+package cafesdk_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cafesdk "test/GoSdk"
+	"test/GoSdk/cafesdktest"
+
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// testPKI is a self-signed CA with a server and a client certificate
+// issued by it, written as PEM files.
+type testPKI struct {
+	caFile                string
+	pool                  *x509.CertPool
+	server                tls.Certificate
+	clientCert, clientKey string
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	dir := t.TempDir()
+
+	caKey := newKey(t)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "cafesdk test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issue := func(serial int64, usage x509.ExtKeyUsage) ([]byte, *ecdsa.PrivateKey) {
+		key := newKey(t)
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "cafesdk test"},
+			DNSNames:     []string{"platform.test"},
+			IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der, key
+	}
+
+	p := &testPKI{pool: x509.NewCertPool()}
+	p.pool.AddCert(ca)
+	p.caFile = writePEM(t, dir, "ca.pem", "CERTIFICATE", caDER)
+
+	serverDER, serverKey := issue(2, x509.ExtKeyUsageServerAuth)
+	p.server = tls.Certificate{Certificate: [][]byte{serverDER}, PrivateKey: serverKey}
+
+	clientDER, clientKey := issue(3, x509.ExtKeyUsageClientAuth)
+	keyDER, err := x509.MarshalECPrivateKey(clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.clientCert = writePEM(t, dir, "client.pem", "CERTIFICATE", clientDER)
+	p.clientKey = writePEM(t, dir, "client-key.pem", "EC PRIVATE KEY", keyDER)
+	return p
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func writePEM(t *testing.T, dir, name, kind string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTLSHandshake(t *testing.T) {
+	pki := newTestPKI(t)
+	srv := cafesdktest.Start(t, grpc.Creds(credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{pki.server}})))
+
+	client := cafesdk.New(cafesdk.WithAddress(srv.Addr), cafesdk.WithTLS(&cafesdk.TLSConfig{CAFile: pki.caFile, ServerName: "platform.test"}))
+	t.Cleanup(func() { client.Close() })
+	if _, err := client.Result.PushData(context.Background(), `{"tls":true}`); err != nil {
+		t.Fatalf("PushData over TLS: %v", err)
+	}
+	if len(srv.Data()) != 1 {
+		t.Errorf("server got %d records, want 1", len(srv.Data()))
+	}
+}
+
+func TestMutualTLSHandshake(t *testing.T) {
+	pki := newTestPKI(t)
+	srv := cafesdktest.Start(t, grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{pki.server},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pki.pool,
+	})))
+
+	client := cafesdk.New(cafesdk.WithAddress(srv.Addr), cafesdk.WithTLS(&cafesdk.TLSConfig{
+		CAFile:   pki.caFile,
+		CertFile: pki.clientCert,
+		KeyFile:  pki.clientKey,
+	}))
+	t.Cleanup(func() { client.Close() })
+	if _, err := client.Result.PushData(context.Background(), `{"mtls":true}`); err != nil {
+		t.Fatalf("PushData over mTLS: %v", err)
+	}
+}
+
+func TestTLSRejectsUnknownAuthority(t *testing.T) {
+	pki := newTestPKI(t)
+	srv := cafesdktest.Start(t, grpc.Creds(credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{pki.server}})))
+
+	// Without CAFile the system roots, which do not include the test CA,
+	// verify the server.
+	client := cafesdk.New(cafesdk.WithAddress(srv.Addr), cafesdk.WithTLS(&cafesdk.TLSConfig{}))
+	t.Cleanup(func() { client.Close() })
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := client.Result.PushData(ctx, `{}`); err == nil {
+		t.Fatal("PushData succeeded against a server signed by an unknown CA")
+	}
+	if len(srv.Data()) != 0 {
+		t.Errorf("server got %d records, want 0", len(srv.Data()))
+	}
+}
+
+func TestTLSConfigErrors(t *testing.T) {
+	client := cafesdk.New(cafesdk.WithAddress("127.0.0.1:1"), cafesdk.WithTLS(&cafesdk.TLSConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")}))
+	t.Cleanup(func() { client.Close() })
+	if _, err := client.Result.PushData(context.Background(), `{}`); err == nil {
+		t.Fatal("PushData with a missing CA file succeeded")
+	}
+}
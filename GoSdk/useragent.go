@@ -0,0 +1,51 @@
+package cafesdk
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"sync/atomic"
+)
+
+// DefaultUserAgents is a small pool of common desktop browser user agents.
+var DefaultUserAgents = []string{
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:125.0) Gecko/20100101 Firefox/125.0",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_4_1) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4.1 Safari/605.1.15",
+	"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 Edg/124.0.0.0",
+}
+
+// NewUserAgentTransport returns a RoundTripper that sets a User-Agent from
+// agents on requests that do not already carry one, in rotation or at random.
+// An empty agents list selects DefaultUserAgents.
+func NewUserAgentTransport(base http.RoundTripper, agents []string, random bool) http.RoundTripper {
+	if len(agents) == 0 {
+		agents = DefaultUserAgents
+	}
+	return &userAgentTransport{base: base, agents: agents, random: random}
+}
+
+type userAgentTransport struct {
+	base   http.RoundTripper
+	agents []string
+	random bool
+	next   atomic.Uint64
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	var ua string
+	if t.random {
+		ua = t.agents[rand.IntN(len(t.agents))]
+	} else {
+		ua = t.agents[(t.next.Add(1)-1)%uint64(len(t.agents))]
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", ua)
+	return t.base.RoundTrip(req)
+}
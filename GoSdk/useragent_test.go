@@ -0,0 +1,72 @@
+package cafesdk_test
+
+import (
+	"net/http"
+	"testing"
+
+	cafesdk "test/GoSdk"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// uaRecorder is a transport that records the User-Agent of each request.
+func uaRecorder(seen *[]string) http.RoundTripper {
+	return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		*seen = append(*seen, req.Header.Get("User-Agent"))
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})
+}
+
+func TestUserAgentTransportRotates(t *testing.T) {
+	var seen []string
+	agents := []string{"ua-1", "ua-2", "ua-3"}
+	client := &http.Client{Transport: cafesdk.NewUserAgentTransport(uaRecorder(&seen), agents, false)}
+
+	for range 4 {
+		if _, err := client.Get("http://example.com/"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []string{"ua-1", "ua-2", "ua-3", "ua-1"}; !equalStrings(seen, want) {
+		t.Errorf("user agents = %q, want %q", seen, want)
+	}
+}
+
+func TestUserAgentTransportRandomUsesPool(t *testing.T) {
+	var seen []string
+	client := &http.Client{Transport: cafesdk.NewUserAgentTransport(uaRecorder(&seen), nil, true)}
+
+	pool := map[string]bool{}
+	for _, ua := range cafesdk.DefaultUserAgents {
+		pool[ua] = true
+	}
+	for range 20 {
+		if _, err := client.Get("http://example.com/"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, ua := range seen {
+		if !pool[ua] {
+			t.Errorf("user agent %q is not in DefaultUserAgents", ua)
+		}
+	}
+}
+
+func TestUserAgentTransportKeepsExplicitUA(t *testing.T) {
+	var seen []string
+	client := &http.Client{Transport: cafesdk.NewUserAgentTransport(uaRecorder(&seen), []string{"ua-1"}, false)}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+	req.Header.Set("User-Agent", "my-bot/1.0")
+	if _, err := client.Do(req); err != nil {
+		t.Fatal(err)
+	}
+	if !equalStrings(seen, []string{"my-bot/1.0"}) {
+		t.Errorf("user agents = %q, want the explicit one", seen)
+	}
+	if got := req.Header.Get("User-Agent"); got != "my-bot/1.0" {
+		t.Errorf("caller's request User-Agent changed to %q", got)
+	}
+}
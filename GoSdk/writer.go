@@ -0,0 +1,330 @@
+package cafesdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultWriterBatchSize     = 100
+	defaultWriterFlushInterval = time.Second
+	defaultWriterBufferSize    = 1000
+)
+
+// ErrWriterClosed is returned by Writer methods called after Close.
+var ErrWriterClosed = errors.New("cafesdk: writer is closed")
+
+// writerSet tracks a Client's open writers so that closing the Client
+// closes, and so drains, them.
+type writerSet struct {
+	mu   sync.Mutex
+	open map[*Writer]struct{}
+}
+
+func (s *writerSet) add(w *Writer) {
+	s.mu.Lock()
+	if s.open == nil {
+		s.open = map[*Writer]struct{}{}
+	}
+	s.open[w] = struct{}{}
+	s.mu.Unlock()
+}
+
+func (s *writerSet) remove(w *Writer) {
+	s.mu.Lock()
+	delete(s.open, w)
+	s.mu.Unlock()
+}
+
+func (s *writerSet) closeAll() error {
+	s.mu.Lock()
+	writers := make([]*Writer, 0, len(s.open))
+	for w := range s.open {
+		writers = append(writers, w)
+	}
+	s.mu.Unlock()
+
+	var errs []error
+	for _, w := range writers {
+		errs = append(errs, w.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// WriterOptions tunes a Writer. Zero values select the defaults.
+type WriterOptions struct {
+	// BatchSize is the number of buffered records that triggers a flush.
+	// The platform takes one record per PushData RPC, so it sets how often
+	// the Writer flushes, not the size of any message.
+	BatchSize int
+	// FlushInterval is the longest a record waits before being flushed.
+	FlushInterval time.Duration
+	// BufferSize is the capacity of the queue between Write and the
+	// background flusher; Write blocks while it is full.
+	BufferSize int
+}
+
+// Writer buffers records and pushes them in the background in batches. It
+// is safe for concurrent use; records from one goroutine keep their order.
+//
+// Delivery is at-least-once: records from a flush that failed transiently
+// (see IsTransient) stay buffered and are retried by the next flush, so a
+// push that reached the platform but reported an error may be sent again.
+// A record refused for any other reason, such as InvalidArgument, would be
+// refused again, so it is dropped instead of holding back the records
+// behind it; Rejected counts them and Flush and Close report them.
+type Writer struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	opts   WriterOptions
+	result _Result
+
+	mu      sync.RWMutex
+	closed  bool
+	records chan string
+	flushes chan chan error
+	done    chan struct{}
+
+	delivered atomic.Int64
+	rejected  atomic.Int64
+
+	// Owned by the run goroutine.
+	pending   []string
+	err       error
+	rejectErr error // first rejection, reported by Close
+	last      *Response
+}
+
+// NewWriter starts a Writer that pushes records with ctx.
+func (r _Result) NewWriter(ctx context.Context, opts WriterOptions) *Writer {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultWriterBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultWriterFlushInterval
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = defaultWriterBufferSize
+	}
+
+	ctx, cancel := r.c.derive(ctx)
+	w := &Writer{
+		ctx:     ctx,
+		cancel:  cancel,
+		opts:    opts,
+		result:  r,
+		records: make(chan string, opts.BufferSize),
+		flushes: make(chan chan error),
+		done:    make(chan struct{}),
+	}
+	r.c.writers.add(w)
+
+	go w.run()
+	return w
+}
+
+// Write queues a JSON record for pushing.
+func (w *Writer) Write(jsonString string) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return ErrWriterClosed
+	}
+	w.records <- jsonString
+	return nil
+}
+
+// Flush pushes every record written so far and reports the flush error, if
+// any.
+func (w *Writer) Flush() error {
+	w.mu.RLock()
+	closed := w.closed
+	w.mu.RUnlock()
+	if closed {
+		return ErrWriterClosed
+	}
+
+	reply := make(chan error, 1)
+	select {
+	case w.flushes <- reply:
+		return <-reply
+	case <-w.done:
+		return ErrWriterClosed
+	}
+}
+
+// Close stops accepting records, pushes everything still buffered, even
+// after the Writer's context is cancelled, and reports records that could
+// not be delivered. Calling Close again returns the same result.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.records)
+	}
+	w.mu.Unlock()
+
+	<-w.done
+
+	w.result.c.writers.remove(w)
+	w.cancel()
+
+	var errs []error
+	if len(w.pending) > 0 {
+		errs = append(errs, fmt.Errorf("cafesdk: %d records not delivered: %w", len(w.pending), w.err))
+	}
+	if n := w.rejected.Load(); n > 0 {
+		errs = append(errs, fmt.Errorf("cafesdk: %d records rejected: %w", n, w.rejectErr))
+	}
+	return errors.Join(errs...)
+}
+
+// Delivered returns how many records the Writer has pushed so far.
+func (w *Writer) Delivered() int64 {
+	return w.delivered.Load()
+}
+
+// Rejected returns how many records the Writer has dropped because they
+// were refused with a non-transient error.
+func (w *Writer) Rejected() int64 {
+	return w.rejected.Load()
+}
+
+func (w *Writer) run() {
+	defer close(w.done)
+
+	ticker := currentClock().NewTicker(w.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case record, ok := <-w.records:
+			if !ok {
+				w.finalFlush()
+				return
+			}
+			w.pending = append(w.pending, record)
+			if len(w.pending) >= w.opts.BatchSize {
+				w.flush()
+			}
+		case <-ticker.C():
+			w.flush()
+		case reply := <-w.flushes:
+			w.drain()
+			reply <- w.flush()
+		}
+	}
+}
+
+// finalFlush pushes what is left once the Writer is closed. Write accepted
+// those records, so they are pushed even if the Writer's context is already
+// cancelled, as OnShutdown's is before it closes writers; Shutdown still ends
+// the push.
+func (w *Writer) finalFlush() {
+	ctx, cancel := w.result.c.derive(context.WithoutCancel(w.ctx))
+	defer cancel()
+	w.ctx = ctx
+	w.flush()
+}
+
+// drain moves records already queued into pending without blocking.
+func (w *Writer) drain() {
+	for {
+		select {
+		case record, ok := <-w.records:
+			if !ok {
+				return
+			}
+			w.pending = append(w.pending, record)
+		default:
+			return
+		}
+	}
+}
+
+func (w *Writer) flush() error {
+	var rejected int
+	var rejectErr error
+	for len(w.pending) > 0 {
+		res, err := w.result.PushBatch(w.ctx, w.pending)
+		if err == nil {
+			w.delivered.Add(int64(len(w.pending)))
+			w.last = res
+			w.pending = w.pending[:0]
+			break
+		}
+
+		var batchErr *BatchError
+		if errors.As(err, &batchErr) {
+			w.delivered.Add(int64(batchErr.Accepted))
+			w.pending = w.pending[batchErr.Accepted:]
+		}
+		if batchErr == nil || !recordRefused(w.ctx, batchErr.Err) {
+			w.err = err
+			return err
+		}
+		w.pending = w.pending[1:]
+		w.rejected.Add(1)
+		rejected++
+		if rejectErr == nil {
+			rejectErr = batchErr.Err
+		}
+		if w.rejectErr == nil {
+			w.rejectErr = batchErr.Err
+		}
+	}
+
+	w.err = nil
+	if rejected > 0 {
+		return fmt.Errorf("cafesdk: %d records rejected: %w", rejected, rejectErr)
+	}
+	return nil
+}
+
+// recordRefused reports whether err, from pushing a single record with
+// ctx, refused that record itself, so that pushing it again would fail the
+// same way. Transient failures and the SDK being closed, shut down or
+// short-circuited say nothing about the record.
+func recordRefused(ctx context.Context, err error) bool {
+	switch {
+	case ctx.Err() != nil, IsTransient(err),
+		errors.Is(err, ErrClosed), errors.Is(err, ErrShutdown), errors.Is(err, ErrCircuitOpen),
+		errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	}
+	return true
+}
+
+// Stream pushes records as they are produced. The platform has no streaming
+// RPC, so a Stream is backed by a Writer: Send blocks while its buffer is
+// full, which gives the producer backpressure.
+type Stream struct {
+	w *Writer
+}
+
+// OpenStream starts a Stream that pushes records with ctx.
+func (r _Result) OpenStream(ctx context.Context) *Stream {
+	return &Stream{w: r.NewWriter(ctx, WriterOptions{})}
+}
+
+// Send queues a JSON record on the stream.
+func (s *Stream) Send(jsonString string) error {
+	return s.w.Write(jsonString)
+}
+
+// CloseAndRecv pushes the remaining records and returns the platform's
+// response to the last one; Count then reports the total delivered.
+func (s *Stream) CloseAndRecv() (*Response, error) {
+	err := s.w.Close()
+	return s.w.last, err
+}
+
+// Count returns how many records the stream has delivered.
+func (s *Stream) Count() int64 {
+	return s.w.Delivered()
+}
@@ -0,0 +1,159 @@
+package cafesdk_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	cafesdk "test/GoSdk"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestWriterDeliversAllOnClose(t *testing.T) {
+	client, srv := newTestClient(t)
+	w := client.Result.NewWriter(context.Background(), cafesdk.WriterOptions{BatchSize: 500, FlushInterval: time.Hour})
+
+	want := records(10_000)
+	for _, record := range want {
+		if err := w.Write(record); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if got := srv.Data(); !equalStrings(got, want) {
+		t.Errorf("server got %d records, want all 10000 in order", len(got))
+	}
+	if n := w.Delivered(); n != 10_000 {
+		t.Errorf("Delivered = %d, want 10000", n)
+	}
+	if err := w.Write(`{}`); !errors.Is(err, cafesdk.ErrWriterClosed) {
+		t.Errorf("Write after Close = %v, want ErrWriterClosed", err)
+	}
+}
+
+func TestWriterFlush(t *testing.T) {
+	client, srv := newTestClient(t)
+	w := client.Result.NewWriter(context.Background(), cafesdk.WriterOptions{BatchSize: 100, FlushInterval: time.Hour})
+	defer w.Close()
+
+	w.Write(`{"n":1}`)
+	w.Write(`{"n":2}`)
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if n := len(srv.Data()); n != 2 {
+		t.Errorf("server got %d records after Flush, want 2", n)
+	}
+}
+
+func TestWriterCloseReportsUndelivered(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.Fail(cafesdk.MethodPushData, status.Error(codes.Unavailable, "down"))
+	w := client.Result.NewWriter(context.Background(), cafesdk.WriterOptions{})
+
+	w.Write(`{"n":1}`)
+	w.Write(`{"n":2}`)
+	err := w.Close()
+	if err == nil {
+		t.Fatal("Close succeeded although every push failed")
+	}
+	if !strings.Contains(err.Error(), "2 records not delivered") {
+		t.Errorf("Close = %v, want it to count the 2 undelivered records", err)
+	}
+}
+
+func TestWriterDropsRejectedRecord(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.FailFunc(cafesdk.MethodPushData, func(call int) error {
+		if call == 3 {
+			return status.Error(codes.InvalidArgument, "bad record")
+		}
+		return nil
+	})
+	w := client.Result.NewWriter(context.Background(), cafesdk.WriterOptions{BatchSize: 100, FlushInterval: time.Hour})
+
+	all := records(6)
+	for _, record := range all {
+		w.Write(record)
+	}
+	err := w.Flush()
+	if !errors.Is(err, cafesdk.ErrInvalidInput) || !strings.Contains(err.Error(), "1 records rejected") {
+		t.Fatalf("Flush = %v, want the rejection reported", err)
+	}
+	want := append(append([]string{}, all[:2]...), all[3:]...)
+	if got := srv.Data(); !equalStrings(got, want) {
+		t.Errorf("server data = %q, want every record but the third", got)
+	}
+
+	w.Write(`{"later":true}`)
+	if err := w.Flush(); err != nil {
+		t.Errorf("Flush after the rejection = %v, want nil", err)
+	}
+	if n := len(srv.Data()); n != 6 {
+		t.Errorf("server got %d records, want 6", n)
+	}
+
+	for range 2 {
+		err = w.Close()
+		if !strings.Contains(err.Error(), "1 records rejected") || strings.Contains(err.Error(), "not delivered") {
+			t.Errorf("Close = %v, want only the rejection", err)
+		}
+	}
+	if d, r := w.Delivered(), w.Rejected(); d != 6 || r != 1 {
+		t.Errorf("Delivered, Rejected = %d, %d; want 6, 1", d, r)
+	}
+}
+
+func TestWriterKeepsRecordsOnTransientFailure(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.FailFunc(cafesdk.MethodPushData, func(call int) error {
+		if call == 2 {
+			return status.Error(codes.Unavailable, "blip")
+		}
+		return nil
+	})
+	w := client.Result.NewWriter(context.Background(), cafesdk.WriterOptions{BatchSize: 100, FlushInterval: time.Hour})
+
+	all := records(4)
+	for _, record := range all {
+		w.Write(record)
+	}
+	if err := w.Flush(); !errors.Is(err, cafesdk.ErrUnavailable) {
+		t.Fatalf("first Flush = %v, want ErrUnavailable", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got := srv.Data(); !equalStrings(got, all) || w.Rejected() != 0 {
+		t.Errorf("server data = %q, rejected %d; want all 4 records retried", got, w.Rejected())
+	}
+}
+
+func TestStreamReportsTotal(t *testing.T) {
+	client, srv := newTestClient(t)
+
+	s := client.Result.OpenStream(context.Background())
+	want := records(1000)
+	for _, record := range want {
+		if err := s.Send(record); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+	res, err := s.CloseAndRecv()
+	if err != nil || !res.OK() {
+		t.Fatalf("CloseAndRecv = %v, %v", res, err)
+	}
+	if n := s.Count(); n != 1000 {
+		t.Errorf("Count() = %d, want 1000", n)
+	}
+	if got := srv.Data(); !equalStrings(got, want) {
+		t.Errorf("server received %d records, want all 1000 in order", len(got))
+	}
+}